@@ -0,0 +1,485 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AffectedPackage identifies the software package affected by a
+// vulnerability and the versions involved.
+type AffectedPackage struct {
+	PURL             string   `json:"purl"`                        // Mandatory. Package URL of the package, e.g.: "pkg:npm/lodash".
+	InstalledVersion string   `json:"installed_version,omitempty"` // Version of the package found in the target.
+	FixedVersions    []string `json:"fixed_versions,omitempty"`    // Versions that fix the vulnerability.
+	VulnerableRange  string   `json:"vulnerable_range,omitempty"`  // Vulnerable versions in vers syntax, e.g.: "vers:npm/>=4.0.0|<4.17.21".
+}
+
+// Version returns the installed version of the package, taking it from the
+// PURL when not explicitly set.
+func (p AffectedPackage) Version() string {
+	if p.InstalledVersion != "" {
+		return p.InstalledVersion
+	}
+	if purl, err := ParsePackageURL(p.PURL); err == nil {
+		return purl.Version
+	}
+	return ""
+}
+
+// Validate checks that the package URL and the vulnerable range are well
+// formed and consistent with the installed and fixed versions.
+func (p AffectedPackage) Validate() error {
+	if p.PURL == "" {
+		return errors.New("affected package is missing purl")
+	}
+	purl, err := ParsePackageURL(p.PURL)
+	if err != nil {
+		return err
+	}
+	if purl.Version != "" && p.InstalledVersion != "" && purl.Version != p.InstalledVersion {
+		return fmt.Errorf("affected package purl version %s does not match installed version %s", purl.Version, p.InstalledVersion)
+	}
+	if p.VulnerableRange == "" {
+		return nil
+	}
+	r, err := ParseVersRange(p.VulnerableRange)
+	if err != nil {
+		return err
+	}
+	if r.Scheme != purl.Type {
+		return fmt.Errorf("vulnerable range scheme %s does not match purl type %s", r.Scheme, purl.Type)
+	}
+	if v := p.Version(); v != "" && !r.Contains(v) {
+		return fmt.Errorf("installed version %s is not in the vulnerable range %s", v, p.VulnerableRange)
+	}
+	for _, v := range p.FixedVersions {
+		if r.Contains(v) {
+			return fmt.Errorf("fixed version %s is in the vulnerable range %s", v, p.VulnerableRange)
+		}
+	}
+	return nil
+}
+
+// DeriveAffectedResource sets the AffectedResource of the vulnerability to
+// the package URL of its affected package including the installed version,
+// and the AffectedResourceString to its human readable "name@version" form.
+// Vulnerabilities without affected package are left unchanged.
+func (v *Vulnerability) DeriveAffectedResource() error {
+	if v.Package == nil {
+		return nil
+	}
+	purl, err := ParsePackageURL(v.Package.PURL)
+	if err != nil {
+		return err
+	}
+	purl.Version = v.Package.Version()
+	v.AffectedResource = purl.String()
+	name := purl.Name
+	if purl.Namespace != "" {
+		name = purl.Namespace + "/" + name
+	}
+	if purl.Version != "" {
+		name += "@" + purl.Version
+	}
+	v.AffectedResourceString = name
+	return nil
+}
+
+// PackageURL is a parsed package URL, as defined in
+// https://github.com/package-url/purl-spec.
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+	Subpath    string
+}
+
+var purlTypeRegexp = regexp.MustCompile(`^[a-z][a-z0-9.+-]*$`)
+
+// ParsePackageURL parses a package URL, normalizing it according to the
+// rules of its type.
+func ParsePackageURL(s string) (PackageURL, error) {
+	var p PackageURL
+	rest := s
+	if !strings.HasPrefix(rest, "pkg:") {
+		return p, fmt.Errorf("invalid purl %q: missing pkg scheme", s)
+	}
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "pkg:"), "/")
+
+	if i := strings.Index(rest, "#"); i >= 0 {
+		var subpath []string
+		for _, seg := range strings.Split(rest[i+1:], "/") {
+			seg, err := url.PathUnescape(seg)
+			if err != nil {
+				return p, fmt.Errorf("invalid purl %q: %w", s, err)
+			}
+			if seg != "" && seg != "." && seg != ".." {
+				subpath = append(subpath, seg)
+			}
+		}
+		p.Subpath, rest = strings.Join(subpath, "/"), rest[:i]
+	}
+	if i := strings.Index(rest, "?"); i >= 0 {
+		p.Qualifiers = map[string]string{}
+		for _, kv := range strings.Split(rest[i+1:], "&") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" {
+				return p, fmt.Errorf("invalid purl %q: malformed qualifier %q", s, kv)
+			}
+			v, err := url.PathUnescape(v)
+			if err != nil {
+				return p, fmt.Errorf("invalid purl %q: %w", s, err)
+			}
+			if v != "" {
+				p.Qualifiers[strings.ToLower(k)] = v
+			}
+		}
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		v, err := url.PathUnescape(rest[i+1:])
+		if err != nil {
+			return p, fmt.Errorf("invalid purl %q: %w", s, err)
+		}
+		p.Version, rest = v, rest[:i]
+	}
+
+	rest = strings.Trim(rest, "/")
+	typ, rest, ok := strings.Cut(rest, "/")
+	if !ok {
+		return p, fmt.Errorf("invalid purl %q: missing name", s)
+	}
+	p.Type = strings.ToLower(typ)
+	if !purlTypeRegexp.MatchString(p.Type) {
+		return p, fmt.Errorf("invalid purl %q: invalid type %q", s, typ)
+	}
+	segments := strings.Split(rest, "/")
+	for i, seg := range segments {
+		seg, err := url.PathUnescape(seg)
+		if err != nil {
+			return p, fmt.Errorf("invalid purl %q: %w", s, err)
+		}
+		segments[i] = seg
+	}
+	p.Name = segments[len(segments)-1]
+	if p.Name == "" {
+		return p, fmt.Errorf("invalid purl %q: missing name", s)
+	}
+	var namespace []string
+	for _, seg := range segments[:len(segments)-1] {
+		if seg != "" {
+			namespace = append(namespace, seg)
+		}
+	}
+	p.Namespace = strings.Join(namespace, "/")
+
+	switch p.Type {
+	case "bitbucket", "github", "golang", "npm", "composer", "deb", "apk":
+		p.Namespace = strings.ToLower(p.Namespace)
+		if p.Type != "golang" {
+			p.Name = strings.ToLower(p.Name)
+		}
+	case "pypi":
+		p.Name = strings.ReplaceAll(strings.ToLower(p.Name), "_", "-")
+	}
+	if p.Type == "maven" && p.Namespace == "" {
+		return p, fmt.Errorf("invalid purl %q: maven packages require a namespace", s)
+	}
+	return p, nil
+}
+
+// String returns the canonical form of the package URL.
+func (p PackageURL) String() string {
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(p.Type)
+	b.WriteString("/")
+	if p.Namespace != "" {
+		for _, seg := range strings.Split(p.Namespace, "/") {
+			b.WriteString(purlEscape(seg))
+			b.WriteString("/")
+		}
+	}
+	b.WriteString(purlEscape(p.Name))
+	if p.Version != "" {
+		b.WriteString("@")
+		b.WriteString(purlEscape(p.Version))
+	}
+	if len(p.Qualifiers) > 0 {
+		keys := make([]string, 0, len(p.Qualifiers))
+		for k := range p.Qualifiers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i == 0 {
+				b.WriteString("?")
+			} else {
+				b.WriteString("&")
+			}
+			b.WriteString(k)
+			b.WriteString("=")
+			b.WriteString(purlEscape(p.Qualifiers[k]))
+		}
+	}
+	if p.Subpath != "" {
+		b.WriteString("#")
+		for i, seg := range strings.Split(p.Subpath, "/") {
+			if i > 0 {
+				b.WriteString("/")
+			}
+			b.WriteString(purlEscape(seg))
+		}
+	}
+	return b.String()
+}
+
+// purlEscape percent-encodes a component of a package URL. Unlike in a URL
+// path, "@" separates the version so it must be encoded, while ":" is left
+// as is.
+func purlEscape(s string) string {
+	s = strings.ReplaceAll(url.PathEscape(s), "%3A", ":")
+	return strings.ReplaceAll(s, "@", "%40")
+}
+
+// Comparators of a vers constraint.
+const (
+	VersEqual          = "="
+	VersNotEqual       = "!="
+	VersLess           = "<"
+	VersLessOrEqual    = "<="
+	VersGreater        = ">"
+	VersGreaterOrEqual = ">="
+	VersAny            = "*"
+)
+
+// VersRange is a range of versions expressed in the vers syntax, as defined
+// in https://github.com/package-url/purl-spec/blob/master/VERSION-RANGE-SPEC.rst.
+type VersRange struct {
+	Scheme      string
+	Constraints []VersConstraint
+}
+
+// VersConstraint is a single constraint of a vers range.
+type VersConstraint struct {
+	Comparator string
+	Version    string
+}
+
+// ParseVersRange parses a range of versions in the vers syntax, e.g.:
+// "vers:npm/>=1.0.0|<1.2.3|!=1.1.0".
+func ParseVersRange(s string) (VersRange, error) {
+	var r VersRange
+	rest := strings.TrimSpace(s)
+	if !strings.HasPrefix(rest, "vers:") {
+		return r, fmt.Errorf("invalid vers range %q: missing vers scheme", s)
+	}
+	scheme, constraints, ok := strings.Cut(strings.TrimPrefix(rest, "vers:"), "/")
+	if !ok || scheme == "" || constraints == "" {
+		return r, fmt.Errorf("invalid vers range %q: missing versioning scheme or constraints", s)
+	}
+	r.Scheme = strings.ToLower(scheme)
+	constraints = strings.ReplaceAll(constraints, " ", "")
+	if constraints == VersAny {
+		r.Constraints = []VersConstraint{{Comparator: VersAny}}
+		return r, nil
+	}
+	seen := map[string]bool{}
+	for _, c := range strings.Split(constraints, "|") {
+		var vc VersConstraint
+		for _, op := range []string{VersGreaterOrEqual, VersLessOrEqual, VersNotEqual, VersLess, VersGreater, VersEqual} {
+			if strings.HasPrefix(c, op) {
+				vc.Comparator, c = op, strings.TrimPrefix(c, op)
+				break
+			}
+		}
+		if vc.Comparator == "" {
+			vc.Comparator = VersEqual
+		}
+		v, err := url.PathUnescape(c)
+		if err != nil || v == "" {
+			return r, fmt.Errorf("invalid vers range %q: invalid constraint %q", s, c)
+		}
+		if seen[v] {
+			return r, fmt.Errorf("invalid vers range %q: duplicated version %s", s, v)
+		}
+		seen[v] = true
+		vc.Version = v
+		r.Constraints = append(r.Constraints, vc)
+	}
+	sort.SliceStable(r.Constraints, func(i, j int) bool {
+		return CompareVersions(r.Scheme, r.Constraints[i].Version, r.Constraints[j].Version) < 0
+	})
+	return r, nil
+}
+
+// String returns the canonical form of the range.
+func (r VersRange) String() string {
+	var cs []string
+	for _, c := range r.Constraints {
+		if c.Comparator == VersAny {
+			cs = append(cs, VersAny)
+			continue
+		}
+		op := c.Comparator
+		if op == VersEqual {
+			op = ""
+		}
+		cs = append(cs, op+url.PathEscape(c.Version))
+	}
+	return "vers:" + r.Scheme + "/" + strings.Join(cs, "|")
+}
+
+// Contains returns true if the version is in the range, following the
+// algorithm of the vers specification.
+func (r VersRange) Contains(version string) bool {
+	var ranges []VersConstraint
+	for _, c := range r.Constraints {
+		switch c.Comparator {
+		case VersAny:
+			return true
+		case VersEqual:
+			if CompareVersions(r.Scheme, version, c.Version) == 0 {
+				return true
+			}
+		case VersNotEqual:
+			if CompareVersions(r.Scheme, version, c.Version) == 0 {
+				return false
+			}
+		default:
+			ranges = append(ranges, c)
+		}
+	}
+	for i, c := range ranges {
+		lower := c.Comparator == VersGreater || c.Comparator == VersGreaterOrEqual
+		if i == 0 && !lower && r.satisfies(version, c) {
+			return true
+		}
+		if i == len(ranges)-1 && lower && r.satisfies(version, c) {
+			return true
+		}
+		if i+1 < len(ranges) && lower {
+			next := ranges[i+1]
+			upper := next.Comparator == VersLess || next.Comparator == VersLessOrEqual
+			if upper && r.satisfies(version, c) && r.satisfies(version, next) {
+				return true
+			}
+		}
+	}
+	// With no range constraints, a version is in the range if it is not
+	// excluded by a != constraint and there are no = constraints.
+	if len(ranges) == 0 {
+		for _, c := range r.Constraints {
+			if c.Comparator == VersEqual {
+				return false
+			}
+		}
+		return len(r.Constraints) > 0
+	}
+	return false
+}
+
+func (r VersRange) satisfies(version string, c VersConstraint) bool {
+	cmp := CompareVersions(r.Scheme, version, c.Version)
+	switch c.Comparator {
+	case VersLess:
+		return cmp < 0
+	case VersLessOrEqual:
+		return cmp <= 0
+	case VersGreater:
+		return cmp > 0
+	case VersGreaterOrEqual:
+		return cmp >= 0
+	}
+	return false
+}
+
+// CompareVersions compares two versions of a versioning scheme, returning
+// -1, 0 or 1. Versions are compared segment by segment, numeric segments
+// numerically and the rest lexically. For schemes following semantic
+// versioning, a pre-release is lower than its release and build metadata is
+// ignored. This is an approximation for schemes with more elaborate rules.
+func CompareVersions(scheme, a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	switch scheme {
+	case "deb", "rpm", "apk", "alpine":
+		// The dash separates the upstream version from the package
+		// revision, it does not denote a pre-release.
+		return compareSegments(a, b)
+	}
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	aMain, aPre, aHasPre := strings.Cut(a, "-")
+	bMain, bPre, bHasPre := strings.Cut(b, "-")
+	if c := compareSegments(aMain, bMain); c != 0 {
+		return c
+	}
+	switch {
+	case !aHasPre && !bHasPre:
+		return 0
+	case !aHasPre:
+		return 1
+	case !bHasPre:
+		return -1
+	}
+	return compareSegments(aPre, bPre)
+}
+
+var versionSegmentRegexp = regexp.MustCompile(`[0-9]+|[A-Za-z]+`)
+
+func compareSegments(a, b string) int {
+	as := versionSegmentRegexp.FindAllString(a, -1)
+	bs := versionSegmentRegexp.FindAllString(b, -1)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareSegment(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareSegment(x, y string) int {
+	xn, xErr := strconv.ParseUint(orZero(x), 10, 64)
+	yn, yErr := strconv.ParseUint(orZero(y), 10, 64)
+	switch {
+	case xErr == nil && yErr == nil:
+		switch {
+		case xn < yn:
+			return -1
+		case xn > yn:
+			return 1
+		}
+		return 0
+	case xErr == nil:
+		// Numeric segments are greater than alphabetic ones, so 1.0.1 > 1.0.rc.
+		return 1
+	case yErr == nil:
+		return -1
+	}
+	return strings.Compare(x, y)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
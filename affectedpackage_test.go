@@ -0,0 +1,164 @@
+package report
+
+import "testing"
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		purl    string
+		want    string
+		wantErr bool
+	}{
+		{"NPMScoped", "pkg:npm/%40Angular/Core@12.0.0", "pkg:npm/%40angular/core@12.0.0", false},
+		{"PyPINameNormalized", "pkg:pypi/Django_Rest@3.0", "pkg:pypi/django-rest@3.0", false},
+		{"MavenQualifiersSorted", "pkg:maven/org.apache/commons-text@1.9?type=jar&classifier=sources", "pkg:maven/org.apache/commons-text@1.9?classifier=sources&type=jar", false},
+		{"Subpath", "pkg:golang/github.com/gorilla/context@v1.1.1#api/./", "pkg:golang/github.com/gorilla/context@v1.1.1#api", false},
+		{"DebWithDistro", "pkg:deb/debian/curl@7.50.3-1?arch=i386&distro=jessie", "pkg:deb/debian/curl@7.50.3-1?arch=i386&distro=jessie", false},
+		{"MissingScheme", "npm/lodash@1.0.0", "", true},
+		{"MissingName", "pkg:npm", "", true},
+		{"MavenWithoutNamespace", "pkg:maven/commons-text@1.9", "", true},
+		{"InvalidType", "pkg:1npm/lodash", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePackageURL(tt.purl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: have: %v - want error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if p.String() != tt.want {
+				t.Errorf("purl does not match: have: %s - want: %s", p.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestVersRangeContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		vers    string
+		version string
+		want    bool
+	}{
+		{"InsideInterval", "vers:npm/>=4.0.0|<4.17.21", "4.17.20", true},
+		{"UpperBoundExcluded", "vers:npm/>=4.0.0|<4.17.21", "4.17.21", false},
+		{"BelowInterval", "vers:npm/>=4.0.0|<4.17.21", "3.10.1", false},
+		{"ConstraintsUnsorted", "vers:npm/<4.17.21|>=4.0.0", "4.1.0", true},
+		{"SeveralIntervals", "vers:pypi/>=1.0|<1.5|>=2.0|<2.3", "2.1", true},
+		{"BetweenIntervals", "vers:pypi/>=1.0|<1.5|>=2.0|<2.3", "1.7", false},
+		{"OpenLowerBound", "vers:golang/<=v1.2.0", "v0.9.0", true},
+		{"OpenUpperBound", "vers:golang/>1.2.0", "1.10.0", true},
+		{"Equal", "vers:npm/1.0.0|2.0.0", "2.0.0", true},
+		{"NotEqualExcludes", "vers:npm/>=1.0.0|!=1.2.0|<2.0.0", "1.2.0", false},
+		{"Any", "vers:npm/*", "0.0.1", true},
+		{"PreReleaseBeforeRelease", "vers:npm/<1.0.0", "1.0.0-rc.1", true},
+		{"DebRevision", "vers:deb/<7.50.3-2", "7.50.3-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseVersRange(tt.vers)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if r.Contains(tt.version) != tt.want {
+				t.Errorf("unexpected containment of %s in %s: have: %v - want: %v", tt.version, tt.vers, !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVersRangeErrors(t *testing.T) {
+	for _, vers := range []string{"npm/<1.0.0", "vers:npm", "vers:/<1.0.0", "vers:npm/<1.0.0|", "vers:npm/1.0.0|>=1.0.0"} {
+		t.Run(vers, func(t *testing.T) {
+			if _, err := ParseVersRange(vers); err == nil {
+				t.Errorf("expected error parsing %q", vers)
+			}
+		})
+	}
+}
+
+func TestAffectedPackageValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkg     AffectedPackage
+		wantErr bool
+	}{
+		{
+			name: "Valid",
+			pkg: AffectedPackage{
+				PURL:             "pkg:npm/lodash",
+				InstalledVersion: "4.17.20",
+				FixedVersions:    []string{"4.17.21"},
+				VulnerableRange:  "vers:npm/<4.17.21",
+			},
+		},
+		{
+			name: "VersionFromPURL",
+			pkg: AffectedPackage{
+				PURL:            "pkg:npm/lodash@4.17.20",
+				VulnerableRange: "vers:npm/<4.17.21",
+			},
+		},
+		{
+			name:    "MissingPURL",
+			pkg:     AffectedPackage{InstalledVersion: "1.0.0"},
+			wantErr: true,
+		},
+		{
+			name:    "VersionMismatch",
+			pkg:     AffectedPackage{PURL: "pkg:npm/lodash@4.17.20", InstalledVersion: "4.17.19"},
+			wantErr: true,
+		},
+		{
+			name:    "SchemeMismatch",
+			pkg:     AffectedPackage{PURL: "pkg:npm/lodash", VulnerableRange: "vers:pypi/<4.17.21"},
+			wantErr: true,
+		},
+		{
+			name:    "InstalledVersionNotVulnerable",
+			pkg:     AffectedPackage{PURL: "pkg:npm/lodash", InstalledVersion: "4.17.21", VulnerableRange: "vers:npm/<4.17.21"},
+			wantErr: true,
+		},
+		{
+			name:    "FixedVersionVulnerable",
+			pkg:     AffectedPackage{PURL: "pkg:npm/lodash", FixedVersions: []string{"4.17.0"}, VulnerableRange: "vers:npm/<4.17.21"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pkg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: have: %v - want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeriveAffectedResource(t *testing.T) {
+	v := Vulnerability{
+		Summary: "Prototype pollution",
+		Package: &AffectedPackage{
+			PURL:             "pkg:npm/%40babel/traverse?repository_url=https://registry.npmjs.org",
+			InstalledVersion: "7.22.0",
+		},
+	}
+	if err := v.DeriveAffectedResource(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	wantResource := "pkg:npm/%40babel/traverse@7.22.0?repository_url=https:%2F%2Fregistry.npmjs.org"
+	if v.AffectedResource != wantResource {
+		t.Errorf("affected resource does not match: have: %s - want: %s", v.AffectedResource, wantResource)
+	}
+	if v.AffectedResourceString != "@babel/traverse@7.22.0" {
+		t.Errorf("affected resource string does not match: have: %s - want: %s", v.AffectedResourceString, "@babel/traverse@7.22.0")
+	}
+	if err := ValidateVulnerability(v); err != nil {
+		t.Errorf("unexpected validation error: %s", err)
+	}
+}
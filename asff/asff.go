@@ -0,0 +1,398 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package asff converts Vulcan reports to findings in the AWS Security
+// Finding Format (ASFF), ready to be imported into AWS Security Hub.
+package asff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// SchemaVersion is the version of the ASFF the findings conform to.
+const SchemaVersion = "2018-10-08"
+
+// Security Hub limits. Lengths are measured in characters.
+const (
+	MaxBatchSize   = 100
+	MaxFindingSize = 240 * 1024
+
+	MaxIDLength                = 512
+	MaxTitleLength             = 256
+	MaxDescriptionLength       = 1024
+	MaxRecommendationLength    = 512
+	MaxProductFields           = 50
+	MaxProductFieldKeyLength   = 128
+	MaxProductFieldValueLength = 2048
+	MaxResources               = 32
+)
+
+// Severity labels defined by the ASFF.
+const (
+	SeverityLabelInformational = "INFORMATIONAL"
+	SeverityLabelLow           = "LOW"
+	SeverityLabelMedium        = "MEDIUM"
+	SeverityLabelHigh          = "HIGH"
+	SeverityLabelCritical      = "CRITICAL"
+)
+
+const (
+	timeLayout         = "2006-01-02T15:04:05.000Z"
+	truncationSuffix   = "..."
+	defaultPartition   = "aws"
+	findingType        = "Software and Configuration Checks/Vulnerabilities"
+	productFieldPrefix = "vulcan/"
+)
+
+// Config defines the Security Hub the findings are imported into.
+type Config struct {
+	AccountID string // Mandatory. AWS account of the Security Hub.
+	Region    string // Mandatory. AWS region of the Security Hub.
+	Partition string // AWS partition. Defaults to "aws".
+	// ProductArn of the integration. Defaults to the default product of the
+	// account, used for custom integrations.
+	ProductArn string
+}
+
+// Finding is a finding in the AWS Security Finding Format.
+type Finding struct {
+	SchemaVersion   string            `json:"SchemaVersion"`
+	Id              string            `json:"Id"`
+	ProductArn      string            `json:"ProductArn"`
+	GeneratorId     string            `json:"GeneratorId"`
+	AwsAccountId    string            `json:"AwsAccountId"`
+	Types           []string          `json:"Types"`
+	CreatedAt       string            `json:"CreatedAt"`
+	UpdatedAt       string            `json:"UpdatedAt"`
+	FirstObservedAt string            `json:"FirstObservedAt,omitempty"`
+	LastObservedAt  string            `json:"LastObservedAt,omitempty"`
+	Severity        Severity          `json:"Severity"`
+	Title           string            `json:"Title"`
+	Description     string            `json:"Description"`
+	Remediation     *Remediation      `json:"Remediation,omitempty"`
+	SourceUrl       string            `json:"SourceUrl,omitempty"`
+	ProductFields   map[string]string `json:"ProductFields,omitempty"`
+	Resources       []Resource        `json:"Resources"`
+}
+
+// Severity of a finding.
+type Severity struct {
+	Label      string `json:"Label"`
+	Normalized int    `json:"Normalized"`
+	Original   string `json:"Original,omitempty"`
+}
+
+// Remediation of a finding.
+type Remediation struct {
+	Recommendation Recommendation `json:"Recommendation"`
+}
+
+// Recommendation to remediate a finding.
+type Recommendation struct {
+	Text string `json:"Text,omitempty"`
+	Url  string `json:"Url,omitempty"`
+}
+
+// Resource affected by a finding.
+type Resource struct {
+	Type      string `json:"Type"`
+	Id        string `json:"Id"`
+	Partition string `json:"Partition,omitempty"`
+	Region    string `json:"Region,omitempty"`
+
+	Details *ResourceDetails `json:"Details,omitempty"`
+}
+
+// ResourceDetails are the additional details of a resource.
+type ResourceDetails struct {
+	Other map[string]string `json:"Other,omitempty"`
+}
+
+// SeverityLabel maps a Vulcan score to an ASFF severity label.
+func SeverityLabel(score float32) string {
+	switch report.RankSeverity(score) {
+	case report.SeverityNone:
+		return SeverityLabelInformational
+	case report.SeverityLow:
+		return SeverityLabelLow
+	case report.SeverityMedium:
+		return SeverityLabelMedium
+	case report.SeverityHigh:
+		return SeverityLabelHigh
+	}
+	return SeverityLabelCritical
+}
+
+// NormalizedScore maps a Vulcan score in the range [0, 10] to the ASFF
+// normalized score in the range [0, 100].
+func NormalizedScore(score float32) int {
+	n := int(math.Round(float64(score) * 10))
+	if n < 0 {
+		return 0
+	}
+	if n > 100 {
+		return 100
+	}
+	return n
+}
+
+// FindingID returns a stable identifier for a vulnerability, so the findings
+// of successive scans of the same target update the same Security Hub
+// finding, even if the target is written differently. See
+// report.VulnerabilityIdentity.
+func FindingID(r report.Report, v report.FlatVulnerability) string {
+	return "vulcan/" + report.VulnerabilityIdentity(r, v)
+}
+
+// Convert returns one finding for every vulnerability of the report.
+// Vulnerabilities with children are flattened into one finding per child,
+// see report.Vulnerability.Flatten.
+func Convert(r report.Report, c Config) ([]Finding, error) {
+	if c.AccountID == "" || c.Region == "" {
+		return nil, errors.New("config is missing account ID or region")
+	}
+	if c.Partition == "" {
+		c.Partition = defaultPartition
+	}
+	if c.ProductArn == "" {
+		c.ProductArn = fmt.Sprintf("arn:%s:securityhub:%s:%s:product/%s/default", c.Partition, c.Region, c.AccountID, c.AccountID)
+	}
+	var findings []Finding
+	for _, v := range r.FlattenVulnerabilities() {
+		findings = append(findings, convertVulnerability(r, c, v))
+	}
+	return findings, nil
+}
+
+func convertVulnerability(r report.Report, c Config, fv report.FlatVulnerability) Finding {
+	v := fv.Vulnerability
+	resource := Resource{Type: "Other", Id: r.Target, Partition: c.Partition, Region: c.Region}
+	if t, err := report.ParseTarget(r.Target); err == nil {
+		resource.Id = t.Normalized()
+		switch t.Type {
+		case report.AssetTypeAWSAccount:
+			// The finding belongs to the account importing it, the scanned
+			// account is the affected resource.
+			resource.Type, resource.Id = "AwsAccount", "AWS::::Account:"+t.AccountID
+			resource.Partition = t.Partition
+			resource.Details = &ResourceDetails{Other: map[string]string{"AccountId": t.AccountID}}
+		case report.AssetTypeDockerImage:
+			resource.Type = "Container"
+		}
+	}
+
+	updated := r.EndTime
+	if updated.IsZero() {
+		updated = r.StartTime
+	}
+	f := Finding{
+		SchemaVersion: SchemaVersion,
+		Id:            FindingID(r, fv),
+		ProductArn:    c.ProductArn,
+		GeneratorId:   "vulcan-" + strings.TrimPrefix(r.ChecktypeName, "vulcan-"),
+		AwsAccountId:  c.AccountID,
+		Types:         []string{findingType},
+		CreatedAt:     formatTime(r.StartTime),
+		UpdatedAt:     formatTime(updated),
+		Severity: Severity{
+			Label:      SeverityLabel(v.Score),
+			Normalized: NormalizedScore(v.Score),
+			Original:   strconv.FormatFloat(float64(v.Score), 'f', -1, 32),
+		},
+		Title:       Truncate(v.Summary, MaxTitleLength),
+		Description: Truncate(description(v), MaxDescriptionLength),
+		Resources:   []Resource{resource},
+		ProductFields: map[string]string{
+			productFieldPrefix + "CheckID":          r.CheckID,
+			productFieldPrefix + "ChecktypeName":    r.ChecktypeName,
+			productFieldPrefix + "ChecktypeVersion": r.ChecktypeVersion,
+			productFieldPrefix + "Target":           r.Target,
+		},
+	}
+	if !r.StartTime.IsZero() {
+		f.FirstObservedAt = formatTime(r.StartTime)
+		f.LastObservedAt = formatTime(updated)
+	}
+	if len(v.Recommendations) > 0 || len(v.References) > 0 {
+		f.Remediation = &Remediation{Recommendation: Recommendation{
+			Text: Truncate(strings.Join(v.Recommendations, "\n"), MaxRecommendationLength),
+		}}
+		if len(v.References) > 0 {
+			f.Remediation.Recommendation.Url = v.References[0]
+			f.SourceUrl = v.References[0]
+		}
+	}
+	addProductField(f.ProductFields, "AffectedResource", v.AffectedResource)
+	addProductField(f.ProductFields, "Fingerprint", v.Fingerprint)
+	addProductField(f.ProductFields, "Details", v.Details)
+	addProductField(f.ProductFields, "ImpactDetails", v.ImpactDetails)
+	if v.CWEID != 0 {
+		addProductField(f.ProductFields, "CWE", "CWE-"+strconv.FormatUint(uint64(v.CWEID), 10))
+	}
+	return f
+}
+
+func addProductField(fields map[string]string, key, value string) {
+	if value != "" {
+		fields[productFieldPrefix+key] = Truncate(value, MaxProductFieldValueLength)
+	}
+}
+
+// description returns the description of the vulnerability. Vulnerabilities
+// without description nor details are described by their summary.
+func description(v report.Vulnerability) string {
+	if d := v.FullDescription(); d != "" {
+		return d
+	}
+	return v.Summary
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// Truncate shortens s to at most n characters. Truncated strings end with
+// "..." so readers know part of the text is missing. The result only
+// depends on the input, so re-importing the same finding does not produce
+// spurious updates.
+func Truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	keep := n - len(truncationSuffix)
+	if keep < 0 {
+		keep = 0
+	}
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if i == keep {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + truncationSuffix
+}
+
+// Validate checks the finding against the required fields and limits of
+// Security Hub.
+func (f Finding) Validate() error {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"SchemaVersion", f.SchemaVersion},
+		{"Id", f.Id},
+		{"ProductArn", f.ProductArn},
+		{"GeneratorId", f.GeneratorId},
+		{"AwsAccountId", f.AwsAccountId},
+		{"CreatedAt", f.CreatedAt},
+		{"UpdatedAt", f.UpdatedAt},
+		{"Title", f.Title},
+		{"Description", f.Description},
+		{"Severity", f.Severity.Label},
+	} {
+		if field.value == "" {
+			return fmt.Errorf("finding is missing %s", field.name)
+		}
+	}
+	if len(f.Types) == 0 {
+		return errors.New("finding is missing Types")
+	}
+	if len(f.Resources) == 0 || len(f.Resources) > MaxResources {
+		return fmt.Errorf("finding must have between 1 and %d resources", MaxResources)
+	}
+	for _, l := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"Id", f.Id, MaxIDLength},
+		{"GeneratorId", f.GeneratorId, MaxIDLength},
+		{"Title", f.Title, MaxTitleLength},
+		{"Description", f.Description, MaxDescriptionLength},
+	} {
+		if utf8.RuneCountInString(l.value) > l.max {
+			return fmt.Errorf("finding %s is longer than %d characters", l.name, l.max)
+		}
+	}
+	if f.Remediation != nil && utf8.RuneCountInString(f.Remediation.Recommendation.Text) > MaxRecommendationLength {
+		return fmt.Errorf("finding recommendation is longer than %d characters", MaxRecommendationLength)
+	}
+	if len(f.ProductFields) > MaxProductFields {
+		return fmt.Errorf("finding has more than %d product fields", MaxProductFields)
+	}
+	for k, v := range f.ProductFields {
+		if utf8.RuneCountInString(k) > MaxProductFieldKeyLength || utf8.RuneCountInString(v) > MaxProductFieldValueLength {
+			return fmt.Errorf("finding product field %q exceeds the length limits", k)
+		}
+	}
+	for _, t := range []string{f.CreatedAt, f.UpdatedAt} {
+		if _, err := time.Parse(time.RFC3339, t); err != nil {
+			return fmt.Errorf("finding has an invalid timestamp %q", t)
+		}
+	}
+	switch f.Severity.Label {
+	case SeverityLabelInformational, SeverityLabelLow, SeverityLabelMedium, SeverityLabelHigh, SeverityLabelCritical:
+	default:
+		return fmt.Errorf("finding has an invalid severity label %q", f.Severity.Label)
+	}
+	return nil
+}
+
+// ValidateBatch checks that the findings can be sent to Security Hub in a
+// single BatchImportFindings call.
+func ValidateBatch(findings []Finding) error {
+	if len(findings) == 0 || len(findings) > MaxBatchSize {
+		return fmt.Errorf("batch must contain between 1 and %d findings", MaxBatchSize)
+	}
+	for i, f := range findings {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("finding %d: %w", i, err)
+		}
+		b, err := json.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("finding %d: %w", i, err)
+		}
+		if len(b) > MaxFindingSize {
+			return fmt.Errorf("finding %d is larger than %d bytes", i, MaxFindingSize)
+		}
+	}
+	return nil
+}
+
+// Importer sends batches of findings to Security Hub. It is usually
+// implemented by a thin wrapper over the BatchImportFindings operation of
+// the AWS SDK.
+type Importer interface {
+	BatchImportFindings(ctx context.Context, findings []Finding) error
+}
+
+// Import validates the findings and sends them to Security Hub in batches
+// of at most MaxBatchSize findings.
+func Import(ctx context.Context, imp Importer, findings []Finding) error {
+	for start := 0; start < len(findings); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(findings) {
+			end = len(findings)
+		}
+		batch := findings[start:end]
+		if err := ValidateBatch(batch); err != nil {
+			return err
+		}
+		if err := imp.BatchImportFindings(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
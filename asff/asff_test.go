@@ -0,0 +1,211 @@
+package asff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+var testConfig = Config{AccountID: "111111111111", Region: "eu-west-1"}
+
+// fakeSecurityHub is a local stand-in of Security Hub that validates the
+// shape of the batches it receives.
+type fakeSecurityHub struct {
+	batches  [][]Finding
+	findings map[string]Finding
+}
+
+func (s *fakeSecurityHub) BatchImportFindings(ctx context.Context, findings []Finding) error {
+	if err := ValidateBatch(findings); err != nil {
+		return err
+	}
+	if s.findings == nil {
+		s.findings = map[string]Finding{}
+	}
+	s.batches = append(s.batches, findings)
+	for _, f := range findings {
+		s.findings[f.Id] = f
+	}
+	return nil
+}
+
+func testReport(target string, vulns ...report.Vulnerability) report.Report {
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          "ID0",
+			ChecktypeName:    "vulcan-aws-trusted-advisor",
+			ChecktypeVersion: "1",
+			Target:           target,
+			Status:           "FINISHED",
+			StartTime:        time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+			EndTime:          time.Date(2021, 5, 18, 14, 0, 50, 0, time.UTC),
+		},
+		ResultData: report.ResultData{Vulnerabilities: vulns},
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		score          float32
+		wantLabel      string
+		wantNormalized int
+	}{
+		{0, SeverityLabelInformational, 0},
+		{0.1, SeverityLabelLow, 1},
+		{3.9, SeverityLabelLow, 39},
+		{4.0, SeverityLabelMedium, 40},
+		{6.9, SeverityLabelMedium, 69},
+		{8.9, SeverityLabelHigh, 89},
+		{9.0, SeverityLabelCritical, 90},
+		{10, SeverityLabelCritical, 100},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.score), func(t *testing.T) {
+			if l := SeverityLabel(tt.score); l != tt.wantLabel {
+				t.Errorf("unexpected label: have: %s - want: %s", l, tt.wantLabel)
+			}
+			if n := NormalizedScore(tt.score); n != tt.wantNormalized {
+				t.Errorf("unexpected normalized score: have: %d - want: %d", n, tt.wantNormalized)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 10, "this is..."},
+		{"ñññññññññññ", 5, "ññ..."},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("unexpected truncation of %q: have: %q - want: %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	v := report.Vulnerability{
+		Summary:          "Root account without MFA",
+		Score:            8.9,
+		AffectedResource: "root",
+		Fingerprint:      "fp1",
+		Description:      strings.Repeat("d", 2000),
+		Details:          strings.Repeat("x", 3000),
+		Recommendations:  []string{"Enable MFA"},
+		References:       []string{"https://docs.aws.amazon.com/mfa"},
+	}
+	findings, err := Convert(testReport("arn:aws:iam::123456789012:root", v), testConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("unexpected number of findings: %d", len(findings))
+	}
+	f := findings[0]
+	if err := f.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %s", err)
+	}
+	if f.AwsAccountId != testConfig.AccountID {
+		t.Errorf("unexpected account: %s", f.AwsAccountId)
+	}
+	if f.Resources[0].Type != "AwsAccount" || f.Resources[0].Id != "AWS::::Account:123456789012" {
+		t.Errorf("unexpected resources: %+v", f.Resources)
+	}
+	if d := f.Resources[0].Details; d == nil || d.Other["AccountId"] != "123456789012" {
+		t.Errorf("unexpected resource details: %+v", d)
+	}
+	if f.ProductArn != "arn:aws:securityhub:eu-west-1:111111111111:product/111111111111/default" {
+		t.Errorf("unexpected product ARN: %s", f.ProductArn)
+	}
+	if utf8.RuneCountInString(f.Description) != MaxDescriptionLength || !strings.HasSuffix(f.Description, "...") {
+		t.Errorf("description not truncated: %d characters", utf8.RuneCountInString(f.Description))
+	}
+	if utf8.RuneCountInString(f.ProductFields["vulcan/Details"]) != MaxProductFieldValueLength {
+		t.Errorf("details not truncated: %d characters", utf8.RuneCountInString(f.ProductFields["vulcan/Details"]))
+	}
+	if f.CreatedAt != "2021-05-18T13:30:15.000Z" || f.UpdatedAt != "2021-05-18T14:00:50.000Z" {
+		t.Errorf("unexpected times: %s %s", f.CreatedAt, f.UpdatedAt)
+	}
+
+	// A later scan of the same target must update the same finding.
+	r := testReport("123456789012", v)
+	r.StartTime = r.StartTime.Add(24 * time.Hour)
+	later, _ := Convert(r, testConfig)
+	if later[0].Id != f.Id {
+		t.Errorf("finding ID is not stable: %s - %s", later[0].Id, f.Id)
+	}
+}
+
+func TestConvertMissingConfig(t *testing.T) {
+	if _, err := Convert(testReport("example.com"), Config{Region: "eu-west-1"}); err == nil {
+		t.Errorf("expected error for config without account ID")
+	}
+}
+
+func TestImport(t *testing.T) {
+	var vulns []report.Vulnerability
+	for i := 0; i < 250; i++ {
+		vulns = append(vulns, report.Vulnerability{
+			Summary:          fmt.Sprintf("vulnerability %d", i),
+			Score:            3.9,
+			AffectedResource: fmt.Sprintf("resource-%d", i),
+		})
+	}
+	findings, err := Convert(testReport("example.com", vulns...), testConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	hub := &fakeSecurityHub{}
+	if err := Import(context.Background(), hub, findings); err != nil {
+		t.Fatalf("unexpected error importing findings: %s", err)
+	}
+	if len(hub.batches) != 3 || len(hub.batches[2]) != 50 {
+		t.Errorf("unexpected batches: %d", len(hub.batches))
+	}
+	if len(hub.findings) != 250 {
+		t.Errorf("unexpected number of distinct findings: %d", len(hub.findings))
+	}
+	if f := hub.findings[findings[0].Id]; f.Resources[0].Type != "Other" || f.Resources[0].Id != "example.com" {
+		t.Errorf("unexpected resource: %+v", f.Resources)
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	if err := ValidateBatch(nil); err == nil {
+		t.Errorf("expected error for empty batch")
+	}
+	findings, _ := Convert(testReport("example.com", report.Vulnerability{Summary: "s", AffectedResource: "r"}), testConfig)
+	findings[0].Title = ""
+	if err := ValidateBatch(findings); err == nil || err.Error() != "finding 0: finding is missing Title" {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestConvertChildrenSharingFingerprint(t *testing.T) {
+	child := report.Vulnerability{Summary: "Access key not rotated", Score: 3.9, AffectedResource: "user", Fingerprint: "fp1"}
+	parent := report.Vulnerability{Summary: "Access keys not rotated", Vulnerabilities: []report.Vulnerability{child, child}}
+	findings, err := Convert(testReport("123456789012", parent), testConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("unexpected number of findings: %d", len(findings))
+	}
+	if findings[0].Id == findings[1].Id {
+		t.Errorf("children sharing a fingerprint must have different finding IDs")
+	}
+	if err := ValidateBatch(findings); err != nil {
+		t.Errorf("unexpected validation error: %s", err)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const digestPrefix = "sha256:"
+
+// ErrAttachmentNotFound is returned by an AttachmentStore when the requested
+// digest is not present in the store.
+var ErrAttachmentNotFound = errors.New("attachment not found in store")
+
+// AttachmentStore defines a content-addressed storage for attachment data.
+type AttachmentStore interface {
+	// Put stores the data and returns its digest.
+	Put(data []byte) (string, error)
+	// Get returns the data identified by the digest.
+	Get(digest string) ([]byte, error)
+}
+
+// DirStore is an AttachmentStore that keeps the data in files of a local
+// directory, named after the hex encoded SHA-256 of their content.
+type DirStore struct {
+	Dir string
+}
+
+// NewDirStore returns a DirStore rooted at dir, creating the directory if it
+// does not exist.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{Dir: dir}, nil
+}
+
+// Put stores the data in the directory and returns its digest.
+func (s *DirStore) Put(data []byte) (string, error) {
+	digest := Digest(data)
+	path := s.path(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+	// Write to a temporary file first so a partially written file is never
+	// served under a valid digest.
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return digest, nil
+}
+
+// Get returns the data identified by the digest, verifying its integrity.
+func (s *DirStore) Get(digest string) ([]byte, error) {
+	if !validDigest(digest) {
+		return nil, fmt.Errorf("invalid attachment digest %q", digest)
+	}
+	data, err := os.ReadFile(s.path(digest))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if Digest(data) != digest {
+		return nil, fmt.Errorf("attachment data does not match digest %s", digest)
+	}
+	return data, nil
+}
+
+func (s *DirStore) path(digest string) string {
+	return filepath.Join(s.Dir, strings.TrimPrefix(digest, digestPrefix))
+}
+
+// Digest returns the SHA-256 digest of the data in the form "sha256:<hex>".
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+func validDigest(digest string) bool {
+	if !strings.HasPrefix(digest, digestPrefix) {
+		return false
+	}
+	h := strings.TrimPrefix(digest, digestPrefix)
+	if len(h) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(h)
+	return err == nil
+}
+
+// IsExternal returns true if the data of the attachment lives in an
+// AttachmentStore instead of inline.
+func (a Attachment) IsExternal() bool {
+	return len(a.Data) == 0 && a.Digest != ""
+}
+
+// DataSize returns the size in bytes of the attachment data, whether it is
+// inline or stored externally.
+func (a Attachment) DataSize() int {
+	if a.IsExternal() {
+		return a.Size
+	}
+	return len(a.Data)
+}
+
+// SniffContentType returns the content type of the attachment data as
+// detected by http.DetectContentType.
+func (a Attachment) SniffContentType() string {
+	return http.DetectContentType(a.Data)
+}
+
+// VerifyContentType checks that the declared ContentType of the attachment
+// is consistent with the type sniffed from its data. Text based types are
+// considered compatible with each other, and data that can not be
+// identified is accepted for any declared type.
+func (a Attachment) VerifyContentType() error {
+	if a.IsExternal() || len(a.Data) == 0 {
+		return nil
+	}
+	declared, _, err := mime.ParseMediaType(a.ContentType)
+	if err != nil {
+		return fmt.Errorf("attachment %q has an invalid content type %q", a.Name, a.ContentType)
+	}
+	sniffed, _, _ := mime.ParseMediaType(a.SniffContentType())
+	if declared == sniffed || sniffed == "application/octet-stream" {
+		return nil
+	}
+	if isTextMediaType(declared) && isTextMediaType(sniffed) {
+		return nil
+	}
+	return fmt.Errorf("attachment %q declares content type %s but looks like %s", a.Name, declared, sniffed)
+}
+
+func isTextMediaType(t string) bool {
+	if strings.HasPrefix(t, "text/") {
+		return true
+	}
+	switch t {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-yaml", "application/yaml", "image/svg+xml":
+		return true
+	}
+	return strings.HasSuffix(t, "+json") || strings.HasSuffix(t, "+xml")
+}
+
+// ExternalizeAttachments moves the data of every attachment of the report
+// larger than threshold bytes to the store, leaving only its digest and size
+// in the report. A threshold of 0 moves all the non empty attachments.
+func (r *Report) ExternalizeAttachments(s AttachmentStore, threshold int) error {
+	return walkAttachments(r.Vulnerabilities, func(a *Attachment) error {
+		if len(a.Data) == 0 || (threshold > 0 && len(a.Data) <= threshold) {
+			return nil
+		}
+		digest, err := s.Put(a.Data)
+		if err != nil {
+			return err
+		}
+		a.Digest = digest
+		a.Size = len(a.Data)
+		a.Data = nil
+		return nil
+	})
+}
+
+// ResolveAttachments loads from the store the data of every external
+// attachment of the report.
+func (r *Report) ResolveAttachments(s AttachmentStore) error {
+	return walkAttachments(r.Vulnerabilities, func(a *Attachment) error {
+		if !a.IsExternal() {
+			return nil
+		}
+		data, err := s.Get(a.Digest)
+		if err != nil {
+			return fmt.Errorf("resolving attachment %q: %w", a.Name, err)
+		}
+		a.Data = data
+		return nil
+	})
+}
+
+// UnmarshalJSONWithStore unmarshals a JSON to a Report and resolves the
+// external attachments using the given store.
+func (r *Report) UnmarshalJSONWithStore(data []byte, s AttachmentStore) error {
+	if err := json.Unmarshal(data, r); err != nil {
+		return err
+	}
+	return r.ResolveAttachments(s)
+}
+
+func walkAttachments(vulns []Vulnerability, fn func(a *Attachment) error) error {
+	for i := range vulns {
+		v := &vulns[i]
+		for j := range v.Attachments {
+			if err := fn(&v.Attachments[j]); err != nil {
+				return err
+			}
+		}
+		if err := walkAttachments(v.Vulnerabilities, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
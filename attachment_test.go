@@ -0,0 +1,184 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+func reportWithAttachments(attachments ...Attachment) Report {
+	v := vulnerabilityWithScore(3.9)
+	v.Attachments = attachments
+	return Report{
+		CheckData: cd0,
+		ResultData: ResultData{
+			Vulnerabilities: []Vulnerability{v},
+		},
+	}
+}
+
+func TestAttachmentVerifyContentType(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       Attachment
+		wantErr bool
+	}{
+		{
+			name: "PNG",
+			a:    Attachment{Name: "screenshot", ContentType: "image/png", Data: pngHeader},
+		},
+		{
+			name: "JSONDeclaredSniffedAsText",
+			a:    Attachment{Name: "response", ContentType: "application/json", Data: []byte(`{"a":1}`)},
+		},
+		{
+			name: "UnknownData",
+			a:    Attachment{Name: "blob", ContentType: "application/zip", Data: []byte{0x01, 0x02, 0x03}},
+		},
+		{
+			name: "External",
+			a:    Attachment{Name: "external", ContentType: "image/png", Digest: Digest([]byte("html")), Size: 4},
+		},
+		{
+			name:    "HTMLDeclaredAsPNG",
+			a:       Attachment{Name: "fake", ContentType: "image/png", Data: []byte("<html><body>hi</body></html>")},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidContentType",
+			a:       Attachment{Name: "invalid", ContentType: "not a type", Data: pngHeader},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.a.VerifyContentType()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: have: %v - want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportAttachments(t *testing.T) {
+	small := Attachment{Name: "small", ContentType: "text/plain", Data: []byte("12345")}
+	big := Attachment{Name: "big", ContentType: "text/plain", Data: bytes.Repeat([]byte("a"), 20)}
+	external := Attachment{Name: "external", ContentType: "text/plain", Digest: Digest(big.Data), Size: 20}
+
+	tests := []struct {
+		name      string
+		r         Report
+		opts      ValidationOptions
+		errString string
+	}{
+		{
+			name: "NoLimits",
+			r:    reportWithAttachments(small, big),
+		},
+		{
+			name: "WithinLimits",
+			r:    reportWithAttachments(small, big),
+			opts: ValidationOptions{MaxAttachmentSize: 20, MaxReportAttachmentsSize: 25},
+		},
+		{
+			name:      "AttachmentTooBig",
+			r:         reportWithAttachments(small, big),
+			opts:      ValidationOptions{MaxAttachmentSize: 10},
+			errString: `attachment "big" size 20 exceeds the limit of 10 bytes`,
+		},
+		{
+			name:      "ExternalAttachmentTooBig",
+			r:         reportWithAttachments(external),
+			opts:      ValidationOptions{MaxAttachmentSize: 10},
+			errString: `attachment "external" size 20 exceeds the limit of 10 bytes`,
+		},
+		{
+			name:      "ReportAttachmentsTooBig",
+			r:         reportWithAttachments(small, big),
+			opts:      ValidationOptions{MaxReportAttachmentsSize: 24},
+			errString: "report attachments size 25 exceeds the limit of 24 bytes",
+		},
+		{
+			name:      "InvalidDigest",
+			r:         reportWithAttachments(Attachment{Name: "bad", Digest: "md5:1234"}),
+			errString: `attachment "bad" has an invalid digest`,
+		},
+		{
+			name:      "DigestMismatch",
+			r:         reportWithAttachments(Attachment{Name: "bad", ContentType: "text/plain", Data: []byte("a"), Digest: Digest([]byte("b"))}),
+			errString: `attachment "bad" digest does not match its data`,
+		},
+		{
+			name:      "ContentTypeMismatch",
+			r:         reportWithAttachments(Attachment{Name: "fake", ContentType: "text/plain", Data: pngHeader}),
+			opts:      ValidationOptions{VerifyAttachmentContentType: true},
+			errString: `attachment "fake" declares content type text/plain but looks like image/png`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateReportWithOptions(tt.r, tt.opts)
+			var errString string
+			if err != nil {
+				errString = err.Error()
+			}
+			if errString != tt.errString {
+				t.Errorf("report validation failed: have error: %s - want error: %s", errString, tt.errString)
+			}
+		})
+	}
+}
+
+func TestExternalizeAndResolveAttachments(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error creating store: %s", err)
+	}
+	small := Attachment{Name: "small", ContentType: "text/plain", Data: []byte("12345")}
+	big := Attachment{Name: "big", ContentType: "image/png", Data: pngHeader}
+	r := reportWithAttachments(small, big)
+	r.Vulnerabilities[0].AddVulnerabilities(reportWithAttachments(big).Vulnerabilities...)
+
+	if err := r.ExternalizeAttachments(store, 10); err != nil {
+		t.Fatalf("unexpected error externalizing attachments: %s", err)
+	}
+	v := r.Vulnerabilities[0]
+	if v.Attachments[0].IsExternal() {
+		t.Errorf("attachment below threshold should be kept inline")
+	}
+	for _, a := range []Attachment{v.Attachments[1], v.Vulnerabilities[0].Attachments[0]} {
+		if !a.IsExternal() || a.Digest != Digest(pngHeader) || a.Size != len(pngHeader) {
+			t.Errorf("unexpected externalized attachment: %+v", a)
+		}
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling report: %s", err)
+	}
+	var got Report
+	if err := got.UnmarshalJSONWithStore(b, store); err != nil {
+		t.Fatalf("unexpected error unmarshaling report: %s", err)
+	}
+	for _, a := range []Attachment{got.Vulnerabilities[0].Attachments[1], got.Vulnerabilities[0].Vulnerabilities[0].Attachments[0]} {
+		if !bytes.Equal(a.Data, pngHeader) {
+			t.Errorf("attachment data not resolved: have: %q - want: %q", a.Data, pngHeader)
+		}
+	}
+}
+
+func TestDirStoreGetMissing(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error creating store: %s", err)
+	}
+	_, err = store.Get(Digest([]byte("missing")))
+	if !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("unexpected error: have: %v - want: %v", err, ErrAttachmentNotFound)
+	}
+}
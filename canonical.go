@@ -0,0 +1,173 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Canonical returns the canonical form of the report, so reports with the
+// same content have the same canonical form regardless of the order of their
+// vulnerabilities, labels and references and of the time zone of their
+// times. In the canonical form:
+//
+//   - The times are in UTC.
+//   - The vulnerabilities, and their children, are sorted by fingerprint,
+//     summary and affected resource and, when those are equal, by their
+//     content.
+//   - The labels and references of the vulnerabilities are sorted.
+//   - The empty lists of vulnerabilities are nil.
+//
+// The report is not modified.
+func (r Report) Canonical() Report {
+	r.StartTime = r.StartTime.UTC()
+	r.EndTime = r.EndTime.UTC()
+	r.Vulnerabilities = canonicalVulnerabilities(r.Vulnerabilities)
+	return r
+}
+
+func canonicalVulnerabilities(vulns []Vulnerability) []Vulnerability {
+	if len(vulns) == 0 {
+		return nil
+	}
+	type entry struct {
+		v   Vulnerability
+		key canonicalKey
+	}
+	entries := make([]entry, len(vulns))
+	for i, v := range vulns {
+		v = canonicalVulnerability(v)
+		entries[i] = entry{v: v, key: newCanonicalKey(v)}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key.less(entries[j].key)
+	})
+	canonical := make([]Vulnerability, len(entries))
+	for i, e := range entries {
+		canonical[i] = e.v
+	}
+	return canonical
+}
+
+// canonicalVulnerability returns the vulnerability with its labels,
+// references and children in canonical order.
+func canonicalVulnerability(v Vulnerability) Vulnerability {
+	v.Labels = sortedStrings(v.Labels)
+	v.References = sortedStrings(v.References)
+	v.Vulnerabilities = canonicalVulnerabilities(v.Vulnerabilities)
+	return v
+}
+
+// canonicalKey is the key vulnerabilities are sorted by in the canonical
+// order. It is computed once per vulnerability, as it contains its content.
+type canonicalKey struct {
+	fingerprint string
+	summary     string
+	resource    string
+	content     []byte
+}
+
+// newCanonicalKey returns the key of a vulnerability in canonical form.
+func newCanonicalKey(v Vulnerability) canonicalKey {
+	// A vulnerability that can not be marshaled makes CanonicalJSON fail,
+	// so its position is irrelevant.
+	content, _ := json.Marshal(v)
+	return canonicalKey{
+		fingerprint: v.Fingerprint,
+		summary:     v.Summary,
+		resource:    v.AffectedResource,
+		content:     content,
+	}
+}
+
+func (k canonicalKey) less(o canonicalKey) bool {
+	switch {
+	case k.fingerprint != o.fingerprint:
+		return k.fingerprint < o.fingerprint
+	case k.summary != o.summary:
+		return k.summary < o.summary
+	case k.resource != o.resource:
+		return k.resource < o.resource
+	}
+	return bytes.Compare(k.content, o.content) < 0
+}
+
+func sortedStrings(ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	sorted := append([]string(nil), ss...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// CanonicalJSON returns the canonical JSON serialization of a report: the
+// JSON encoding of its canonical form, see Report.Canonical, with the object
+// keys sorted, without insignificant white space and without escaping HTML
+// characters. The attachments are serialized by their digest and size,
+// whether their data is inline or stored externally, so the serialization
+// does not change when the attachments are externalized or resolved, see
+// Report.ExternalizeAttachments and Report.ResolveAttachments.
+func CanonicalJSON(r Report) ([]byte, error) {
+	c := r.Canonical()
+	digestAttachments(c.Vulnerabilities)
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJSON(data)
+}
+
+// digestAttachments replaces the inline data of the attachments of the
+// vulnerabilities by its digest and size. The vulnerabilities must be in
+// canonical form, as their attachments are copied but the vulnerabilities
+// themselves are modified.
+func digestAttachments(vulns []Vulnerability) {
+	for i := range vulns {
+		v := &vulns[i]
+		if v.Attachments != nil {
+			attachments := make([]Attachment, len(v.Attachments))
+			for j, a := range v.Attachments {
+				if len(a.Data) > 0 {
+					a.Digest, a.Size, a.Data = Digest(a.Data), len(a.Data), nil
+				}
+				attachments[j] = a
+			}
+			v.Attachments = attachments
+		}
+		digestAttachments(v.Vulnerabilities)
+	}
+}
+
+// canonicalizeJSON sorts the object keys of a JSON document and removes the
+// insignificant white space, keeping the numbers as they are.
+func canonicalizeJSON(data []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// ContentHash returns the SHA-256 digest of the canonical JSON serialization
+// of a report, in the form "sha256:<hex>". Reports with the same content have
+// the same hash, see Report.Canonical.
+func ContentHash(r Report) (string, error) {
+	data, err := CanonicalJSON(r)
+	if err != nil {
+		return "", err
+	}
+	return Digest(data), nil
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Report
+		want string
+	}{
+		{
+			name: "sorted keys",
+			r:    Report{CheckData: CheckData{CheckID: "ID0", Target: "<example.com>"}, ResultData: ResultData{Data: []byte("data")}},
+			want: `{"check_id":"ID0","checktype_name":"","checktype_version":"","data":"ZGF0YQ==","end_time":"0001-01-01T00:00:00Z","error":"","options":"","start_time":"0001-01-01T00:00:00Z","status":"","tag":"","target":"<example.com>","vulnerabilities":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalJSON(tt.r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalJSONRoundTrip(t *testing.T) {
+	r := reportWithAttachments(Attachment{Name: "a.txt", ContentType: "text/plain", Data: []byte("a & b")})
+	r.Vulnerabilities[0].Score = 6.1
+	r.Vulnerabilities[0].Resources = []ResourcesGroup{{
+		Name:   "Users",
+		Header: []string{"Name", "Email"},
+		Rows:   []map[string]string{{"Name": "john", "Email": "john@example.com"}},
+	}}
+	want, err := CanonicalJSON(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := CanonicalJSON(decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestReportCanonical(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*60*60)
+	vuln := func(fingerprint, summary string, labels ...string) Vulnerability {
+		return Vulnerability{Fingerprint: fingerprint, Summary: summary, Labels: labels}
+	}
+	tests := []struct {
+		name string
+		r    Report
+		want Report
+	}{
+		{
+			name: "times in UTC",
+			r:    Report{CheckData: CheckData{StartTime: mustConvertStrToDateTime(st).In(madrid), EndTime: mustConvertStrToDateTime(et).In(madrid)}},
+			want: Report{CheckData: CheckData{StartTime: mustConvertStrToDateTime(st), EndTime: mustConvertStrToDateTime(et)}},
+		},
+		{
+			name: "sorted vulnerabilities",
+			r: Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{
+				vuln("b", "a"),
+				vuln("", "b"),
+				vuln("a", "b", "web", "issue"),
+				vuln("a", "a"),
+				{Fingerprint: "a", Summary: "a", Score: 1, References: []string{"https://b", "https://a"}},
+			}}},
+			want: Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{
+				vuln("", "b"),
+				vuln("a", "a"),
+				{Fingerprint: "a", Summary: "a", Score: 1, References: []string{"https://a", "https://b"}},
+				vuln("a", "b", "issue", "web"),
+				vuln("b", "a"),
+			}}},
+		},
+		{
+			name: "sorted children",
+			r: Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{
+				{Summary: "parent", Vulnerabilities: []Vulnerability{vuln("", "b"), vuln("", "a")}},
+			}}},
+			want: Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{
+				{Summary: "parent", Vulnerabilities: []Vulnerability{vuln("", "a"), vuln("", "b")}},
+			}}},
+		},
+		{
+			name: "empty vulnerabilities",
+			r:    Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{}}},
+			want: Report{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.r.Canonical()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportCanonicalDoesNotModifyReport(t *testing.T) {
+	labels := []string{"web", "issue"}
+	r := Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{vulnerabilityWithScore(1), {Summary: "a", Labels: labels}}}}
+	r.Canonical()
+	if r.Vulnerabilities[1].Summary != "a" || labels[0] != "web" {
+		t.Errorf("report was modified: %+v", r)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	r := Report{
+		CheckData: cd0,
+		ResultData: ResultData{Vulnerabilities: []Vulnerability{
+			vulnerabilityWithScore(1),
+			{Summary: "other", Labels: []string{"web", "issue"}},
+		}},
+	}
+	want, err := ContentHash(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reordered := r
+	reordered.StartTime = r.StartTime.In(time.FixedZone("CEST", 2*60*60))
+	reordered.Vulnerabilities = []Vulnerability{
+		{Summary: "other", Labels: []string{"issue", "web"}},
+		vulnerabilityWithScore(1),
+	}
+	modified := r
+	modified.Vulnerabilities = []Vulnerability{vulnerabilityWithScore(2), r.Vulnerabilities[1]}
+	invalid := r
+	invalid.Vulnerabilities = []Vulnerability{vulnerabilityWithScore(float32(math.NaN()))}
+
+	tests := []struct {
+		name     string
+		r        Report
+		wantSame bool
+		wantErr  bool
+	}{
+		{name: "reordered", r: reordered, wantSame: true},
+		{name: "modified", r: modified},
+		{name: "invalid score", r: invalid, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ContentHash(tt.r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !validDigest(got) {
+				t.Errorf("got invalid hash %q", got)
+			}
+			if (got == want) != tt.wantSame {
+				t.Errorf("got hash %s, original hash %s, want same %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+	"github.com/adevinta/vulcan-report/asff"
+	"github.com/adevinta/vulcan-report/cyclonedx"
+	"github.com/adevinta/vulcan-report/gitlab"
+	"github.com/adevinta/vulcan-report/junit"
+	"github.com/adevinta/vulcan-report/nessus"
+	"github.com/adevinta/vulcan-report/nuclei"
+	"github.com/adevinta/vulcan-report/ocsf"
+	"github.com/adevinta/vulcan-report/trivy"
+	"github.com/adevinta/vulcan-report/zap"
+)
+
+// importers are the input formats of the scanners whose results can be
+// converted to reports.
+var importers = map[string]func(io.Reader) ([]report.Vulnerability, error){
+	"trivy":  trivy.Import,
+	"nuclei": nuclei.Import,
+	"zap":    zap.Import,
+	"nessus": nessus.Import,
+}
+
+var outputFormats = []string{formatNative, formatTimeStr, "gitlab", "ocsf", "asff", "cyclonedx", "junit"}
+
+// parseSeverity parses a severity rank given by name or number, returning
+// errUsage if it is invalid.
+func parseSeverity(s string) (report.SeverityRank, error) {
+	rank, err := report.ParseSeverityRank(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return rank, nil
+}
+
+// runConvert converts a report, or the results of a scanner, to another
+// format.
+func runConvert(e *env, args []string) error {
+	fs := e.newFlagSet("convert", "[file]")
+	in := fs.String("in", formatAuto, "input format: auto, native, timestr, trivy, nuclei, zap or nessus")
+	to := fs.String("to", formatNative, "output format: "+strings.Join(outputFormats, ", "))
+	out := fs.String("o", "", "output file, defaults to the standard output")
+	target := fs.String("target", "", "target of the report built from the results of a scanner, required for the scanner formats")
+	checktype := fs.String("checktype", "", "checktype of the report built from the results of a scanner, defaults to the input format")
+	checktypeVersion := fs.String("checktype-version", "unknown", "checktype version of the report built from the results of a scanner")
+	checkID := fs.String("check-id", "", "check ID of the report built from the results of a scanner, defaults to a random UUID")
+	asffAccount := fs.String("asff-account", "", "AWS account of the Security Hub, for the asff format")
+	asffRegion := fs.String("asff-region", "", "AWS region of the Security Hub, for the asff format")
+	asffPartition := fs.String("asff-partition", "", "AWS partition of the Security Hub, for the asff format")
+	asffProduct := fs.String("asff-product-arn", "", "product ARN of the integration, for the asff format")
+	junitThreshold := fs.String("junit-threshold", "", "minimum severity of the failing vulnerabilities, for the junit format, defaults to "+junit.DefaultThreshold.String())
+	junitSkip := fs.Bool("junit-skip-below", false, "skip the vulnerabilities below the threshold instead of passing them, for the junit format")
+	severity := fs.String("severity", "", "add the severity to the vulnerabilities encoded as a name or a number, for the native format")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: convert accepts one input file", errUsage)
+	}
+	name := fs.Arg(0)
+	if name == "" {
+		name = "-"
+	}
+
+	var r report.Report
+	if imp, ok := importers[*in]; ok {
+		if *target == "" {
+			return fmt.Errorf("%w: -target is required to convert the results of %s", errUsage, *in)
+		}
+		data, err := e.readFile(name)
+		if err != nil {
+			return err
+		}
+		vulns, err := imp(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if *checkID == "" {
+			if *checkID, err = newUUID(); err != nil {
+				return err
+			}
+		}
+		r = importedReport(*in, importedCheck{id: *checkID, checktype: *checktype, version: *checktypeVersion, target: *target}, vulns)
+	} else {
+		if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+			return err
+		}
+		var err error
+		if r, err = e.readReport(name, *in); err != nil {
+			return err
+		}
+	}
+
+	c := outputConfig{
+		asff:           asff.Config{AccountID: *asffAccount, Region: *asffRegion, Partition: *asffPartition, ProductArn: *asffProduct},
+		junitThreshold: *junitThreshold,
+		junitSkip:      *junitSkip,
+		severity:       *severity,
+	}
+	data, err := encodeOutput(r, *to, c)
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, data)
+}
+
+// outputConfig contains the options of the output formats.
+type outputConfig struct {
+	asff           asff.Config
+	junitThreshold string
+	junitSkip      bool
+	severity       string
+}
+
+// encodeOutput encodes a report in one of the output formats.
+func encodeOutput(r report.Report, format string, c outputConfig) ([]byte, error) {
+	var v interface{}
+	switch format {
+	case formatNative:
+		if c.severity == "" {
+			return encodeReport(r, format)
+		}
+		return marshalWithSeverity(r, c.severity)
+	case formatTimeStr:
+		return encodeReport(r, format)
+	case "gitlab":
+		gr, err := gitlab.Convert(r)
+		if err != nil {
+			return nil, err
+		}
+		v = gr
+	case "ocsf":
+		v = ocsf.Convert(r)
+	case "asff":
+		findings, err := asff.Convert(r, c.asff)
+		if err != nil {
+			return nil, err
+		}
+		v = findings
+	case "cyclonedx":
+		v = cyclonedx.Convert(r)
+	case "junit":
+		jc := junit.Config{SkipBelowThreshold: c.junitSkip}
+		if c.junitThreshold != "" {
+			threshold, err := parseSeverity(c.junitThreshold)
+			if err != nil {
+				return nil, err
+			}
+			jc.Threshold = &threshold
+		}
+		return junit.Marshal(jc, r)
+	default:
+		return nil, checkFormat(format, outputFormats...)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// marshalWithSeverity encodes a report in native JSON adding the severity,
+// as a name or a number, to its vulnerabilities.
+func marshalWithSeverity(r report.Report, format string) ([]byte, error) {
+	f := report.SeverityFormatName
+	switch format {
+	case "name":
+	case "number":
+		f = report.SeverityFormatNumber
+	default:
+		return nil, fmt.Errorf("%w: invalid severity format %q, valid formats: name, number", errUsage, format)
+	}
+	data, err := r.MarshalJSONWithSeverity(f)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// importedCheck contains the check data of a report built from the results
+// of a scanner.
+type importedCheck struct {
+	id        string
+	checktype string
+	version   string
+	target    string
+}
+
+// importedReport returns the report of the vulnerabilities imported from the
+// results of a scanner. The checktype defaults to the format.
+func importedReport(format string, c importedCheck, vulns []report.Vulnerability) report.Report {
+	if c.checktype == "" {
+		c.checktype = format
+	}
+	now := time.Now().UTC()
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          c.id,
+			ChecktypeName:    c.checktype,
+			ChecktypeVersion: c.version,
+			Status:           report.StatusFinished,
+			Target:           c.target,
+			StartTime:        now,
+			EndTime:          now,
+		},
+		ResultData: report.ResultData{Vulnerabilities: vulns},
+	}
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// checkFormat returns errUsage if the format is not one of the valid ones.
+func checkFormat(format string, valid ...string) error {
+	for _, v := range valid {
+		if format == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown format %q, valid formats: %s", errUsage, format, strings.Join(valid, ", "))
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestRunConvert(t *testing.T) {
+	path := writeReport(t, testReport())
+	nuclei := writeFile(t, "nuclei.jsonl", []byte(`{"template-id":"git-config","info":{"name":"Git Config Disclosure","severity":"medium"},"type":"http","host":"https://example.com","matched-at":"https://example.com/.git/config","matcher-status":true}`+"\n"))
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+	}{
+		{
+			name:       "native to timestr",
+			args:       []string{"-to", "timestr", path},
+			wantCode:   exitOK,
+			wantStdout: `"start_time": "2021-05-18 13:30:15"`,
+		},
+		{
+			name:       "stdin",
+			args:       []string{"-to", "native"},
+			stdin:      mustRead(t, path),
+			wantCode:   exitOK,
+			wantStdout: `"start_time": "2021-05-18T13:30:15Z"`,
+		},
+		{
+			name:       "gitlab",
+			args:       []string{"-to", "gitlab", path},
+			wantCode:   exitOK,
+			wantStdout: `"vulnerabilities": [`,
+		},
+		{
+			name:       "ocsf",
+			args:       []string{"-to", "ocsf", path},
+			wantCode:   exitOK,
+			wantStdout: `"class_uid"`,
+		},
+		{
+			name:       "cyclonedx",
+			args:       []string{"-to", "cyclonedx", path},
+			wantCode:   exitOK,
+			wantStdout: `"bomFormat": "CycloneDX"`,
+		},
+		{
+			name:       "junit",
+			args:       []string{"-to", "junit", "-junit-threshold", "low", path},
+			wantCode:   exitOK,
+			wantStdout: `tests="3" failures="2"`,
+		},
+		{
+			name:       "junit default threshold",
+			args:       []string{"-to", "junit", path},
+			wantCode:   exitOK,
+			wantStdout: `tests="3" failures="1"`,
+		},
+		{
+			name:       "nuclei",
+			args:       []string{"-in", "nuclei", "-target", "example.com", nuclei},
+			wantCode:   exitOK,
+			wantStdout: `"summary": "Git Config Disclosure"`,
+		},
+		{
+			name:       "severity name",
+			args:       []string{"-severity", "name", path},
+			wantCode:   exitOK,
+			wantStdout: `"severity": "high"`,
+		},
+		{
+			name:       "severity number",
+			args:       []string{"-severity", "number", path},
+			wantCode:   exitOK,
+			wantStdout: `"severity": 3`,
+		},
+		{
+			name:     "invalid severity format",
+			args:     []string{"-severity", "score", path},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "scanner results without target",
+			args:     []string{"-in", "nuclei", nuclei},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "unknown input format",
+			args:     []string{"-in", "xml", path},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "unknown output format",
+			args:     []string{"-to", "xml", path},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "invalid junit threshold",
+			args:     []string{"-to", "junit", "-junit-threshold", "severe", path},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "too many files",
+			args:     []string{path, path},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runTest(append([]string{"convert"}, tt.args...), tt.stdin)
+			if code != tt.wantCode {
+				t.Fatalf("got exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("got stdout %q, want it to contain %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+
+	t.Run("output file", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out.json")
+		if code, _, stderr := runTest([]string{"convert", "-o", out, path}, ""); code != exitOK {
+			t.Fatalf("got exit code %d, want %d: %s", code, exitOK, stderr)
+		}
+		var r report.Report
+		if err := json.Unmarshal([]byte(mustRead(t, out)), &r); err != nil {
+			t.Fatalf("unmarshal report: %v", err)
+		}
+		if r.Target != "example.com" {
+			t.Errorf("got target %q, want %q", r.Target, "example.com")
+		}
+	})
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    report.SeverityRank
+		wantErr bool
+	}{
+		{s: "none", want: report.SeverityNone},
+		{s: "HIGH", want: report.SeverityHigh},
+		{s: "4", want: report.SeverityCritical},
+		{s: "5", wantErr: true},
+		{s: "severe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseSeverity(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustRead(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	return string(data)
+}
+
+func TestRunConvertImported(t *testing.T) {
+	tests := []struct {
+		format string
+		file   string
+		target string
+	}{
+		{format: "trivy", file: "testdata/trivy.json", target: "alpine:3.10"},
+		{format: "nuclei", file: "testdata/nuclei.jsonl", target: "https://example.com"},
+		{format: "zap", file: "testdata/zap.json", target: "https://example.com"},
+		{format: "nessus", file: "testdata/nessus.nessus", target: "192.168.1.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			code, stdout, stderr := runTest([]string{"convert", "-in", tt.format, "-target", tt.target, tt.file}, "")
+			if code != exitOK {
+				t.Fatalf("convert: got exit code %d, want %d: %s", code, exitOK, stderr)
+			}
+			var r report.Report
+			if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+			if r.CheckID == "" || r.ChecktypeVersion != "unknown" {
+				t.Errorf("got check ID %q and checktype version %q, want an ID and %q", r.CheckID, r.ChecktypeVersion, "unknown")
+			}
+			if code, _, stderr := runTest([]string{"validate"}, stdout); code != exitOK {
+				t.Errorf("validate: got exit code %d, want %d: %s", code, exitOK, stderr)
+			}
+			if code, _, stderr := runTest([]string{"convert", "-in", tt.format, "-target", tt.target, "-to", "gitlab", tt.file}, ""); code != exitOK {
+				t.Errorf("convert to gitlab: got exit code %d, want %d: %s", code, exitOK, stderr)
+			}
+		})
+	}
+
+	t.Run("check flags", func(t *testing.T) {
+		args := []string{"convert", "-in", "nuclei", "-target", "https://example.com", "-check-id", "ID1", "-checktype-version", "2.9", "testdata/nuclei.jsonl"}
+		code, stdout, stderr := runTest(args, "")
+		if code != exitOK {
+			t.Fatalf("got exit code %d, want %d: %s", code, exitOK, stderr)
+		}
+		var r report.Report
+		if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+			t.Fatalf("unmarshal report: %v", err)
+		}
+		if r.CheckID != "ID1" || r.ChecktypeVersion != "2.9" {
+			t.Errorf("got check ID %q and checktype version %q, want %q and %q", r.CheckID, r.ChecktypeVersion, "ID1", "2.9")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// vulnerabilityKey returns the key identifying a vulnerability across
+// reports: its summary and affected resource. The fingerprint is not part of
+// the key, because it changes when the context of the vulnerability does.
+func vulnerabilityKey(v report.Vulnerability) string {
+	return v.Summary + "\x00" + v.AffectedResource
+}
+
+// change is a vulnerability that changed between two reports.
+type change struct {
+	Old report.Vulnerability `json:"old"`
+	New report.Vulnerability `json:"new"`
+}
+
+// reportDiff contains the differences between two reports.
+type reportDiff struct {
+	Added   []report.Vulnerability `json:"added"`
+	Removed []report.Vulnerability `json:"removed"`
+	Changed []change               `json:"changed"`
+}
+
+func (d reportDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffReports returns the vulnerabilities added, removed and changed, in
+// score or fingerprint, from the old report to the new one.
+func diffReports(old, new report.Report) reportDiff {
+	d := reportDiff{
+		Added:   []report.Vulnerability{},
+		Removed: []report.Vulnerability{},
+		Changed: []change{},
+	}
+	olds := map[string][]report.Vulnerability{}
+	for _, v := range old.Vulnerabilities {
+		k := vulnerabilityKey(v)
+		olds[k] = append(olds[k], v)
+	}
+	for _, v := range new.Vulnerabilities {
+		k := vulnerabilityKey(v)
+		if len(olds[k]) == 0 {
+			d.Added = append(d.Added, v)
+			continue
+		}
+		o := olds[k][0]
+		olds[k] = olds[k][1:]
+		if o.Score != v.Score || o.Fingerprint != v.Fingerprint {
+			d.Changed = append(d.Changed, change{Old: o, New: v})
+		}
+	}
+	// Keep the order of the old report for the removed vulnerabilities.
+	for _, v := range old.Vulnerabilities {
+		k := vulnerabilityKey(v)
+		if len(olds[k]) > 0 {
+			d.Removed = append(d.Removed, olds[k][0])
+			olds[k] = olds[k][1:]
+		}
+	}
+	return d
+}
+
+// runDiff prints the differences between two reports. Like diff(1), it
+// fails if there are differences.
+func runDiff(e *env, args []string) error {
+	fs := e.newFlagSet("diff", "old new")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	asJSON := fs.Bool("json", false, "print the differences as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("%w: diff requires two input files", errUsage)
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	inputs, err := e.readInputs(fs.Args(), *in)
+	if err != nil {
+		return err
+	}
+
+	d := diffReports(inputs[0].report, inputs[1].report)
+	if *asJSON {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := e.writeOutput("", data); err != nil {
+			return err
+		}
+	} else {
+		for _, v := range d.Removed {
+			fmt.Fprintf(e.stdout, "- %s\n", describe(v))
+		}
+		for _, v := range d.Added {
+			fmt.Fprintf(e.stdout, "+ %s\n", describe(v))
+		}
+		for _, c := range d.Changed {
+			fmt.Fprintf(e.stdout, "~ %s -> %.1f %s\n", describe(c.Old), c.New.Score, c.New.Severity())
+		}
+	}
+	if !d.empty() {
+		return errFailure
+	}
+	return nil
+}
+
+func describe(v report.Vulnerability) string {
+	s := fmt.Sprintf("[%.1f %s] %s", v.Score, v.Severity(), v.Summary)
+	if v.AffectedResource != "" {
+		s += " (" + v.AffectedResource + ")"
+	}
+	return s
+}
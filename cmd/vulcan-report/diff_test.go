@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestDiffReports(t *testing.T) {
+	old := testReport()
+	new := testReport()
+	new.Vulnerabilities = []report.Vulnerability{
+		old.Vulnerabilities[0],
+		{
+			Summary:          "Certificate expired",
+			Score:            10,
+			AffectedResource: "example.com:443",
+			Fingerprint:      "f2",
+		},
+		{
+			Summary:          "Self-signed certificate",
+			Score:            3.9,
+			AffectedResource: "example.com:443",
+		},
+	}
+
+	got := diffReports(old, new)
+	want := reportDiff{
+		Added:   []report.Vulnerability{new.Vulnerabilities[2]},
+		Removed: []report.Vulnerability{old.Vulnerabilities[2]},
+		Changed: []change{{Old: old.Vulnerabilities[1], New: new.Vulnerabilities[1]}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got diff %+v, want %+v", got, want)
+	}
+	if d := diffReports(old, old); !d.empty() {
+		t.Errorf("got diff %+v for the same report, want none", d)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	old := writeReport(t, testReport())
+	r := testReport()
+	r.Vulnerabilities = r.Vulnerabilities[1:]
+	new := writeReport(t, r)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{
+			name:     "equal",
+			args:     []string{old, old},
+			wantCode: exitOK,
+		},
+		{
+			name:       "removed",
+			args:       []string{old, new},
+			wantCode:   exitFailure,
+			wantStdout: "- [6.9 medium] Weak cipher (example.com:443)\n",
+		},
+		{
+			name:       "added",
+			args:       []string{new, old},
+			wantCode:   exitFailure,
+			wantStdout: "+ [6.9 medium] Weak cipher (example.com:443)\n",
+		},
+		{
+			name:     "one file",
+			args:     []string{old},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, _ := runTest(append([]string{"diff"}, tt.args...), "")
+			if code != tt.wantCode {
+				t.Errorf("got exit code %d, want %d", code, tt.wantCode)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("got stdout %q, want %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		_, stdout, _ := runTest([]string{"diff", "-json", old, new}, "")
+		if !strings.Contains(stdout, `"removed": [`) || !strings.Contains(stdout, `"summary": "Weak cipher"`) {
+			t.Errorf("got stdout %q, want the removed vulnerability", stdout)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// stringList is a flag that can be given multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// vulnerabilityFilter selects the vulnerabilities of a report.
+type vulnerabilityFilter struct {
+	minSeverity       report.SeverityRank
+	maxSeverity       report.SeverityRank
+	labels            []string
+	excludeLabels     []string
+	cwes              []uint32
+	summary           string
+	excludeSuppressed bool
+}
+
+// match returns true if the vulnerability has a severity between the minimum
+// and the maximum, every label, none of the excluded labels, one of the
+// CWE-IDs, if any, and a summary containing the given text, ignoring case.
+func (f vulnerabilityFilter) match(v report.Vulnerability) bool {
+	if v.Severity() < f.minSeverity || v.Severity() > f.maxSeverity {
+		return false
+	}
+	if f.excludeSuppressed && v.Suppressed() {
+		return false
+	}
+	for _, l := range f.labels {
+		if !hasLabel(v, l) {
+			return false
+		}
+	}
+	for _, l := range f.excludeLabels {
+		if hasLabel(v, l) {
+			return false
+		}
+	}
+	if len(f.cwes) > 0 {
+		found := false
+		for _, id := range f.cwes {
+			if v.CWEID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return strings.Contains(strings.ToLower(v.Summary), strings.ToLower(f.summary))
+}
+
+func hasLabel(v report.Vulnerability, label string) bool {
+	for _, l := range v.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// runFilter writes a report with the vulnerabilities matching the given
+// criteria.
+func runFilter(e *env, args []string) error {
+	fs := e.newFlagSet("filter", "[file]")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	to := fs.String("to", formatNative, "output format: native or timestr")
+	out := fs.String("o", "", "output file, defaults to the standard output")
+	minSeverity := fs.String("min-severity", "none", "minimum severity of the vulnerabilities")
+	maxSeverity := fs.String("max-severity", "critical", "maximum severity of the vulnerabilities")
+	var labels, excludeLabels, cwes stringList
+	fs.Var(&labels, "label", "label the vulnerabilities must have, can be repeated")
+	fs.Var(&excludeLabels, "exclude-label", "label the vulnerabilities must not have, can be repeated")
+	fs.Var(&cwes, "cwe", "CWE-ID of the vulnerabilities, can be repeated")
+	summary := fs.String("summary", "", "text the summary of the vulnerabilities must contain, ignoring case")
+	excludeSuppressed := fs.Bool("exclude-suppressed", false, "exclude the suppressed vulnerabilities")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: filter accepts one input file", errUsage)
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	if err := checkFormat(*to, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+
+	f := vulnerabilityFilter{
+		labels:            labels,
+		excludeLabels:     excludeLabels,
+		summary:           *summary,
+		excludeSuppressed: *excludeSuppressed,
+	}
+	var err error
+	if f.minSeverity, err = parseSeverity(*minSeverity); err != nil {
+		return err
+	}
+	if f.maxSeverity, err = parseSeverity(*maxSeverity); err != nil {
+		return err
+	}
+	if f.cwes, err = parseCWEs(cwes); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = "-"
+	}
+	r, err := e.readReport(name, *in)
+	if err != nil {
+		return err
+	}
+	var vulns []report.Vulnerability
+	for _, v := range r.Vulnerabilities {
+		if f.match(v) {
+			vulns = append(vulns, v)
+		}
+	}
+	r.Vulnerabilities = vulns
+	data, err := encodeReport(r, *to)
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, data)
+}
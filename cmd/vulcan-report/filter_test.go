@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestRunFilter(t *testing.T) {
+	path := writeReport(t, testReport())
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "no filter",
+			args:     []string{path},
+			wantCode: exitOK,
+			want:     []string{"Weak cipher", "Certificate expired", "Server banner"},
+		},
+		{
+			name:     "min severity",
+			args:     []string{"-min-severity", "high", path},
+			wantCode: exitOK,
+			want:     []string{"Certificate expired"},
+		},
+		{
+			name:     "max severity",
+			args:     []string{"-max-severity", "1", path},
+			wantCode: exitOK,
+			want:     []string{"Server banner"},
+		},
+		{
+			name:     "labels",
+			args:     []string{"-label", "tls", "-exclude-label", "certificate", path},
+			wantCode: exitOK,
+			want:     []string{"Weak cipher"},
+		},
+		{
+			name:     "cwe",
+			args:     []string{"-cwe", "CWE-327", "-cwe", "79", path},
+			wantCode: exitOK,
+			want:     []string{"Weak cipher"},
+		},
+		{
+			name:     "summary",
+			args:     []string{"-summary", "CERTIFICATE", path},
+			wantCode: exitOK,
+			want:     []string{"Certificate expired"},
+		},
+		{
+			name:     "exclude suppressed",
+			args:     []string{"-exclude-suppressed", path},
+			wantCode: exitOK,
+			want:     []string{"Weak cipher", "Certificate expired"},
+		},
+		{
+			name:     "invalid severity",
+			args:     []string{"-min-severity", "severe", path},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "invalid cwe",
+			args:     []string{"-cwe", "xss", path},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runTest(append([]string{"filter"}, tt.args...), "")
+			if code != tt.wantCode {
+				t.Fatalf("got exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if code != exitOK {
+				return
+			}
+			var r report.Report
+			if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+			var got []string
+			for _, v := range r.Vulnerabilities {
+				got = append(got, v.Summary)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got vulnerabilities %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// runGate evaluates a gate policy against the reports. It fails if the
+// reports do not comply with the policy.
+func runGate(e *env, args []string) error {
+	fs := e.newFlagSet("gate", "[file ...]")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	policyFile := fs.String("policy", "", "JSON file with the gate policy, extended by the other flags")
+	maxCounts := map[report.SeverityRank]*int{}
+	for rank := report.SeverityCritical; rank >= report.SeverityNone; rank-- {
+		maxCounts[rank] = fs.Int("max-"+rank.String(), -1, "maximum number of "+rank.String()+" vulnerabilities, -1 for no limit")
+	}
+	var cwes, labels stringList
+	fs.Var(&cwes, "forbid-cwe", "forbidden CWE-ID, can be repeated")
+	fs.Var(&labels, "forbid-label", "forbidden label, can be repeated")
+	maxStatus := fs.String("max-status", "", "worst security status allowed, from A to F")
+	asJSON := fs.Bool("json", false, "print the verdict as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+
+	var p report.GatePolicy
+	if *policyFile != "" {
+		data, err := e.readFile(*policyFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("%s: %w", *policyFile, err)
+		}
+	}
+	for rank, n := range maxCounts {
+		if *n < 0 {
+			continue
+		}
+		if p.MaxCount == nil {
+			p.MaxCount = map[report.SeverityRank]int{}
+		}
+		p.MaxCount[rank] = *n
+	}
+	ids, err := parseCWEs(cwes)
+	if err != nil {
+		return err
+	}
+	p.ForbiddenCWEs = append(p.ForbiddenCWEs, ids...)
+	p.ForbiddenLabels = append(p.ForbiddenLabels, labels...)
+	if *maxStatus != "" {
+		p.MaxSecurityStatus = strings.ToUpper(*maxStatus)
+	}
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	inputs, err := e.readInputs(fs.Args(), *in)
+	if err != nil {
+		return err
+	}
+	var reports []report.Report
+	for _, i := range inputs {
+		reports = append(reports, i.report)
+	}
+	verdict, err := report.EvaluateGate(p, reports...)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(verdict, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := e.writeOutput("", data); err != nil {
+			return err
+		}
+	} else {
+		for _, v := range verdict.Violations {
+			fmt.Fprintf(e.stdout, "%s: %s\n", v.Rule, v.Message)
+			for _, vuln := range v.Vulnerabilities {
+				fmt.Fprintf(e.stdout, "  %s\n", describe(vuln))
+			}
+		}
+		result := "passed"
+		if !verdict.Passed {
+			result = "failed"
+		}
+		fmt.Fprintf(e.stdout, "gate %s, security status: %s\n", result, verdict.SecurityStatus)
+	}
+	if !verdict.Passed {
+		return errFailure
+	}
+	return nil
+}
+
+// parseCWEs parses CWE-IDs given as numbers or in the form "CWE-<number>".
+func parseCWEs(cwes []string) ([]uint32, error) {
+	var ids []uint32
+	for _, s := range cwes {
+		id, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(s), "CWE-"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid CWE-ID %q", errUsage, s)
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestRunGate(t *testing.T) {
+	path := writeReport(t, testReport())
+	policy := writeFile(t, "policy.json", []byte(`{"max_count":{"High":0}}`))
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+	}{
+		{
+			name:       "no policy",
+			args:       []string{path},
+			wantCode:   exitOK,
+			wantStdout: []string{"gate passed, security status: F"},
+		},
+		{
+			name:     "max count",
+			args:     []string{"-max-high", "0", "-max-medium", "1", path},
+			wantCode: exitFailure,
+			wantStdout: []string{
+				"max_count: 1 vulnerabilities of severity high exceed the maximum of 0",
+				"  [8.9 high] Certificate expired (example.com:443)",
+				"gate failed",
+			},
+		},
+		{
+			name:       "policy file",
+			args:       []string{"-policy", policy, path},
+			wantCode:   exitFailure,
+			wantStdout: []string{"max_count: 1 vulnerabilities of severity high"},
+		},
+		{
+			name:       "forbidden cwe",
+			args:       []string{"-forbid-cwe", "CWE-327", path},
+			wantCode:   exitFailure,
+			wantStdout: []string{"forbidden_cwe: 1 vulnerabilities have the forbidden CWE-327"},
+		},
+		{
+			name:       "forbidden suppressed label",
+			args:       []string{"-forbid-label", report.LabelSuppressed, path},
+			wantCode:   exitOK,
+			wantStdout: []string{"gate passed"},
+		},
+		{
+			name:       "max status",
+			args:       []string{"-max-status", "f", path},
+			wantCode:   exitOK,
+			wantStdout: []string{"gate passed"},
+		},
+		{
+			name:     "invalid status",
+			args:     []string{"-max-status", "Z", path},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "invalid cwe",
+			args:     []string{"-forbid-cwe", "xss", path},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runTest(append([]string{"gate"}, tt.args...), "")
+			if code != tt.wantCode {
+				t.Fatalf("got exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("got stdout %q, want it to contain %q", stdout, want)
+				}
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		code, stdout, _ := runTest([]string{"gate", "-json", "-forbid-label", "tls", path}, "")
+		if code != exitFailure {
+			t.Errorf("got exit code %d, want %d", code, exitFailure)
+		}
+		var got report.GateVerdict
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("unmarshal verdict: %v", err)
+		}
+		if got.Passed || len(got.Violations) != 1 || len(got.Violations[0].Vulnerabilities) != 2 {
+			t.Errorf("got verdict %+v, want one violation of two vulnerabilities", got)
+		}
+	})
+}
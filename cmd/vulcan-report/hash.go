@@ -0,0 +1,36 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"fmt"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// runHash prints the content hash of the reports, one per line followed by
+// the name of the file, so reports with the same content can be found.
+func runHash(e *env, args []string) error {
+	fs := e.newFlagSet("hash", "[file ...]")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	inputs, err := e.readInputs(fs.Args(), *in)
+	if err != nil {
+		return err
+	}
+	for _, i := range inputs {
+		hash, err := report.ContentHash(i.report)
+		if err != nil {
+			return fmt.Errorf("%s: %w", i.name, err)
+		}
+		fmt.Fprintf(e.stdout, "%s  %s\n", hash, i.name)
+	}
+	return nil
+}
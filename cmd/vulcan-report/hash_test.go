@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunHash(t *testing.T) {
+	r := testReport()
+	reordered := testReport()
+	for i, j := 0, len(reordered.Vulnerabilities)-1; i < j; i, j = i+1, j-1 {
+		reordered.Vulnerabilities[i], reordered.Vulnerabilities[j] = reordered.Vulnerabilities[j], reordered.Vulnerabilities[i]
+	}
+	modified := testReport()
+	modified.Target = "other.example.com"
+	paths := []string{writeReport(t, r), writeReport(t, reordered), writeReport(t, modified)}
+
+	code, stdout, stderr := runTest(append([]string{"hash"}, paths...), "")
+	if code != exitOK {
+		t.Fatalf("got exit code %d, want %d: %s", code, exitOK, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != len(paths) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(paths), stdout)
+	}
+	var hashes []string
+	for i, line := range lines {
+		hash, name, ok := strings.Cut(line, "  ")
+		if !ok || name != paths[i] || !strings.HasPrefix(hash, "sha256:") {
+			t.Fatalf("got line %q, want hash of %s", line, paths[i])
+		}
+		hashes = append(hashes, hash)
+	}
+	if hashes[0] != hashes[1] {
+		t.Errorf("got different hashes for reordered reports: %s, %s", hashes[0], hashes[1])
+	}
+	if hashes[0] == hashes[2] {
+		t.Errorf("got same hash for modified report: %s", hashes[0])
+	}
+}
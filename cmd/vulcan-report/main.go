@@ -0,0 +1,250 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Command vulcan-report validates, converts, summarizes, compares, merges,
+// filters, gates, redacts, hashes, signs and verifies Vulcan reports.
+//
+// Usage:
+//
+//	vulcan-report <command> [flags] [file ...]
+//
+// Reports are read from the given files or, when no file is given or the
+// file is "-", from the standard input. The exit code is 0 on success, 1
+// when the command completed but its result is negative (e.g. invalid
+// reports, differences between reports, reports not passing a gate or
+// invalid signatures), 2 on usage errors and 3 on any other error.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// Exit codes.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitError   = 3
+)
+
+// Native report formats.
+const (
+	formatAuto    = "auto"
+	formatNative  = "native"
+	formatTimeStr = "timestr"
+)
+
+// errUsage is returned by the commands when their arguments are invalid.
+var errUsage = errors.New("invalid usage")
+
+// errFailure is returned by the commands that completed but whose result is
+// negative.
+var errFailure = errors.New("failure")
+
+type command struct {
+	name  string
+	usage string
+	run   func(env *env, args []string) error
+}
+
+var commands = []command{
+	{"validate", "validate reports", runValidate},
+	{"convert", "convert a report to another format", runConvert},
+	{"summary", "summarize reports", runSummary},
+	{"diff", "show the differences between two reports", runDiff},
+	{"merge", "merge reports of the same check and target", runMerge},
+	{"filter", "filter the vulnerabilities of a report", runFilter},
+	{"gate", "check reports against a gate policy", runGate},
+	{"redact", "redact secrets and personal data from a report", runRedact},
+	{"hash", "print the content hash of reports", runHash},
+	{"sign", "sign a report", runSign},
+	{"verify", "verify the signature of a signed report", runVerify},
+}
+
+// env is the environment commands run in.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		err := c.run(e, args[1:])
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errFailure):
+			return exitFailure
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(stderr, "vulcan-report %s: %v\n", c.name, err)
+			return exitUsage
+		default:
+			fmt.Fprintf(stderr, "vulcan-report %s: %v\n", c.name, err)
+			return exitError
+		}
+	}
+	fmt.Fprintf(stderr, "vulcan-report: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: vulcan-report <command> [flags] [file ...]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w, "\nRun 'vulcan-report <command> -h' for the flags of a command.")
+}
+
+// newFlagSet returns the flag set of a command.
+func (e *env) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: vulcan-report %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command, returning errUsage if they are
+// invalid.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return nil
+}
+
+// input is a report read by a command.
+type input struct {
+	name   string
+	report report.Report
+}
+
+// readInputs reads the reports in the given files, or the report in the
+// standard input if no file is given.
+func (e *env) readInputs(files []string, format string) ([]input, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var inputs []input
+	for _, name := range files {
+		r, err := e.readReport(name, format)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input{name: name, report: r})
+	}
+	return inputs, nil
+}
+
+func (e *env) readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func (e *env) readReport(name, format string) (report.Report, error) {
+	data, err := e.readFile(name)
+	if err != nil {
+		return report.Report{}, err
+	}
+	r, err := decodeReport(data, format)
+	if err != nil {
+		return report.Report{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return r, nil
+}
+
+// decodeReport decodes a report in native JSON, with RFC 3339 times, or in
+// JSON with times as strings. The auto format accepts both.
+func decodeReport(data []byte, format string) (report.Report, error) {
+	var r report.Report
+	switch format {
+	case formatNative:
+		err := json.Unmarshal(data, &r)
+		return r, err
+	case formatTimeStr:
+		err := r.UnmarshalJSONTimeAsString(data)
+		return r, err
+	case formatAuto:
+		err := json.Unmarshal(data, &r)
+		if err == nil {
+			return r, nil
+		}
+		var rs report.Report
+		if rs.UnmarshalJSONTimeAsString(data) == nil {
+			return rs, nil
+		}
+		return report.Report{}, err
+	}
+	return report.Report{}, fmt.Errorf("%w: unknown input format %q", errUsage, format)
+}
+
+// encodeReport encodes a report in native JSON or in JSON with times as
+// strings.
+func encodeReport(r report.Report, format string) ([]byte, error) {
+	switch format {
+	case formatNative:
+		return json.MarshalIndent(r, "", "  ")
+	case formatTimeStr:
+		return marshalTimeStr(r)
+	}
+	return nil, fmt.Errorf("%w: unknown report format %q", errUsage, format)
+}
+
+func marshalTimeStr(r report.Report) ([]byte, error) {
+	data, err := r.MarshalJSONTimeAsString()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeOutput writes data, followed by a new line, to the given file or to
+// the standard output if the file is empty or "-".
+func (e *env) writeOutput(name string, data []byte) error {
+	data = append(data, '\n')
+	if name == "" || name == "-" {
+		_, err := e.stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func testReport() report.Report {
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          "ID0",
+			ChecktypeName:    "vulcan-tls",
+			ChecktypeVersion: "1",
+			Target:           "example.com",
+			Status:           "FINISHED",
+			StartTime:        time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+			EndTime:          time.Date(2021, 5, 18, 14, 0, 50, 0, time.UTC),
+		},
+		ResultData: report.ResultData{
+			Vulnerabilities: []report.Vulnerability{
+				{
+					Summary:          "Weak cipher",
+					Score:            6.9,
+					AffectedResource: "example.com:443",
+					Fingerprint:      "f1",
+					CWEID:            327,
+					Labels:           []string{"tls"},
+				},
+				{
+					Summary:          "Certificate expired",
+					Score:            8.9,
+					AffectedResource: "example.com:443",
+					Fingerprint:      "f2",
+					Labels:           []string{"tls", "certificate"},
+				},
+				{
+					Summary:          "Server banner",
+					Score:            0,
+					AffectedResource: "example.com:443",
+					Fingerprint:      "f3",
+					Labels:           []string{report.LabelSuppressed},
+				},
+			},
+		},
+	}
+}
+
+// writeReport writes the report in native JSON to a temporary file and
+// returns its path.
+func writeReport(t *testing.T, r report.Report) string {
+	t.Helper()
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("marshal report: %v", err)
+	}
+	return writeFile(t, "report.json", data)
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	return path
+}
+
+func runTest(args []string, stdin string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			name:       "no args",
+			args:       nil,
+			wantCode:   exitUsage,
+			wantStderr: "usage: vulcan-report",
+		},
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantCode:   exitOK,
+			wantStderr: "commands:",
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"unknown"},
+			wantCode:   exitUsage,
+			wantStderr: `unknown command "unknown"`,
+		},
+		{
+			name:       "command help",
+			args:       []string{"summary", "-h"},
+			wantCode:   exitOK,
+			wantStderr: "usage: vulcan-report summary",
+		},
+		{
+			name:       "invalid flag",
+			args:       []string{"summary", "-unknown"},
+			wantCode:   exitUsage,
+			wantStderr: "vulcan-report summary: invalid usage",
+		},
+		{
+			name:       "missing file",
+			args:       []string{"summary", filepath.Join(t.TempDir(), "missing.json")},
+			wantCode:   exitError,
+			wantStderr: "no such file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runTest(tt.args, "")
+			if code != tt.wantCode {
+				t.Errorf("got exit code %d, want %d", code, tt.wantCode)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("got stderr %q, want it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestDecodeReport(t *testing.T) {
+	r := testReport()
+	native, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("marshal report: %v", err)
+	}
+	timestr, err := r.MarshalJSONTimeAsString()
+	if err != nil {
+		t.Fatalf("marshal report: %v", err)
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		format  string
+		wantErr bool
+	}{
+		{name: "native", data: native, format: formatNative},
+		{name: "timestr", data: timestr, format: formatTimeStr},
+		{name: "auto native", data: native, format: formatAuto},
+		{name: "auto timestr", data: timestr, format: formatAuto},
+		{name: "native as timestr", data: native, format: formatTimeStr, wantErr: true},
+		{name: "invalid", data: []byte("{"), format: formatAuto, wantErr: true},
+		{name: "unknown format", data: native, format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeReport(tt.data, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !got.StartTime.Equal(r.StartTime) || len(got.Vulnerabilities) != len(r.Vulnerabilities) {
+				t.Errorf("got report %+v, want %+v", got, r)
+			}
+		})
+	}
+}
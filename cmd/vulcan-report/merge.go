@@ -0,0 +1,94 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// mergeReports merges reports into the first one. The merged report spans
+// from the earliest start time to the latest end time, and contains the
+// vulnerabilities of every report, without duplicates, and their notes and
+// errors. It is not applicable only if none of the reports is applicable.
+func mergeReports(reports []report.Report) report.Report {
+	merged := reports[0]
+	merged.Vulnerabilities = nil
+	var notes, errs []string
+	seen := map[string]bool{}
+	for i, r := range reports {
+		if i > 0 {
+			if r.StartTime.Before(merged.StartTime) {
+				merged.StartTime = r.StartTime
+			}
+			if r.EndTime.After(merged.EndTime) {
+				merged.EndTime = r.EndTime
+			}
+			merged.NotApplicable = merged.NotApplicable && r.NotApplicable
+			if len(merged.Data) == 0 {
+				merged.Data = r.Data
+			}
+		}
+		if r.Notes != "" {
+			notes = append(notes, r.Notes)
+		}
+		if r.Error != "" {
+			errs = append(errs, r.Error)
+		}
+		for _, v := range r.Vulnerabilities {
+			k := vulnerabilityKey(v) + "\x00" + v.Fingerprint
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged.Vulnerabilities = append(merged.Vulnerabilities, v)
+		}
+	}
+	merged.Notes = strings.Join(notes, "\n")
+	merged.Error = strings.Join(errs, "; ")
+	return merged
+}
+
+// runMerge merges reports of the same check and target.
+func runMerge(e *env, args []string) error {
+	fs := e.newFlagSet("merge", "file ...")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	to := fs.String("to", formatNative, "output format: native or timestr")
+	out := fs.String("o", "", "output file, defaults to the standard output")
+	mixed := fs.Bool("allow-mixed", false, "allow merging reports of different checktypes or targets")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("%w: merge requires at least one input file", errUsage)
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	if err := checkFormat(*to, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	inputs, err := e.readInputs(fs.Args(), *in)
+	if err != nil {
+		return err
+	}
+
+	var reports []report.Report
+	for _, i := range inputs {
+		first := inputs[0].report
+		if !*mixed && (i.report.ChecktypeName != first.ChecktypeName || i.report.Target != first.Target) {
+			return fmt.Errorf("%s: checktype %q and target %q differ from %q and %q", i.name,
+				i.report.ChecktypeName, i.report.Target, first.ChecktypeName, first.Target)
+		}
+		reports = append(reports, i.report)
+	}
+	data, err := encodeReport(mergeReports(reports), *to)
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, data)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestMergeReports(t *testing.T) {
+	a := testReport()
+	a.Notes = "first"
+	b := testReport()
+	b.StartTime = a.StartTime.Add(-time.Hour)
+	b.EndTime = a.EndTime.Add(time.Hour)
+	b.Notes = "second"
+	b.Error = "timeout"
+	b.Vulnerabilities = append(b.Vulnerabilities, report.Vulnerability{
+		Summary:          "Self-signed certificate",
+		Score:            3.9,
+		AffectedResource: "example.com:443",
+	})
+
+	got := mergeReports([]report.Report{a, b})
+	if !got.StartTime.Equal(b.StartTime) || !got.EndTime.Equal(b.EndTime) {
+		t.Errorf("got times %v - %v, want %v - %v", got.StartTime, got.EndTime, b.StartTime, b.EndTime)
+	}
+	if got.Notes != "first\nsecond" {
+		t.Errorf("got notes %q, want %q", got.Notes, "first\nsecond")
+	}
+	if got.Error != "timeout" {
+		t.Errorf("got error %q, want %q", got.Error, "timeout")
+	}
+	if len(got.Vulnerabilities) != 4 {
+		t.Errorf("got %d vulnerabilities, want 4", len(got.Vulnerabilities))
+	}
+	if len(a.Vulnerabilities) != 3 {
+		t.Errorf("merge modified the first report")
+	}
+}
+
+func TestRunMerge(t *testing.T) {
+	a := writeReport(t, testReport())
+	r := testReport()
+	r.Target = "example.org"
+	other := writeReport(t, r)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantCode  int
+		wantVulns int
+	}{
+		{
+			name:      "same target",
+			args:      []string{a, a},
+			wantCode:  exitOK,
+			wantVulns: 3,
+		},
+		{
+			name:     "different target",
+			args:     []string{a, other},
+			wantCode: exitError,
+		},
+		{
+			name:      "allow mixed",
+			args:      []string{"-allow-mixed", a, other},
+			wantCode:  exitOK,
+			wantVulns: 3,
+		},
+		{
+			name:     "no files",
+			wantCode: exitUsage,
+		},
+		{
+			name:     "invalid output format",
+			args:     []string{"-to", "gitlab", a},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, _ := runTest(append([]string{"merge"}, tt.args...), "")
+			if code != tt.wantCode {
+				t.Fatalf("got exit code %d, want %d", code, tt.wantCode)
+			}
+			if code != exitOK {
+				return
+			}
+			var got report.Report
+			if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+			if len(got.Vulnerabilities) != tt.wantVulns {
+				t.Errorf("got %d vulnerabilities, want %d", len(got.Vulnerabilities), tt.wantVulns)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+var maskStyles = map[string]report.MaskStyle{
+	"label":   report.MaskLabel,
+	"stars":   report.MaskStars,
+	"partial": report.MaskPartial,
+	"hash":    report.MaskHash,
+}
+
+// runRedact redacts secrets and personal data from a report, printing what
+// was redacted to the standard error.
+func runRedact(e *env, args []string) error {
+	fs := e.newFlagSet("redact", "[file]")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	to := fs.String("to", formatNative, "output format: native or timestr")
+	out := fs.String("o", "", "output file, defaults to the standard output")
+	mask := fs.String("mask", "label", "masking style: label, stars, partial or hash")
+	noBuiltin := fs.Bool("no-builtin", false, "do not apply the built-in rules")
+	var rules stringList
+	fs.Var(&rules, "rule", "custom rule in the form name=regexp, can be repeated")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: redact accepts one input file", errUsage)
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	if err := checkFormat(*to, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+
+	style, ok := maskStyles[*mask]
+	if !ok {
+		return fmt.Errorf("%w: invalid masking style %q", errUsage, *mask)
+	}
+	opts := report.RedactionOptions{Rules: []report.RedactionRule{}, Mask: style}
+	if !*noBuiltin {
+		opts.Rules = report.DefaultRedactionRules()
+	}
+	for _, r := range rules {
+		name, expr, ok := strings.Cut(r, "=")
+		if !ok {
+			return fmt.Errorf("%w: invalid rule %q, expected name=regexp", errUsage, r)
+		}
+		rule, err := report.NewRedactionRule(name, expr)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		opts.Rules = append(opts.Rules, rule)
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		name = "-"
+	}
+	r, err := e.readReport(name, *in)
+	if err != nil {
+		return err
+	}
+	for _, rd := range r.Redact(opts) {
+		fmt.Fprintf(e.stderr, "%s: redacted %d %s\n", rd.Field, rd.Count, rd.Rule)
+	}
+	data, err := encodeReport(r, *to)
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, data)
+}
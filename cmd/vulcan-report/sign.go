@@ -0,0 +1,162 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// runSign signs a report, writing the signed report.
+func runSign(e *env, args []string) error {
+	fs := e.newFlagSet("sign", "-key file -key-id id [file]")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	out := fs.String("o", "", "output file, defaults to the standard output")
+	keyFile := fs.String("key", "", "PEM file with the Ed25519 private key in PKCS #8 form")
+	keyID := fs.String("key-id", "", "ID of the key, used to find its public key when verifying")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: sign accepts one input file", errUsage)
+	}
+	if *keyFile == "" || *keyID == "" {
+		return fmt.Errorf("%w: -key and -key-id are required", errUsage)
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+
+	key, err := e.readPrivateKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	if name == "" {
+		name = "-"
+	}
+	r, err := e.readReport(name, *in)
+	if err != nil {
+		return err
+	}
+	signed, err := report.NewSignedReport(r, *keyID, key)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return e.writeOutput(*out, data)
+}
+
+// runVerify verifies the signature of a signed report. It fails if the
+// signature is invalid or made with an unknown key.
+func runVerify(e *env, args []string) error {
+	fs := e.newFlagSet("verify", "-key id=file [file]")
+	var keyFiles stringList
+	fs.Var(&keyFiles, "key", "public key in the form id=file, with the file in PEM PKIX form, can be repeated")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: verify accepts one input file", errUsage)
+	}
+	if len(keyFiles) == 0 {
+		return fmt.Errorf("%w: at least one -key is required", errUsage)
+	}
+
+	keys := report.KeyRing{}
+	for _, k := range keyFiles {
+		id, file, ok := strings.Cut(k, "=")
+		if !ok || id == "" {
+			return fmt.Errorf("%w: invalid key %q, expected id=file", errUsage, k)
+		}
+		key, err := e.readPublicKey(file)
+		if err != nil {
+			return err
+		}
+		keys[id] = key
+	}
+	name := fs.Arg(0)
+	if name == "" {
+		name = "-"
+	}
+	data, err := e.readFile(name)
+	if err != nil {
+		return err
+	}
+	var signed report.SignedReport
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	err = signed.Verify(keys)
+	if errors.Is(err, report.ErrInvalidSignature) || errors.Is(err, report.ErrUnknownKey) {
+		fmt.Fprintf(e.stdout, "%s: %v\n", name, err)
+		return errFailure
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Fprintf(e.stdout, "%s: valid signature, key %q\n", name, signed.Signature.KeyID)
+	return nil
+}
+
+// readPEM reads the first PEM block of the given type in a file.
+func (e *env) readPEM(name, blockType string) ([]byte, error) {
+	data, err := e.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: missing PEM block %q", name, blockType)
+		}
+		if block.Type == blockType {
+			return block.Bytes, nil
+		}
+	}
+}
+
+func (e *env) readPrivateKey(name string) (ed25519.PrivateKey, error) {
+	der, err := e.readPEM(name, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	k, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 private key", name)
+	}
+	return k, nil
+}
+
+func (e *env) readPublicKey(name string) (ed25519.PublicKey, error) {
+	der, err := e.readPEM(name, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	k, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 public key", name)
+	}
+	return k, nil
+}
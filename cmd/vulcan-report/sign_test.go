@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func writeKeys(t *testing.T, seed byte) (string, string) {
+	t.Helper()
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal private key: %v", err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv})),
+		writeFile(t, "key.pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+}
+
+func TestRunSignAndVerify(t *testing.T) {
+	privKey, pubKey := writeKeys(t, 1)
+	_, otherPubKey := writeKeys(t, 2)
+	code, stdout, stderr := runTest([]string{"sign", "-key", privKey, "-key-id", "ci", writeReport(t, testReport())}, "")
+	if code != exitOK {
+		t.Fatalf("got exit code %d, want %d: %s", code, exitOK, stderr)
+	}
+	signed := writeFile(t, "signed.json", []byte(stdout))
+
+	var tampered report.SignedReport
+	if err := json.Unmarshal([]byte(stdout), &tampered); err != nil {
+		t.Fatalf("unmarshal signed report: %v", err)
+	}
+	tampered.Report.Vulnerabilities = nil
+	data, err := json.Marshal(tampered)
+	if err != nil {
+		t.Fatalf("marshal signed report: %v", err)
+	}
+	tamperedPath := writeFile(t, "tampered.json", data)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{
+			name:       "valid",
+			args:       []string{"-key", "ci=" + pubKey, signed},
+			wantCode:   exitOK,
+			wantStdout: `valid signature, key "ci"`,
+		},
+		{
+			name:       "tampered",
+			args:       []string{"-key", "ci=" + pubKey, tamperedPath},
+			wantCode:   exitFailure,
+			wantStdout: "invalid signature",
+		},
+		{
+			name:       "other key",
+			args:       []string{"-key", "ci=" + otherPubKey, signed},
+			wantCode:   exitFailure,
+			wantStdout: "invalid signature",
+		},
+		{
+			name:       "unknown key",
+			args:       []string{"-key", "release=" + pubKey, signed},
+			wantCode:   exitFailure,
+			wantStdout: `unknown key: "ci"`,
+		},
+		{
+			name:     "missing key",
+			args:     []string{signed},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "invalid key",
+			args:     []string{"-key", pubKey, signed},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "private key as public key",
+			args:     []string{"-key", "ci=" + privKey, signed},
+			wantCode: exitError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runTest(append([]string{"verify"}, tt.args...), "")
+			if code != tt.wantCode {
+				t.Fatalf("got exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("got stdout %q, want it to contain %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestRunSignUsage(t *testing.T) {
+	privKey, pubKey := writeKeys(t, 1)
+	path := writeReport(t, testReport())
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{name: "missing key", args: []string{"-key-id", "ci", path}, wantCode: exitUsage},
+		{name: "missing key ID", args: []string{"-key", privKey, path}, wantCode: exitUsage},
+		{name: "public key", args: []string{"-key", pubKey, "-key-id", "ci", path}, wantCode: exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runTest(append([]string{"sign"}, tt.args...), "")
+			if code != tt.wantCode {
+				t.Errorf("got exit code %d, want %d: %s", code, tt.wantCode, stderr)
+			}
+		})
+	}
+}
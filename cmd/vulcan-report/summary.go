@@ -0,0 +1,105 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// reportSummary summarizes a report.
+type reportSummary struct {
+	File            string                      `json:"file"`
+	CheckID         string                      `json:"check_id"`
+	Checktype       string                      `json:"checktype"`
+	Target          string                      `json:"target"`
+	Status          string                      `json:"status"`
+	Error           string                      `json:"error,omitempty"`
+	NotApplicable   bool                        `json:"not_applicable,omitempty"`
+	Vulnerabilities int                         `json:"vulnerabilities"`
+	Suppressed      int                         `json:"suppressed"`
+	Severities      map[report.SeverityName]int `json:"severities"`
+	MaxScore        float32                     `json:"max_score"`
+	SecurityStatus  string                      `json:"security_status"`
+}
+
+func summarize(name string, r report.Report) reportSummary {
+	s := reportSummary{
+		File:          name,
+		CheckID:       r.CheckID,
+		Checktype:     r.ChecktypeName,
+		Target:        r.Target,
+		Status:        r.Status,
+		Error:         r.Error,
+		NotApplicable: r.NotApplicable,
+		Severities:    map[report.SeverityName]int{},
+	}
+	for rank := report.SeverityNone; rank <= report.SeverityCritical; rank++ {
+		s.Severities[report.SeverityName(rank)] = 0
+	}
+	for _, v := range r.Vulnerabilities {
+		if v.Suppressed() {
+			s.Suppressed++
+			continue
+		}
+		s.Vulnerabilities++
+		s.Severities[report.SeverityName(v.Severity())]++
+		if v.Score > s.MaxScore {
+			s.MaxScore = v.Score
+		}
+	}
+	s.SecurityStatus = report.SecurityStatus(s.MaxScore)
+	return s
+}
+
+// runSummary prints the number of vulnerabilities per severity, the maximum
+// score and the security status of the reports. Suppressed vulnerabilities
+// are only counted.
+func runSummary(e *env, args []string) error {
+	fs := e.newFlagSet("summary", "[file ...]")
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	asJSON := fs.Bool("json", false, "print the summaries as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+	inputs, err := e.readInputs(fs.Args(), *in)
+	if err != nil {
+		return err
+	}
+
+	var summaries []reportSummary
+	for _, i := range inputs {
+		summaries = append(summaries, summarize(i.name, i.report))
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		return e.writeOutput("", data)
+	}
+	for _, s := range summaries {
+		fmt.Fprintf(e.stdout, "%s: %s %s %s\n", s.File, s.Checktype, s.Target, s.Status)
+		switch {
+		case s.Error != "":
+			fmt.Fprintf(e.stdout, "  error: %s\n", s.Error)
+		case s.NotApplicable:
+			fmt.Fprintln(e.stdout, "  not applicable")
+		}
+		var counts []string
+		for rank := report.SeverityCritical; rank >= report.SeverityNone; rank-- {
+			counts = append(counts, fmt.Sprintf("%s %d", rank, s.Severities[report.SeverityName(rank)]))
+		}
+		fmt.Fprintf(e.stdout, "  vulnerabilities: %d (%s), suppressed: %d\n", s.Vulnerabilities, strings.Join(counts, ", "), s.Suppressed)
+		fmt.Fprintf(e.stdout, "  max score: %.1f, security status: %s\n", s.MaxScore, s.SecurityStatus)
+	}
+	return nil
+}
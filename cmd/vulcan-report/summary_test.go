@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestSummarize(t *testing.T) {
+	got := summarize("report.json", testReport())
+	want := reportSummary{
+		File:            "report.json",
+		CheckID:         "ID0",
+		Checktype:       "vulcan-tls",
+		Target:          "example.com",
+		Status:          "FINISHED",
+		Vulnerabilities: 2,
+		Suppressed:      1,
+		Severities: map[report.SeverityName]int{
+			report.SeverityName(report.SeverityNone):     0,
+			report.SeverityName(report.SeverityLow):      0,
+			report.SeverityName(report.SeverityMedium):   1,
+			report.SeverityName(report.SeverityHigh):     1,
+			report.SeverityName(report.SeverityCritical): 0,
+		},
+		MaxScore:       8.9,
+		SecurityStatus: "F",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got summary %+v, want %+v", got, want)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	path := writeReport(t, testReport())
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout []string
+	}{
+		{
+			name: "text",
+			args: []string{path},
+			wantStdout: []string{
+				path + ": vulcan-tls example.com FINISHED",
+				"vulnerabilities: 2 (critical 0, high 1, medium 1, low 0, none 0), suppressed: 1",
+				"max score: 8.9, security status: F",
+			},
+		},
+		{
+			name:       "json",
+			args:       []string{"-json", path},
+			wantStdout: []string{`"high": 1`, `"max_score": 8.9`, `"security_status": "F"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runTest(append([]string{"summary"}, tt.args...), "")
+			if code != exitOK {
+				t.Fatalf("got exit code %d, want %d: %s", code, exitOK, stderr)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("got stdout %q, want it to contain %q", stdout, want)
+				}
+			}
+		})
+	}
+
+	t.Run("json is valid", func(t *testing.T) {
+		_, stdout, _ := runTest([]string{"summary", "-json", path, path}, "")
+		var got []reportSummary
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("unmarshal summaries: %v", err)
+		}
+		if len(got) != 2 {
+			t.Errorf("got %d summaries, want 2", len(got))
+		}
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package main
+
+import (
+	"fmt"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// runValidate validates the reports, printing the result for each one. It
+// fails if any report is invalid, and stops at the first report that can not
+// be read or decoded.
+func runValidate(e *env, args []string) error {
+	fs := e.newFlagSet("validate", "[file ...]")
+	var opts report.ValidationOptions
+	in := fs.String("in", formatAuto, "input format: auto, native or timestr")
+	fs.IntVar(&opts.MaxAttachmentSize, "max-attachment-size", 0, "maximum size in bytes of an attachment, 0 for no limit")
+	fs.IntVar(&opts.MaxReportAttachmentsSize, "max-attachments-size", 0, "maximum size in bytes of the attachments of a report, 0 for no limit")
+	fs.BoolVar(&opts.VerifyAttachmentContentType, "verify-content-type", false, "verify the content type of the attachments")
+	fs.BoolVar(&opts.ValidateTarget, "target", false, "verify the targets are valid assets")
+	fs.BoolVar(&opts.ValidateCWEID, "cwe", false, "verify the CWE-IDs are known")
+	fs.BoolVar(&opts.ValidateCVSSScore, "cvss-score", false, "verify the scores match their CVSS vectors")
+	fs.BoolVar(&opts.ValidateParentScore, "parent-score", false, "verify the scores of the parent vulnerabilities match their children")
+	fs.BoolVar(&opts.ValidateEndTime, "end-time", false, "verify the presence of the end time according to the status")
+	fs.DurationVar(&opts.MaxClockSkew, "max-clock-skew", 0, "how far in the future the times can be, 0 for no limit")
+	fs.DurationVar(&opts.MaxAge, "max-age", 0, "how far in the past the start time can be, 0 for no limit")
+	fs.DurationVar(&opts.MaxDuration, "max-duration", 0, "maximum duration of the checks, 0 for no limit")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*in, formatAuto, formatNative, formatTimeStr); err != nil {
+		return err
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	invalid := 0
+	for _, name := range files {
+		r, err := e.readReport(name, *in)
+		if err != nil {
+			return err
+		}
+		err = report.ValidateReportWithOptions(r, opts)
+		if err != nil {
+			invalid++
+			fmt.Fprintf(e.stdout, "%s: invalid: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(e.stdout, "%s: valid\n", name)
+	}
+	if invalid > 0 {
+		return errFailure
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunValidate(t *testing.T) {
+	valid := writeReport(t, testReport())
+	r := testReport()
+	r.Target = ""
+	invalid := writeReport(t, r)
+	r = testReport()
+	r.Vulnerabilities[0].CVSS = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+	mismatch := writeReport(t, r)
+	r = testReport()
+	r.Vulnerabilities[0].CWEID = 99999
+	unknownCWE := writeReport(t, r)
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:       "valid",
+			args:       []string{valid},
+			wantCode:   exitOK,
+			wantStdout: []string{valid + ": valid"},
+		},
+		{
+			name:     "invalid",
+			args:     []string{valid, invalid},
+			wantCode: exitFailure,
+			wantStdout: []string{
+				valid + ": valid",
+				invalid + ": invalid: report is missing target",
+			},
+		},
+		{
+			name:       "cvss score not verified",
+			args:       []string{mismatch},
+			wantCode:   exitOK,
+			wantStdout: []string{mismatch + ": valid"},
+		},
+		{
+			name:       "cvss score",
+			args:       []string{"-cvss-score", mismatch},
+			wantCode:   exitFailure,
+			wantStdout: []string{mismatch + ": invalid: vulnerability score 6.9 does not match the base score 9.8 of its CVSS vector"},
+		},
+		{
+			name:       "unknown cwe",
+			args:       []string{"-cwe", unknownCWE},
+			wantCode:   exitFailure,
+			wantStdout: []string{unknownCWE + ": invalid: vulnerability CWE-ID 99999 is unknown"},
+		},
+		{
+			name:       "max duration",
+			args:       []string{"-max-duration", "30m", valid},
+			wantCode:   exitFailure,
+			wantStdout: []string{valid + ": invalid: report duration 30m35s exceeds the limit of 30m0s"},
+		},
+		{
+			name:       "malformed stdin",
+			stdin:      "{",
+			wantCode:   exitError,
+			wantStderr: []string{"-: unexpected end of JSON input"},
+		},
+		{
+			name:       "missing file",
+			args:       []string{valid, "missing.json"},
+			wantCode:   exitError,
+			wantStdout: []string{valid + ": valid"},
+			wantStderr: []string{"missing.json"},
+		},
+		{
+			name:     "unknown format",
+			args:     []string{"-in", "xml", valid},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runTest(append([]string{"validate"}, tt.args...), tt.stdin)
+			if code != tt.wantCode {
+				t.Errorf("got exit code %d, want %d", code, tt.wantCode)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("got stdout %q, want it to contain %q", stdout, want)
+				}
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("got stderr %q, want it to contain %q", stderr, want)
+				}
+			}
+		})
+	}
+}
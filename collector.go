@@ -0,0 +1,235 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// LabelOverflow is the label of the vulnerability that reports the
+// vulnerabilities a Collector discarded for exceeding its maximum.
+const LabelOverflow = "overflow"
+
+// OverflowSummary is the summary of the vulnerability that reports the
+// vulnerabilities a Collector discarded for exceeding its maximum.
+const OverflowSummary = "Vulnerabilities Not Reported"
+
+// CollectorOptions defines how a Collector collects vulnerabilities.
+type CollectorOptions struct {
+	// Deduplicate keeps only one vulnerability per fingerprint, the one
+	// with the highest score. The vulnerabilities without fingerprint are
+	// never deduplicated.
+	Deduplicate bool
+	// MaxVulnerabilities is the maximum number of vulnerabilities
+	// collected, 0 means no limit. The ones with the highest score are kept
+	// and the rest are reported by a vulnerability with the OverflowSummary,
+	// the LabelOverflow and the highest score of the discarded ones.
+	MaxVulnerabilities int
+	// OverflowResource is the affected resource of the vulnerability
+	// reporting the discarded vulnerabilities, e.g. the target of the
+	// check. Defaults to "overflow".
+	OverflowResource string
+}
+
+// Collector collects the vulnerabilities found by a check from many
+// goroutines. Its result does not depend on the order the vulnerabilities
+// are added. The zero value is a collector without limit that does not
+// deduplicate.
+type Collector struct {
+	opts CollectorOptions
+
+	mu    sync.Mutex
+	vulns collectedHeap
+	// kept contains the deduplicated vulnerabilities by fingerprint.
+	kept map[string]*collected
+	// dropped contains the highest score of the discarded deduplicated
+	// vulnerabilities by fingerprint.
+	dropped map[string]float32
+	// droppedScore is the highest score of the other discarded
+	// vulnerabilities.
+	droppedScore float32
+	// total is the number of distinct vulnerabilities added.
+	total int
+}
+
+// collected is a vulnerability in canonical form kept by a Collector.
+type collected struct {
+	v     Vulnerability
+	key   canonicalKey
+	index int // Index in the heap.
+}
+
+// rankedBefore returns true if the vulnerability a is kept before b, that
+// is, it has a higher score or, with the same score, it goes before in the
+// canonical order.
+func rankedBefore(a, b *collected) bool {
+	if a.v.Score != b.v.Score {
+		return a.v.Score > b.v.Score
+	}
+	return a.key.less(b.key)
+}
+
+// collectedHeap is a heap of collected vulnerabilities with the lowest
+// ranked one at the top, so it is the first one discarded.
+type collectedHeap []*collected
+
+func (h collectedHeap) Len() int           { return len(h) }
+func (h collectedHeap) Less(i, j int) bool { return rankedBefore(h[j], h[i]) }
+
+func (h collectedHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *collectedHeap) Push(x interface{}) {
+	e := x.(*collected)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *collectedHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// NewCollector returns a collector with the given options.
+func NewCollector(opts CollectorOptions) *Collector {
+	return &Collector{opts: opts}
+}
+
+// AddVulnerabilities adds one or more vulnerabilities to the collector. It
+// is safe to call it from many goroutines.
+func (c *Collector) AddVulnerabilities(vulns ...Vulnerability) {
+	entries := make([]*collected, len(vulns))
+	for i, v := range vulns {
+		v = canonicalVulnerability(v)
+		entries[i] = &collected{v: v, key: newCanonicalKey(v)}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range entries {
+		c.add(e)
+	}
+}
+
+func (c *Collector) deduplicated(v Vulnerability) bool {
+	return c.opts.Deduplicate && v.Fingerprint != ""
+}
+
+func (c *Collector) add(e *collected) {
+	if c.deduplicated(e.v) {
+		if k, ok := c.kept[e.v.Fingerprint]; ok {
+			if rankedBefore(e, k) {
+				k.v, k.key = e.v, e.key
+				heap.Fix(&c.vulns, k.index)
+			}
+			return
+		}
+		if _, ok := c.dropped[e.v.Fingerprint]; !ok {
+			c.total++
+		}
+	} else {
+		c.total++
+	}
+
+	if c.opts.MaxVulnerabilities <= 0 || len(c.vulns) < c.opts.MaxVulnerabilities {
+		heap.Push(&c.vulns, e)
+		c.keep(e)
+		return
+	}
+	lowest := c.vulns[0]
+	if !rankedBefore(e, lowest) {
+		c.drop(e.v)
+		return
+	}
+	if c.deduplicated(lowest.v) {
+		delete(c.kept, lowest.v.Fingerprint)
+	}
+	c.drop(lowest.v)
+	e.index = 0
+	c.vulns[0] = e
+	heap.Fix(&c.vulns, 0)
+	c.keep(e)
+}
+
+// keep records a kept deduplicated vulnerability.
+func (c *Collector) keep(e *collected) {
+	if !c.deduplicated(e.v) {
+		return
+	}
+	if c.kept == nil {
+		c.kept = map[string]*collected{}
+	}
+	c.kept[e.v.Fingerprint] = e
+	delete(c.dropped, e.v.Fingerprint)
+}
+
+// drop records the score of a discarded vulnerability.
+func (c *Collector) drop(v Vulnerability) {
+	if !c.deduplicated(v) {
+		if v.Score > c.droppedScore {
+			c.droppedScore = v.Score
+		}
+		return
+	}
+	if c.dropped == nil {
+		c.dropped = map[string]float32{}
+	}
+	if score, ok := c.dropped[v.Fingerprint]; !ok || v.Score > score {
+		c.dropped[v.Fingerprint] = v.Score
+	}
+}
+
+// Result returns the collected vulnerabilities in canonical order, see
+// Report.Canonical, followed by the vulnerability reporting the discarded
+// ones, if any. The other fields of the result are left to the caller.
+func (c *Collector) Result() ResultData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := append([]*collected(nil), c.vulns...)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key.less(entries[j].key)
+	})
+	var vulns []Vulnerability
+	for _, e := range entries {
+		vulns = append(vulns, e.v)
+	}
+	if n := c.total - len(c.vulns); n > 0 {
+		vulns = append(vulns, c.overflow(n))
+	}
+	return ResultData{Vulnerabilities: vulns}
+}
+
+// overflow returns the vulnerability reporting the n discarded
+// vulnerabilities.
+func (c *Collector) overflow(n int) Vulnerability {
+	score := c.droppedScore
+	for _, s := range c.dropped {
+		if s > score {
+			score = s
+		}
+	}
+	resource := c.opts.OverflowResource
+	if resource == "" {
+		resource = "overflow"
+	}
+	return Vulnerability{
+		Summary:          OverflowSummary,
+		Score:            score,
+		AffectedResource: resource,
+		Details:          overflowDetails(n, c.opts.MaxVulnerabilities),
+		Labels:           []string{LabelOverflow},
+	}
+}
+
+func overflowDetails(n, max int) string {
+	return fmt.Sprintf("%d vulnerabilities were not reported because the check found more than the maximum of %d.", n, max)
+}
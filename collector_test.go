@@ -0,0 +1,146 @@
+package report
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCollector(t *testing.T) {
+	vuln := func(fingerprint, summary string, score float32) Vulnerability {
+		return Vulnerability{Fingerprint: fingerprint, Summary: summary, AffectedResource: "port-80", Score: score}
+	}
+	overflow := func(n int, max int, score float32) Vulnerability {
+		return Vulnerability{
+			Summary:          OverflowSummary,
+			Score:            score,
+			AffectedResource: "example.com",
+			Details:          overflowDetails(n, max),
+			Labels:           []string{LabelOverflow},
+		}
+	}
+	tests := []struct {
+		name  string
+		opts  CollectorOptions
+		vulns []Vulnerability
+		want  []Vulnerability
+	}{
+		{
+			name: "no vulnerabilities",
+		},
+		{
+			name:  "sorted",
+			vulns: []Vulnerability{vuln("b", "b", 1), vuln("a", "b", 2), {Summary: "c", Labels: []string{"web", "issue"}}},
+			want:  []Vulnerability{{Summary: "c", Labels: []string{"issue", "web"}}, vuln("a", "b", 2), vuln("b", "b", 1)},
+		},
+		{
+			name:  "duplicates kept",
+			vulns: []Vulnerability{vuln("a", "a", 1), vuln("a", "a", 1)},
+			want:  []Vulnerability{vuln("a", "a", 1), vuln("a", "a", 1)},
+		},
+		{
+			name:  "deduplicated",
+			opts:  CollectorOptions{Deduplicate: true},
+			vulns: []Vulnerability{vuln("a", "a", 1), vuln("a", "b", 5), vuln("b", "b", 1), vuln("", "c", 1), vuln("", "c", 1)},
+			want:  []Vulnerability{vuln("", "c", 1), vuln("", "c", 1), vuln("a", "b", 5), vuln("b", "b", 1)},
+		},
+		{
+			name:  "max vulnerabilities",
+			opts:  CollectorOptions{MaxVulnerabilities: 2, OverflowResource: "example.com"},
+			vulns: []Vulnerability{vuln("a", "a", 1), vuln("b", "b", 9), vuln("c", "c", 3), vuln("d", "d", 5), vuln("e", "e", 3)},
+			want:  []Vulnerability{vuln("b", "b", 9), vuln("d", "d", 5), overflow(3, 2, 3)},
+		},
+		{
+			name:  "max vulnerabilities with same score",
+			opts:  CollectorOptions{MaxVulnerabilities: 2, OverflowResource: "example.com"},
+			vulns: []Vulnerability{vuln("c", "c", 1), vuln("a", "a", 1), vuln("b", "b", 1)},
+			want:  []Vulnerability{vuln("a", "a", 1), vuln("b", "b", 1), overflow(1, 2, 1)},
+		},
+		{
+			name: "deduplicated with max vulnerabilities",
+			opts: CollectorOptions{Deduplicate: true, MaxVulnerabilities: 2, OverflowResource: "example.com"},
+			vulns: []Vulnerability{
+				vuln("a", "a", 1), vuln("a", "a", 2), vuln("b", "b", 6), vuln("c", "c", 3),
+				vuln("c", "c", 7), vuln("d", "d", 4), vuln("d", "d", 5), vuln("b", "b", 0),
+			},
+			want: []Vulnerability{vuln("b", "b", 6), vuln("c", "c", 7), overflow(2, 2, 5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := map[string]func(c *Collector){
+				"in order": func(c *Collector) {
+					c.AddVulnerabilities(tt.vulns...)
+				},
+				"reversed": func(c *Collector) {
+					for i := len(tt.vulns) - 1; i >= 0; i-- {
+						c.AddVulnerabilities(tt.vulns[i])
+					}
+				},
+				"concurrent": func(c *Collector) {
+					var wg sync.WaitGroup
+					for _, v := range tt.vulns {
+						wg.Add(1)
+						go func(v Vulnerability) {
+							defer wg.Done()
+							c.AddVulnerabilities(v)
+						}(v)
+					}
+					wg.Wait()
+				},
+			}
+			for name, add := range orders {
+				c := NewCollector(tt.opts)
+				add(c)
+				got := c.Result()
+				if !reflect.DeepEqual(got.Vulnerabilities, tt.want) {
+					t.Errorf("%s: got %+v, want %+v", name, got.Vulnerabilities, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectorZeroValue(t *testing.T) {
+	var c Collector
+	c.AddVulnerabilities(vulnerabilityWithScore(1))
+	got := c.Result()
+	want := ResultData{Vulnerabilities: []Vulnerability{vulnerabilityWithScore(1)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCollectorOverflowIsValid(t *testing.T) {
+	c := NewCollector(CollectorOptions{MaxVulnerabilities: 1})
+	c.AddVulnerabilities(vulnerabilityWithScore(1), vulnerabilityWithScore(2))
+	r := Report{CheckData: cd0, ResultData: c.Result()}
+	if err := ValidateReport(r); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCollectorKeepsHighestScores(t *testing.T) {
+	const n, max = 200, 10
+	var vulns []Vulnerability
+	for i := 0; i < n; i++ {
+		// Scores are spread so the order they are added in is not sorted.
+		score := float32((i*37)%n) / 20
+		vulns = append(vulns, Vulnerability{Summary: fmt.Sprintf("v%03d", i), AffectedResource: "port-80", Score: score})
+	}
+	c := NewCollector(CollectorOptions{MaxVulnerabilities: max})
+	c.AddVulnerabilities(vulns...)
+	got := c.Result().Vulnerabilities
+	if len(got) != max+1 {
+		t.Fatalf("got %d vulnerabilities, want %d", len(got), max+1)
+	}
+	for _, v := range got[:max] {
+		if v.Score < float32(n-max)/20 {
+			t.Errorf("got vulnerability %s with score %v, want only the highest scores", v.Summary, v.Score)
+		}
+	}
+	if overflow := got[max]; overflow.Score != float32(n-max-1)/20 || overflow.Details != overflowDetails(n-max, max) {
+		t.Errorf("got overflow vulnerability %+v", overflow)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrUnsupportedCVSSVersion is returned when computing the score of a CVSS
+// vector whose version is not supported, e.g. CVSS v4.0.
+var ErrUnsupportedCVSSVersion = errors.New("unsupported CVSS version")
+
+// cvssMetrics defines the metrics of a CVSS version: the base metrics, which
+// are mandatory, in the order of the vectors and their weights, and the names
+// of the optional temporal and environmental ones, whose values are not
+// checked.
+type cvssMetrics struct {
+	names    []string
+	base     map[string]map[string]float64
+	optional []string
+}
+
+var cvss2Metrics = cvssMetrics{
+	names: []string{"AV", "AC", "Au", "C", "I", "A"},
+	base: map[string]map[string]float64{
+		"AV": {"L": 0.395, "A": 0.646, "N": 1},
+		"AC": {"H": 0.35, "M": 0.61, "L": 0.71},
+		"Au": {"M": 0.45, "S": 0.56, "N": 0.704},
+		"C":  {"N": 0, "P": 0.275, "C": 0.66},
+		"I":  {"N": 0, "P": 0.275, "C": 0.66},
+		"A":  {"N": 0, "P": 0.275, "C": 0.66},
+	},
+	optional: []string{"E", "RL", "RC", "CDP", "TD", "CR", "IR", "AR"},
+}
+
+// cvss3Metrics contains the weights of the privileges required with the
+// scope unchanged, see cvss3ChangedPR. The scope has no weight, it selects
+// the formulas used.
+var cvss3Metrics = cvssMetrics{
+	names: []string{"AV", "AC", "PR", "UI", "S", "C", "I", "A"},
+	base: map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+		"UI": {"N": 0.85, "R": 0.62},
+		"S":  {"U": 0, "C": 1},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	},
+	optional: []string{"E", "RL", "RC", "CR", "IR", "AR", "MAV", "MAC", "MPR", "MUI", "MS", "MC", "MI", "MA"},
+}
+
+// cvss3ChangedPR contains the weights of the privileges required with the
+// scope changed.
+var cvss3ChangedPR = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+
+// parse returns the values of the base metrics of a vector without prefix.
+func (m cvssMetrics) parse(vector string) (map[string]string, error) {
+	values := map[string]string{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(vector, "/") {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid CVSS metric %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicated CVSS metric %q", name)
+		}
+		seen[name] = true
+		if weights, ok := m.base[name]; ok {
+			if _, ok := weights[value]; !ok {
+				return nil, fmt.Errorf("invalid value %q of CVSS metric %q", value, name)
+			}
+			values[name] = value
+			continue
+		}
+		if !containsString(m.optional, name) {
+			return nil, fmt.Errorf("unknown CVSS metric %q", name)
+		}
+	}
+	for _, name := range m.names {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("missing CVSS metric %q", name)
+		}
+	}
+	return values, nil
+}
+
+// weights returns the weights of the values of the base metrics.
+func (m cvssMetrics) weights(values map[string]string) map[string]float64 {
+	weights := map[string]float64{}
+	for name, value := range values {
+		weights[name] = m.base[name][value]
+	}
+	return weights
+}
+
+// CVSSBaseScore returns the base score of a CVSS v2, v3.0 or v3.1 vector.
+// CVSS v3 vectors start with their version, e.g. "CVSS:3.1/AV:N/...", while
+// CVSS v2 vectors have no prefix, e.g. "AV:N/AC:L/Au:N/C:P/I:P/A:P". The
+// temporal and environmental metrics are accepted but ignored. It returns
+// an error wrapping ErrUnsupportedCVSSVersion for other versions.
+func CVSSBaseScore(vector string) (float32, error) {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.0/"):
+		return cvss3BaseScore(strings.TrimPrefix(vector, "CVSS:3.0/"), roundUp30)
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		return cvss3BaseScore(strings.TrimPrefix(vector, "CVSS:3.1/"), roundUp31)
+	case strings.HasPrefix(vector, "CVSS:"):
+		version, _, _ := strings.Cut(strings.TrimPrefix(vector, "CVSS:"), "/")
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCVSSVersion, version)
+	}
+	return cvss2BaseScore(strings.TrimPrefix(vector, "CVSS2#"))
+}
+
+func cvss2BaseScore(vector string) (float32, error) {
+	values, err := cvss2Metrics.parse(strings.TrimSuffix(strings.TrimPrefix(vector, "("), ")"))
+	if err != nil {
+		return 0, err
+	}
+	w := cvss2Metrics.weights(values)
+	impact := 10.41 * (1 - (1-w["C"])*(1-w["I"])*(1-w["A"]))
+	exploitability := 20 * w["AV"] * w["AC"] * w["Au"]
+	if impact == 0 {
+		return 0, nil
+	}
+	score := (0.6*impact + 0.4*exploitability - 1.5) * 1.176
+	return float32(math.Round(score*10) / 10), nil
+}
+
+func cvss3BaseScore(vector string, roundUp func(float64) float64) (float32, error) {
+	values, err := cvss3Metrics.parse(vector)
+	if err != nil {
+		return 0, err
+	}
+	w := cvss3Metrics.weights(values)
+	changed := values["S"] == "C"
+	pr := w["PR"]
+	if changed {
+		pr = cvss3ChangedPR[values["PR"]]
+	}
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * pr * w["UI"]
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return float32(roundUp(math.Min(score, 10))), nil
+}
+
+// roundUp30 rounds up to one decimal as defined by CVSS v3.0.
+func roundUp30(x float64) float64 {
+	return math.Ceil(x*10) / 10
+}
+
+// roundUp31 rounds up to one decimal as defined by CVSS v3.1, avoiding
+// floating point errors.
+func roundUp31(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+// equalScores returns true if two scores are equal when rounded to one
+// decimal, the precision of the CVSS scores.
+func equalScores(a, b float32) bool {
+	return math.Round(float64(a)*10) == math.Round(float64(b)*10)
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCVSSBaseScore(t *testing.T) {
+	tests := []struct {
+		vector      string
+		want        float32
+		wantErr     bool
+		unsupported bool
+	}{
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", want: 9.8},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", want: 10},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", want: 7.2},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", want: 6.1},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", want: 6.4},
+		{vector: "CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", want: 1.6},
+		{vector: "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:N/I:N/A:N", want: 0},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", want: 9.8},
+		{vector: "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:N", want: 6.5},
+		{vector: "CVSS:3.0/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:H/A:N", want: 5.9},
+		{vector: "AV:N/AC:L/Au:N/C:P/I:P/A:N", want: 6.4},
+		{vector: "AV:N/AC:L/Au:N/C:C/I:C/A:C", want: 10},
+		{vector: "AV:N/AC:M/Au:N/C:N/I:P/A:N", want: 4.3},
+		{vector: "AV:N/AC:L/Au:N/C:N/I:N/A:N", want: 0},
+		{vector: "CVSS2#AV:N/AC:L/Au:N/C:P/I:P/A:P", want: 7.5},
+		{vector: "(AV:N/AC:L/Au:N/C:P/I:P/A:P/E:F/RL:OF/RC:C)", want: 7.5},
+		{vector: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", wantErr: true, unsupported: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", wantErr: true},
+		{vector: "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", wantErr: true},
+		{vector: "CVSS:3.1/AV:N/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", wantErr: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/XX:Y", wantErr: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/Au:N/C:P/I:P/A:P", wantErr: true},
+		{vector: "not a vector", wantErr: true},
+		{vector: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			got, err := CVSSBaseScore(tt.vector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUnsupportedCVSSVersion) != tt.unsupported {
+				t.Errorf("got error %v, want unsupported version %v", err, tt.unsupported)
+			}
+			if got != tt.want {
+				t.Errorf("got score %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
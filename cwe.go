@@ -0,0 +1,158 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//go:generate go run ./internal/cwegen -o data/cwe.json
+
+// cweCatalogData is a snapshot of the MITRE CWE list, generated from the XML
+// catalog published by MITRE. It contains all the weaknesses and categories,
+// with the parents of the weaknesses in the Research Concepts view.
+//
+//go:embed data/cwe.json
+var cweCatalogData []byte
+
+var (
+	cweCatalogOnce sync.Once
+	cweCatalog     cweCatalogSnapshot
+)
+
+type cweCatalogSnapshot struct {
+	Version    string `json:"version"`
+	Weaknesses []CWE  `json:"weaknesses"`
+
+	byID     map[uint32]CWE
+	children map[uint32][]uint32
+}
+
+// CWE represents a weakness of the MITRE CWE catalog.
+type CWE struct {
+	ID          uint32   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Parents     []uint32 `json:"parents,omitempty"` // IDs of the weaknesses this one is a child of.
+}
+
+// URL returns the address of the definition of the weakness at MITRE.
+func (c CWE) URL() string {
+	return CWEURL(c.ID)
+}
+
+// CWEURL returns the address of the definition of a weakness at MITRE.
+func CWEURL(id uint32) string {
+	return fmt.Sprintf("https://cwe.mitre.org/data/definitions/%d.html", id)
+}
+
+func loadCWECatalog() *cweCatalogSnapshot {
+	cweCatalogOnce.Do(func() {
+		if err := json.Unmarshal(cweCatalogData, &cweCatalog); err != nil {
+			panic(fmt.Sprintf("invalid embedded CWE catalog: %s", err))
+		}
+		cweCatalog.byID = make(map[uint32]CWE, len(cweCatalog.Weaknesses))
+		cweCatalog.children = make(map[uint32][]uint32)
+		for _, c := range cweCatalog.Weaknesses {
+			cweCatalog.byID[c.ID] = c
+			for _, p := range c.Parents {
+				cweCatalog.children[p] = append(cweCatalog.children[p], c.ID)
+			}
+		}
+	})
+	return &cweCatalog
+}
+
+// CWECatalogVersion returns the version of the CWE list the embedded
+// catalog was taken from.
+func CWECatalogVersion() string {
+	return loadCWECatalog().Version
+}
+
+// LookupCWE returns the weakness with the given ID from the embedded catalog.
+func LookupCWE(id uint32) (CWE, bool) {
+	c, ok := loadCWECatalog().byID[id]
+	return c, ok
+}
+
+// CWEParents returns the weaknesses the given one is a direct child of.
+func CWEParents(id uint32) []CWE {
+	c, ok := LookupCWE(id)
+	if !ok {
+		return nil
+	}
+	var parents []CWE
+	for _, p := range c.Parents {
+		if parent, ok := LookupCWE(p); ok {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// CWEChildren returns the weaknesses that are direct children of the given
+// one, sorted by ID.
+func CWEChildren(id uint32) []CWE {
+	ids := append([]uint32(nil), loadCWECatalog().children[id]...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var children []CWE
+	for _, c := range ids {
+		child, _ := LookupCWE(c)
+		children = append(children, child)
+	}
+	return children
+}
+
+// CWEAncestors returns the IDs of all the weaknesses the given one descends
+// from, sorted by ID.
+func CWEAncestors(id uint32) []uint32 {
+	seen := map[uint32]bool{}
+	pending := []uint32{id}
+	for len(pending) > 0 {
+		c := pending[0]
+		pending = pending[1:]
+		for _, p := range CWEParents(c) {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				pending = append(pending, p.ID)
+			}
+		}
+	}
+	ancestors := make([]uint32, 0, len(seen))
+	for a := range seen {
+		ancestors = append(ancestors, a)
+	}
+	sort.Slice(ancestors, func(i, j int) bool { return ancestors[i] < ancestors[j] })
+	return ancestors
+}
+
+// Enrich fills the empty Description and References of the vulnerability
+// and its children with the information of its CWE from the embedded
+// catalog.
+func (v *Vulnerability) Enrich() {
+	if c, ok := LookupCWE(v.CWEID); ok {
+		if v.Description == "" {
+			v.Description = c.Description
+		}
+		if len(v.References) == 0 {
+			v.References = []string{c.URL()}
+		}
+	}
+	for i := range v.Vulnerabilities {
+		v.Vulnerabilities[i].Enrich()
+	}
+}
+
+// EnrichVulnerabilities enriches all the vulnerabilities of the report
+// using the embedded CWE catalog. See Vulnerability.Enrich.
+func (r *ResultData) EnrichVulnerabilities() {
+	for i := range r.Vulnerabilities {
+		r.Vulnerabilities[i].Enrich()
+	}
+}
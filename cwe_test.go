@@ -0,0 +1,95 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupCWE(t *testing.T) {
+	c, ok := LookupCWE(79)
+	if !ok {
+		t.Fatalf("CWE-79 not found in catalog")
+	}
+	if c.Name != "Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting')" {
+		t.Errorf("unexpected CWE-79 name: %s", c.Name)
+	}
+	if _, ok := LookupCWE(99999); ok {
+		t.Errorf("unexpected CWE-99999 found in catalog")
+	}
+	if CWECatalogVersion() == "" {
+		t.Errorf("catalog version is empty")
+	}
+}
+
+func TestCWERelations(t *testing.T) {
+	var parents []uint32
+	for _, p := range CWEParents(89) {
+		parents = append(parents, p.ID)
+	}
+	if !reflect.DeepEqual(parents, []uint32{943}) {
+		t.Errorf("unexpected CWE-89 parents: have: %v - want: %v", parents, []uint32{943})
+	}
+
+	var children []uint32
+	for _, c := range CWEChildren(77) {
+		children = append(children, c.ID)
+	}
+	if !reflect.DeepEqual(children, []uint32{78, 88}) {
+		t.Errorf("unexpected CWE-77 children: have: %v - want: %v", children, []uint32{78, 88})
+	}
+
+	ancestors := CWEAncestors(78)
+	if !reflect.DeepEqual(ancestors, []uint32{74, 77, 707}) {
+		t.Errorf("unexpected CWE-78 ancestors: have: %v - want: %v", ancestors, []uint32{74, 77, 707})
+	}
+}
+
+func TestCWECatalogConsistency(t *testing.T) {
+	for _, c := range loadCWECatalog().Weaknesses {
+		for _, p := range c.Parents {
+			if _, ok := LookupCWE(p); !ok {
+				t.Errorf("parent CWE-%d of CWE-%d is missing from the catalog", p, c.ID)
+			}
+		}
+	}
+}
+
+func TestVulnerabilityEnrich(t *testing.T) {
+	v := vulnerabilityWithScore(6.9)
+	v.CWEID = 89
+	child := vulnerabilityWithScore(3.9)
+	child.CWEID = 79
+	child.Description = "custom description"
+	child.References = []string{"https://example.com"}
+	v.AddVulnerabilities(child)
+
+	v.Enrich()
+
+	c, _ := LookupCWE(89)
+	if v.Description != c.Description {
+		t.Errorf("description not enriched: have: %s - want: %s", v.Description, c.Description)
+	}
+	if !reflect.DeepEqual(v.References, []string{"https://cwe.mitre.org/data/definitions/89.html"}) {
+		t.Errorf("references not enriched: have: %v", v.References)
+	}
+	if v.Vulnerabilities[0].Description != "custom description" ||
+		!reflect.DeepEqual(v.Vulnerabilities[0].References, []string{"https://example.com"}) {
+		t.Errorf("existing information was overwritten: %+v", v.Vulnerabilities[0])
+	}
+}
+
+func TestValidateVulnerabilityCWEID(t *testing.T) {
+	v := vulnerabilityWithScore(3.9)
+	v.CWEID = 99999
+	if err := v.Validate(); err != nil {
+		t.Errorf("unexpected error without CWE validation: %s", err)
+	}
+	err := ValidateVulnerabilityWithOptions(v, ValidationOptions{ValidateCWEID: true})
+	if err == nil || err.Error() != "vulnerability CWE-ID 99999 is unknown" {
+		t.Errorf("unexpected error with CWE validation: %v", err)
+	}
+	v.CWEID = 79
+	if err := ValidateVulnerabilityWithOptions(v, ValidationOptions{ValidateCWEID: true}); err != nil {
+		t.Errorf("unexpected error with CWE validation: %s", err)
+	}
+}
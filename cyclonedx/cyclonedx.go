@@ -0,0 +1,323 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package cyclonedx converts Vulcan reports to CycloneDX vulnerability BOMs,
+// also usable as VEX documents.
+package cyclonedx
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// SpecVersion is the version of the CycloneDX specification the BOMs
+// conform to.
+const SpecVersion = "1.5"
+
+// Rating methods defined by CycloneDX.
+const (
+	MethodCVSSv2  = "CVSSv2"
+	MethodCVSSv3  = "CVSSv3"
+	MethodCVSSv31 = "CVSSv31"
+	MethodCVSSv4  = "CVSSv4"
+	MethodOther   = "other"
+)
+
+// AnalysisStateNotAffected is the CycloneDX analysis state recorded for
+// suppressed vulnerabilities.
+const AnalysisStateNotAffected = "not_affected"
+
+var cveRegexp = regexp.MustCompile(`\bCVE-\d{4}-\d{4,}\b`)
+
+// BOM is a CycloneDX bill of materials.
+type BOM struct {
+	BOMFormat       string          `json:"bomFormat"`
+	SpecVersion     string          `json:"specVersion"`
+	SerialNumber    string          `json:"serialNumber"`
+	Version         int             `json:"version"`
+	Metadata        Metadata        `json:"metadata"`
+	Components      []Component     `json:"components,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Metadata describes the BOM.
+type Metadata struct {
+	Timestamp string     `json:"timestamp,omitempty"`
+	Tools     *Tools     `json:"tools,omitempty"`
+	Component *Component `json:"component,omitempty"`
+}
+
+// Tools lists the tools used to create the BOM.
+type Tools struct {
+	Components []Component `json:"components"`
+}
+
+// Component is a software component.
+type Component struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// Vulnerability is a vulnerability affecting components of the BOM.
+type Vulnerability struct {
+	BOMRef         string     `json:"bom-ref,omitempty"`
+	ID             string     `json:"id"`
+	Source         *Source    `json:"source,omitempty"`
+	Ratings        []Rating   `json:"ratings,omitempty"`
+	CWEs           []uint32   `json:"cwes,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	Detail         string     `json:"detail,omitempty"`
+	Recommendation string     `json:"recommendation,omitempty"`
+	Advisories     []Advisory `json:"advisories,omitempty"`
+	Created        string     `json:"created,omitempty"`
+	Analysis       *Analysis  `json:"analysis,omitempty"`
+	Affects        []Affect   `json:"affects,omitempty"`
+	Properties     []Property `json:"properties,omitempty"`
+}
+
+// Source is the source of a vulnerability or rating.
+type Source struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Rating is a severity rating of a vulnerability.
+type Rating struct {
+	Source   *Source `json:"source,omitempty"`
+	Score    float32 `json:"score"`
+	Severity string  `json:"severity"`
+	Method   string  `json:"method"`
+	Vector   string  `json:"vector,omitempty"`
+}
+
+// Advisory is a reference to information about a vulnerability.
+type Advisory struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// Analysis records the assessment of the impact of a vulnerability.
+type Analysis struct {
+	State  string `json:"state"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Affect references a component affected by a vulnerability.
+type Affect struct {
+	Ref string `json:"ref"`
+}
+
+// Property is a name-value pair.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Severity maps a Vulcan score to a CycloneDX severity.
+func Severity(score float32) string {
+	switch report.RankSeverity(score) {
+	case report.SeverityNone:
+		return "info"
+	case report.SeverityLow:
+		return "low"
+	case report.SeverityMedium:
+		return "medium"
+	case report.SeverityHigh:
+		return "high"
+	}
+	return "critical"
+}
+
+// RatingMethod returns the CycloneDX rating method of a CVSS vector.
+func RatingMethod(vector string) string {
+	switch {
+	case vector == "":
+		return MethodOther
+	case strings.HasPrefix(vector, "CVSS:4.0/"):
+		return MethodCVSSv4
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		return MethodCVSSv31
+	case strings.HasPrefix(vector, "CVSS:3.0/"):
+		return MethodCVSSv3
+	case strings.HasPrefix(vector, "AV:"):
+		return MethodCVSSv2
+	}
+	return MethodOther
+}
+
+// Convert returns the CycloneDX BOM listing the vulnerabilities of the
+// report and the components they affect. Vulnerabilities with children are
+// flattened into one vulnerability per child, see
+// report.Vulnerability.Flatten. The affected component is the package of the
+// vulnerability or, if it has none, its affected resource.
+func Convert(r report.Report) BOM {
+	bom := BOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  SpecVersion,
+		SerialNumber: serialNumber(r),
+		Version:      1,
+		Metadata: Metadata{
+			Tools: &Tools{Components: []Component{{
+				Type:    "application",
+				Name:    r.ChecktypeName,
+				Version: r.ChecktypeVersion,
+			}}},
+			Component: targetComponent(r.Target),
+		},
+		Vulnerabilities: []Vulnerability{},
+	}
+	if !r.EndTime.IsZero() {
+		bom.Metadata.Timestamp = r.EndTime.UTC().Format(time.RFC3339)
+	}
+
+	components := map[string]bool{}
+	for _, v := range r.FlattenVulnerabilities() {
+		cv := convertVulnerability(r, v)
+		var c Component
+		switch {
+		case v.Package != nil && v.Package.PURL != "":
+			c = packageComponent(*v.Package)
+		case v.AffectedResource != "":
+			c = component(v.AffectedResource)
+		}
+		if c.BOMRef != "" {
+			if !components[c.BOMRef] {
+				components[c.BOMRef] = true
+				bom.Components = append(bom.Components, c)
+			}
+			cv.Affects = []Affect{{Ref: c.BOMRef}}
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, cv)
+	}
+	return bom
+}
+
+func convertVulnerability(r report.Report, fv report.FlatVulnerability) Vulnerability {
+	v := fv.Vulnerability
+	cv := Vulnerability{
+		BOMRef:         report.VulnerabilityIdentity(r, fv),
+		ID:             vulnerabilityID(r, fv),
+		Description:    v.Description,
+		Detail:         v.Details,
+		Recommendation: strings.Join(v.Recommendations, "\n"),
+		Ratings: []Rating{{
+			Source:   &Source{Name: "Vulcan"},
+			Score:    v.Score,
+			Severity: Severity(v.Score),
+			Method:   RatingMethod(v.CVSS),
+			Vector:   v.CVSS,
+		}},
+	}
+	if cve := cveRegexp.FindString(v.Summary); cve != "" {
+		cv.ID = cve
+		cv.Source = &Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + cve}
+	}
+	if v.CWEID != 0 {
+		cv.CWEs = []uint32{v.CWEID}
+	}
+	for _, ref := range v.References {
+		cv.Advisories = append(cv.Advisories, Advisory{URL: ref})
+	}
+	if !r.StartTime.IsZero() {
+		cv.Created = r.StartTime.UTC().Format(time.RFC3339)
+	}
+	if v.Suppressed() {
+		cv.Analysis = &Analysis{
+			State:  AnalysisStateNotAffected,
+			Detail: "The vulnerability has been suppressed in Vulcan.",
+		}
+	}
+	cv.Properties = append(cv.Properties, Property{Name: "vulcan:summary", Value: v.Summary})
+	if v.Fingerprint != "" {
+		cv.Properties = append(cv.Properties, Property{Name: "vulcan:fingerprint", Value: v.Fingerprint})
+	}
+	return cv
+}
+
+// component returns the component identified by an affected resource. The
+// resource can be a package URL, a "name@version" pair or any other string,
+// which is used as the name of the component.
+func component(resource string) Component {
+	c := Component{Type: "library", BOMRef: resource, Name: resource}
+	if strings.HasPrefix(resource, "pkg:") {
+		c.PURL = resource
+		name := strings.TrimPrefix(resource, "pkg:")
+		if i := strings.IndexAny(name, "?#"); i >= 0 {
+			name = name[:i]
+		}
+		if i := strings.LastIndex(name, "@"); i > 0 {
+			name, c.Version = name[:i], name[i+1:]
+		}
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		c.Name = name
+		return c
+	}
+	if i := strings.LastIndex(resource, "@"); i > 0 {
+		c.Name, c.Version = resource[:i], resource[i+1:]
+	}
+	return c
+}
+
+// packageComponent returns the library component of an affected package,
+// identified by its package URL including the installed version.
+func packageComponent(p report.AffectedPackage) Component {
+	purl, err := report.ParsePackageURL(p.PURL)
+	if err != nil {
+		return component(p.PURL)
+	}
+	purl.Version = p.Version()
+	c := Component{Type: "library", Name: purl.Name, Version: purl.Version, PURL: purl.String()}
+	if purl.Namespace != "" {
+		c.Name = purl.Namespace + "/" + purl.Name
+	}
+	c.BOMRef = c.PURL
+	return c
+}
+
+func targetComponent(target string) *Component {
+	if target == "" {
+		return nil
+	}
+	c := &Component{Type: "application", BOMRef: target, Name: target}
+	t, err := report.ParseTarget(target)
+	if err != nil {
+		return c
+	}
+	switch t.Type {
+	case report.AssetTypeDockerImage:
+		c.Type, c.Name, c.Version = "container", t.Registry+"/"+t.Repository, t.Tag
+	case report.AssetTypeGitRepository:
+		c.Name = t.Normalized()
+	}
+	return c
+}
+
+// vulnerabilityID returns the ID of the vulnerability or, if it does not
+// have one, an ID derived from its identity, see
+// report.VulnerabilityIdentity.
+func vulnerabilityID(r report.Report, v report.FlatVulnerability) string {
+	if v.ID != "" {
+		return v.ID
+	}
+	return report.VulnerabilityIdentity(r, v)[:32]
+}
+
+// serialNumber returns a UUID URN that identifies the BOM of a report.
+func serialNumber(r report.Report) string {
+	sum := sha256.Sum256([]byte(r.CheckID + "\x00" + r.Target))
+	// Set the version and variant bits of a name based UUID.
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
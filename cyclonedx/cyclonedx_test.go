@@ -0,0 +1,189 @@
+package cyclonedx
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func testReport(vulns ...report.Vulnerability) report.Report {
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          "ID0",
+			ChecktypeName:    "vulcan-trivy",
+			ChecktypeVersion: "1",
+			Target:           "registry.example.com/team/app:1.0",
+			Status:           "FINISHED",
+			StartTime:        time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+			EndTime:          time.Date(2021, 5, 18, 14, 0, 50, 0, time.UTC),
+		},
+		ResultData: report.ResultData{Vulnerabilities: vulns},
+	}
+}
+
+func TestRatingMethod(t *testing.T) {
+	tests := map[string]string{
+		"": MethodOther,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H":                    MethodCVSSv31,
+		"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H":                    MethodCVSSv3,
+		"AV:N/AC:L/Au:N/C:P/I:P/A:P":                                      MethodCVSSv2,
+		"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N": MethodCVSSv4,
+	}
+	for vector, want := range tests {
+		if got := RatingMethod(vector); got != want {
+			t.Errorf("unexpected method for %q: have: %s - want: %s", vector, got, want)
+		}
+	}
+}
+
+func TestComponent(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     Component
+	}{
+		{"pkg:npm/lodash@4.17.15", Component{Type: "library", BOMRef: "pkg:npm/lodash@4.17.15", Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15"}},
+		{"pkg:maven/org.apache/log4j@2.14.0?type=jar", Component{Type: "library", BOMRef: "pkg:maven/org.apache/log4j@2.14.0?type=jar", Name: "org.apache/log4j", Version: "2.14.0", PURL: "pkg:maven/org.apache/log4j@2.14.0?type=jar"}},
+		{"lodash@4.17.15", Component{Type: "library", BOMRef: "lodash@4.17.15", Name: "lodash", Version: "4.17.15"}},
+		{"@babel/core", Component{Type: "library", BOMRef: "@babel/core", Name: "@babel/core"}},
+	}
+	for _, tt := range tests {
+		if got := component(tt.resource); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unexpected component for %q: have: %+v - want: %+v", tt.resource, got, tt.want)
+		}
+	}
+}
+
+func TestPackageComponent(t *testing.T) {
+	tests := []struct {
+		pkg  report.AffectedPackage
+		want Component
+	}{
+		{report.AffectedPackage{PURL: "pkg:npm/lodash@4.17.15"}, Component{Type: "library", BOMRef: "pkg:npm/lodash@4.17.15", Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15"}},
+		{report.AffectedPackage{PURL: "pkg:maven/org.apache/log4j", InstalledVersion: "2.14.0"}, Component{Type: "library", BOMRef: "pkg:maven/org.apache/log4j@2.14.0", Name: "org.apache/log4j", Version: "2.14.0", PURL: "pkg:maven/org.apache/log4j@2.14.0"}},
+		{report.AffectedPackage{PURL: "lodash"}, Component{Type: "library", BOMRef: "lodash", Name: "lodash"}},
+	}
+	for _, tt := range tests {
+		if got := packageComponent(tt.pkg); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unexpected component for %+v: have: %+v - want: %+v", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	bom := Convert(testReport(
+		report.Vulnerability{
+			Summary:          "CVE-2021-23337 in lodash",
+			Score:            7.2,
+			CVSS:             "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H",
+			AffectedResource: "lodash@4.17.15",
+			CWEID:            94,
+			Recommendations:  []string{"Upgrade to 4.17.21"},
+			References:       []string{"https://github.com/advisories/GHSA-35jh-r3h4-6jhm"},
+		},
+		report.Vulnerability{
+			Summary:          "Outdated package",
+			Score:            3.9,
+			AffectedResource: "lodash@4.17.15",
+			Labels:           []string{report.LabelSuppressed},
+		},
+	))
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || bom.Version != 1 {
+		t.Errorf("unexpected BOM header: %+v", bom)
+	}
+	if bom.SerialNumber != Convert(testReport()).SerialNumber {
+		t.Errorf("serial number is not stable")
+	}
+	if bom.Metadata.Component == nil || bom.Metadata.Component.Type != "container" || bom.Metadata.Component.Name != "registry.example.com/team/app" {
+		t.Errorf("unexpected metadata component: %+v", bom.Metadata.Component)
+	}
+	if len(bom.Components) != 1 || bom.Components[0].Name != "lodash" {
+		t.Errorf("unexpected components: %+v", bom.Components)
+	}
+	if len(bom.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(bom.Vulnerabilities))
+	}
+
+	v := bom.Vulnerabilities[0]
+	if v.ID != "CVE-2021-23337" || v.Source == nil || v.Source.Name != "NVD" {
+		t.Errorf("unexpected vulnerability id and source: %s %+v", v.ID, v.Source)
+	}
+	wantRating := Rating{Source: &Source{Name: "Vulcan"}, Score: 7.2, Severity: "high", Method: MethodCVSSv31, Vector: "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H"}
+	if !reflect.DeepEqual(v.Ratings, []Rating{wantRating}) {
+		t.Errorf("unexpected ratings: %+v", v.Ratings)
+	}
+	if !reflect.DeepEqual(v.CWEs, []uint32{94}) || v.Recommendation != "Upgrade to 4.17.21" {
+		t.Errorf("unexpected cwes or recommendation: %v %q", v.CWEs, v.Recommendation)
+	}
+	if len(v.Advisories) != 1 || v.Advisories[0].URL != "https://github.com/advisories/GHSA-35jh-r3h4-6jhm" {
+		t.Errorf("unexpected advisories: %+v", v.Advisories)
+	}
+	if !reflect.DeepEqual(v.Affects, []Affect{{Ref: "lodash@4.17.15"}}) {
+		t.Errorf("unexpected affects: %+v", v.Affects)
+	}
+	if v.Analysis != nil {
+		t.Errorf("unexpected analysis for non suppressed vulnerability: %+v", v.Analysis)
+	}
+
+	suppressed := bom.Vulnerabilities[1]
+	if suppressed.Analysis == nil || suppressed.Analysis.State != AnalysisStateNotAffected {
+		t.Errorf("unexpected analysis for suppressed vulnerability: %+v", suppressed.Analysis)
+	}
+	if suppressed.Ratings[0].Method != MethodOther {
+		t.Errorf("unexpected rating method without CVSS: %s", suppressed.Ratings[0].Method)
+	}
+
+	if _, err := json.Marshal(bom); err != nil {
+		t.Errorf("unexpected error marshaling BOM: %s", err)
+	}
+}
+
+func TestConvertChildrenSharingFingerprint(t *testing.T) {
+	child := report.Vulnerability{Summary: "Outdated package", Score: 3.9, AffectedResource: "lodash@4.17.15", Fingerprint: "fp1"}
+	bom := Convert(testReport(report.Vulnerability{
+		Summary:         "Vulnerable packages",
+		Vulnerabilities: []report.Vulnerability{child, child},
+	}))
+	if len(bom.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(bom.Vulnerabilities))
+	}
+	a, b := bom.Vulnerabilities[0], bom.Vulnerabilities[1]
+	if a.ID == b.ID || a.BOMRef == b.BOMRef {
+		t.Errorf("children sharing a fingerprint must have different IDs and BOM references: %s %s", a.BOMRef, b.BOMRef)
+	}
+}
+
+func TestConvertDuplicatedIDs(t *testing.T) {
+	bom := Convert(testReport(
+		report.Vulnerability{ID: "ID1", Summary: "Outdated package", AffectedResource: "lodash@4.17.15"},
+		report.Vulnerability{ID: "ID1", Summary: "Outdated package", AffectedResource: "express@4.17.1"},
+	))
+	if len(bom.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(bom.Vulnerabilities))
+	}
+	a, b := bom.Vulnerabilities[0], bom.Vulnerabilities[1]
+	if a.ID != "ID1" || b.ID != "ID1" {
+		t.Errorf("unexpected IDs: %s %s", a.ID, b.ID)
+	}
+	if a.BOMRef == b.BOMRef {
+		t.Errorf("vulnerabilities with the same ID must have different BOM references: %s", a.BOMRef)
+	}
+}
+
+func TestConvertPackage(t *testing.T) {
+	bom := Convert(testReport(report.Vulnerability{
+		Summary:          "CVE-2021-23337 in lodash",
+		AffectedResource: "package.json",
+		Package:          &report.AffectedPackage{PURL: "pkg:npm/lodash", InstalledVersion: "4.17.15"},
+	}))
+	want := Component{Type: "library", BOMRef: "pkg:npm/lodash@4.17.15", Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15"}
+	if !reflect.DeepEqual(bom.Components, []Component{want}) {
+		t.Errorf("unexpected components: %+v", bom.Components)
+	}
+	if len(bom.Vulnerabilities) != 1 || !reflect.DeepEqual(bom.Vulnerabilities[0].Affects, []Affect{{Ref: want.BOMRef}}) {
+		t.Errorf("unexpected affects: %+v", bom.Vulnerabilities)
+	}
+}
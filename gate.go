@@ -0,0 +1,182 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import "fmt"
+
+// GatePolicy defines the vulnerabilities a set of reports may contain to pass
+// a gate, for instance, in a CI pipeline. Suppressed vulnerabilities are not
+// taken into account.
+type GatePolicy struct {
+	// MaxCount is the maximum number of vulnerabilities allowed per
+	// severity. Severities not present are not limited.
+	MaxCount map[SeverityRank]int `json:"max_count,omitempty"`
+	// ForbiddenCWEs are the CWE-IDs no vulnerability is allowed to have.
+	ForbiddenCWEs []uint32 `json:"forbidden_cwes,omitempty"`
+	// ForbiddenLabels are the labels no vulnerability is allowed to have.
+	ForbiddenLabels []string `json:"forbidden_labels,omitempty"`
+	// MaxSecurityStatus is the worst security status grade allowed, from
+	// "A" to "F". Empty means no limit.
+	MaxSecurityStatus string `json:"max_security_status,omitempty"`
+}
+
+// Gate rules reported in the violations.
+const (
+	GateRuleMaxCount          = "max_count"
+	GateRuleForbiddenCWE      = "forbidden_cwe"
+	GateRuleForbiddenLabel    = "forbidden_label"
+	GateRuleMaxSecurityStatus = "max_security_status"
+)
+
+// GateViolation is a rule of a policy the reports do not comply with.
+type GateViolation struct {
+	Rule            string          `json:"rule"`
+	Message         string          `json:"message"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// GateVerdict is the result of evaluating a gate policy.
+type GateVerdict struct {
+	Passed         bool                 `json:"passed"`
+	SecurityStatus string               `json:"security_status"`
+	Counts         map[SeverityRank]int `json:"counts"`
+	Violations     []GateViolation      `json:"violations"`
+}
+
+// Validate checks if a gate policy is valid.
+func (p GatePolicy) Validate() error {
+	for rank, n := range p.MaxCount {
+		if rank < SeverityNone || rank > SeverityCritical {
+			return fmt.Errorf("gate policy has an invalid severity %d", rank)
+		}
+		if n < 0 {
+			return fmt.Errorf("gate policy has a negative maximum count %d", n)
+		}
+	}
+	if p.MaxSecurityStatus != "" && !validSecurityStatus(p.MaxSecurityStatus) {
+		return fmt.Errorf("gate policy has an invalid security status %q", p.MaxSecurityStatus)
+	}
+	return nil
+}
+
+func validSecurityStatus(s string) bool {
+	return len(s) == 1 && s[0] >= 'A' && s[0] <= 'F'
+}
+
+// EvaluateGate evaluates a gate policy against the vulnerabilities of the
+// reports. The CWE-IDs and labels of the child vulnerabilities, completed with
+// the information of their parent, are also checked, but the violations list
+// the top level vulnerabilities.
+func EvaluateGate(p GatePolicy, reports ...Report) (GateVerdict, error) {
+	if err := p.Validate(); err != nil {
+		return GateVerdict{}, err
+	}
+	verdict := GateVerdict{
+		Counts:     map[SeverityRank]int{},
+		Violations: []GateViolation{},
+	}
+	bySeverity := map[SeverityRank][]Vulnerability{}
+	byCWE := map[uint32][]Vulnerability{}
+	byLabel := map[string][]Vulnerability{}
+	var maxScore float32
+	for _, r := range reports {
+		for _, v := range r.Vulnerabilities {
+			if v.Suppressed() {
+				continue
+			}
+			verdict.Counts[v.Severity()]++
+			bySeverity[v.Severity()] = append(bySeverity[v.Severity()], v)
+			if v.Score > maxScore {
+				maxScore = v.Score
+			}
+			for _, id := range p.ForbiddenCWEs {
+				if matchFlattened(v, func(f Vulnerability) bool { return f.CWEID == id }) {
+					byCWE[id] = append(byCWE[id], v)
+				}
+			}
+			for _, l := range p.ForbiddenLabels {
+				if matchFlattened(v, func(f Vulnerability) bool { return hasLabel(f, l) }) {
+					byLabel[l] = append(byLabel[l], v)
+				}
+			}
+		}
+	}
+	verdict.SecurityStatus = SecurityStatus(maxScore)
+
+	for rank := SeverityCritical; rank >= SeverityNone; rank-- {
+		max, ok := p.MaxCount[rank]
+		if !ok || verdict.Counts[rank] <= max {
+			continue
+		}
+		verdict.Violations = append(verdict.Violations, GateViolation{
+			Rule:            GateRuleMaxCount,
+			Message:         fmt.Sprintf("%d vulnerabilities of severity %v exceed the maximum of %d", verdict.Counts[rank], rank, max),
+			Vulnerabilities: bySeverity[rank],
+		})
+	}
+	for _, id := range p.ForbiddenCWEs {
+		if len(byCWE[id]) == 0 {
+			continue
+		}
+		verdict.Violations = append(verdict.Violations, GateViolation{
+			Rule:            GateRuleForbiddenCWE,
+			Message:         fmt.Sprintf("%d vulnerabilities have the forbidden CWE-%d", len(byCWE[id]), id),
+			Vulnerabilities: byCWE[id],
+		})
+	}
+	for _, l := range p.ForbiddenLabels {
+		if len(byLabel[l]) == 0 {
+			continue
+		}
+		verdict.Violations = append(verdict.Violations, GateViolation{
+			Rule:            GateRuleForbiddenLabel,
+			Message:         fmt.Sprintf("%d vulnerabilities have the forbidden label %q", len(byLabel[l]), l),
+			Vulnerabilities: byLabel[l],
+		})
+	}
+	if p.MaxSecurityStatus != "" && verdict.SecurityStatus > p.MaxSecurityStatus {
+		var worst []Vulnerability
+		for _, r := range reports {
+			for _, v := range r.Vulnerabilities {
+				if !v.Suppressed() && SecurityStatus(v.Score) > p.MaxSecurityStatus {
+					worst = append(worst, v)
+				}
+			}
+		}
+		verdict.Violations = append(verdict.Violations, GateViolation{
+			Rule:            GateRuleMaxSecurityStatus,
+			Message:         fmt.Sprintf("security status %s is worse than %s", verdict.SecurityStatus, p.MaxSecurityStatus),
+			Vulnerabilities: worst,
+		})
+	}
+	verdict.Passed = len(verdict.Violations) == 0
+	return verdict, nil
+}
+
+// matchFlattened returns true if the vulnerability or any of its children
+// that is not suppressed matches.
+func matchFlattened(v Vulnerability, match func(Vulnerability) bool) bool {
+	if match(v) {
+		return true
+	}
+	if len(v.Vulnerabilities) == 0 {
+		return false
+	}
+	for _, f := range v.Flatten() {
+		if !f.Suppressed() && match(f) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLabel(v Vulnerability, label string) bool {
+	for _, l := range v.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateGate(t *testing.T) {
+	critical := Vulnerability{Summary: "SQL injection", Score: 10, CWEID: 89, Labels: []string{"web"}}
+	high := Vulnerability{Summary: "Outdated TLS", Score: 8.9, Labels: []string{"tls"}}
+	medium := Vulnerability{Summary: "Weak cipher", Score: 6.9, Labels: []string{"tls"}}
+	suppressed := Vulnerability{Summary: "Accepted risk", Score: 10, CWEID: 89, Labels: []string{LabelSuppressed}}
+	parent := Vulnerability{
+		Summary: "Vulnerable package",
+		Score:   6.9,
+		Vulnerabilities: []Vulnerability{
+			{Summary: "CVE-1", Score: 6.9, CWEID: 79},
+			{Summary: "CVE-2", Score: 3.9, CWEID: 89, Labels: []string{LabelSuppressed}},
+		},
+	}
+	r := Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{critical, high, medium, suppressed, parent}}}
+
+	tests := []struct {
+		name    string
+		policy  GatePolicy
+		reports []Report
+		want    GateVerdict
+		wantErr bool
+	}{
+		{
+			name:    "empty policy",
+			policy:  GatePolicy{},
+			reports: []Report{r},
+			want: GateVerdict{
+				Passed:         true,
+				SecurityStatus: "F",
+				Counts:         map[SeverityRank]int{SeverityCritical: 1, SeverityHigh: 1, SeverityMedium: 2},
+				Violations:     []GateViolation{},
+			},
+		},
+		{
+			name:    "no reports",
+			policy:  GatePolicy{MaxCount: map[SeverityRank]int{SeverityNone: 0}, MaxSecurityStatus: "A"},
+			reports: nil,
+			want: GateVerdict{
+				Passed:         true,
+				SecurityStatus: "A",
+				Counts:         map[SeverityRank]int{},
+				Violations:     []GateViolation{},
+			},
+		},
+		{
+			name: "violations",
+			policy: GatePolicy{
+				MaxCount:          map[SeverityRank]int{SeverityCritical: 0, SeverityHigh: 1, SeverityMedium: 1},
+				ForbiddenCWEs:     []uint32{89, 79, 20},
+				ForbiddenLabels:   []string{"tls"},
+				MaxSecurityStatus: "E",
+			},
+			reports: []Report{r},
+			want: GateVerdict{
+				Passed:         false,
+				SecurityStatus: "F",
+				Counts:         map[SeverityRank]int{SeverityCritical: 1, SeverityHigh: 1, SeverityMedium: 2},
+				Violations: []GateViolation{
+					{
+						Rule:            GateRuleMaxCount,
+						Message:         "1 vulnerabilities of severity critical exceed the maximum of 0",
+						Vulnerabilities: []Vulnerability{critical},
+					},
+					{
+						Rule:            GateRuleMaxCount,
+						Message:         "2 vulnerabilities of severity medium exceed the maximum of 1",
+						Vulnerabilities: []Vulnerability{medium, parent},
+					},
+					{
+						Rule:            GateRuleForbiddenCWE,
+						Message:         "1 vulnerabilities have the forbidden CWE-89",
+						Vulnerabilities: []Vulnerability{critical},
+					},
+					{
+						Rule:            GateRuleForbiddenCWE,
+						Message:         "1 vulnerabilities have the forbidden CWE-79",
+						Vulnerabilities: []Vulnerability{parent},
+					},
+					{
+						Rule:            GateRuleForbiddenLabel,
+						Message:         `2 vulnerabilities have the forbidden label "tls"`,
+						Vulnerabilities: []Vulnerability{high, medium},
+					},
+					{
+						Rule:            GateRuleMaxSecurityStatus,
+						Message:         "security status F is worse than E",
+						Vulnerabilities: []Vulnerability{critical, high},
+					},
+				},
+			},
+		},
+		{
+			name:    "several reports",
+			policy:  GatePolicy{MaxCount: map[SeverityRank]int{SeverityHigh: 1}},
+			reports: []Report{{ResultData: ResultData{Vulnerabilities: []Vulnerability{high}}}, {ResultData: ResultData{Vulnerabilities: []Vulnerability{high}}}},
+			want: GateVerdict{
+				Passed:         false,
+				SecurityStatus: "F",
+				Counts:         map[SeverityRank]int{SeverityHigh: 2},
+				Violations: []GateViolation{
+					{
+						Rule:            GateRuleMaxCount,
+						Message:         "2 vulnerabilities of severity high exceed the maximum of 1",
+						Vulnerabilities: []Vulnerability{high, high},
+					},
+				},
+			},
+		},
+		{
+			name:    "invalid severity",
+			policy:  GatePolicy{MaxCount: map[SeverityRank]int{5: 0}},
+			wantErr: true,
+		},
+		{
+			name:    "negative count",
+			policy:  GatePolicy{MaxCount: map[SeverityRank]int{SeverityLow: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid security status",
+			policy:  GatePolicy{MaxSecurityStatus: "G"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateGate(tt.policy, tt.reports...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got verdict %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package gitlab converts Vulcan reports to GitLab security reports, so they
+// can be shown in the security widgets of merge requests.
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// SchemaVersion is the version of the GitLab security report schemas the
+// reports are generated for.
+const SchemaVersion = "15.0.7"
+
+// timeLayout is the format of the scan times required by the schemas.
+const timeLayout = "2006-01-02T15:04:05"
+
+// ScanType is the kind of GitLab security report.
+type ScanType string
+
+const (
+	ScanTypeDAST               ScanType = "dast"
+	ScanTypeContainerScanning  ScanType = "container_scanning"
+	ScanTypeDependencyScanning ScanType = "dependency_scanning"
+)
+
+// Severity levels of a GitLab vulnerability.
+const (
+	SeverityInfo     = "Info"
+	SeverityUnknown  = "Unknown"
+	SeverityLow      = "Low"
+	SeverityMedium   = "Medium"
+	SeverityHigh     = "High"
+	SeverityCritical = "Critical"
+)
+
+// Report is a GitLab security report.
+type Report struct {
+	Version         string          `json:"version"`
+	Scan            Scan            `json:"scan"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	Remediations    []interface{}   `json:"remediations"`
+}
+
+// Scan describes the scan that generated the report.
+type Scan struct {
+	Analyzer         Tool              `json:"analyzer"`
+	Scanner          Tool              `json:"scanner"`
+	Type             ScanType          `json:"type"`
+	StartTime        string            `json:"start_time"`
+	EndTime          string            `json:"end_time"`
+	Status           string            `json:"status"`
+	ScannedResources []ScannedResource `json:"scanned_resources,omitempty"`
+}
+
+// Tool identifies an analyzer or a scanner.
+type Tool struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Vendor  Vendor `json:"vendor"`
+}
+
+// Vendor identifies the vendor of a tool.
+type Vendor struct {
+	Name string `json:"name"`
+}
+
+// ScannedResource is a resource scanned by a DAST scan.
+type ScannedResource struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+}
+
+// Vulnerability is a finding of a GitLab security report.
+type Vulnerability struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Severity    string       `json:"severity,omitempty"`
+	Solution    string       `json:"solution,omitempty"`
+	Identifiers []Identifier `json:"identifiers"`
+	Links       []Link       `json:"links,omitempty"`
+	Location    Location     `json:"location"`
+}
+
+// Identifier identifies a vulnerability in an external or internal scheme.
+type Identifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Link is a reference to additional information about a vulnerability.
+type Link struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+// Location identifies where a vulnerability was found. The fields that
+// apply depend on the scan type.
+type Location struct {
+	// DAST.
+	Hostname string `json:"hostname,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Param    string `json:"param,omitempty"`
+	Path     string `json:"path,omitempty"`
+
+	// Container scanning.
+	Image           string `json:"image,omitempty"`
+	OperatingSystem string `json:"operating_system,omitempty"`
+
+	// Dependency scanning.
+	File string `json:"file,omitempty"`
+
+	// Container and dependency scanning.
+	Dependency *Dependency `json:"dependency,omitempty"`
+}
+
+// Dependency is a software package affected by a vulnerability.
+type Dependency struct {
+	Package Package `json:"package"`
+	Version string  `json:"version"`
+}
+
+// Package identifies a software package.
+type Package struct {
+	Name string `json:"name"`
+}
+
+// ScanTypeFor returns the kind of GitLab security report that suits the
+// report, based on its checktype name and target.
+func ScanTypeFor(r report.Report) ScanType {
+	name := strings.ToLower(r.ChecktypeName)
+	switch {
+	case strings.Contains(name, "dependency") || strings.Contains(name, "dependencies"):
+		return ScanTypeDependencyScanning
+	case strings.Contains(name, "container") || strings.Contains(name, "trivy") || strings.Contains(name, "image"):
+		return ScanTypeContainerScanning
+	}
+	t, err := report.ParseTarget(r.Target)
+	if err != nil {
+		return ScanTypeDAST
+	}
+	switch t.Type {
+	case report.AssetTypeDockerImage:
+		return ScanTypeContainerScanning
+	case report.AssetTypeGitRepository:
+		return ScanTypeDependencyScanning
+	}
+	return ScanTypeDAST
+}
+
+// Severity maps a Vulcan score to a GitLab severity.
+func Severity(score float32) string {
+	switch report.RankSeverity(score) {
+	case report.SeverityNone:
+		return SeverityInfo
+	case report.SeverityLow:
+		return SeverityLow
+	case report.SeverityMedium:
+		return SeverityMedium
+	case report.SeverityHigh:
+		return SeverityHigh
+	case report.SeverityCritical:
+		return SeverityCritical
+	}
+	return SeverityUnknown
+}
+
+// Convert returns the GitLab security report equivalent to a Vulcan report.
+// Vulnerabilities with children are flattened into one GitLab vulnerability
+// per child, see report.Vulnerability.Flatten. The generated report is
+// validated before being returned.
+func Convert(r report.Report) (Report, error) {
+	scanType := ScanTypeFor(r)
+	target, _ := report.ParseTarget(r.Target)
+
+	tool := Tool{
+		ID:      toolID(r.ChecktypeName),
+		Name:    r.ChecktypeName,
+		Version: r.ChecktypeVersion,
+		Vendor:  Vendor{Name: "Vulcan"},
+	}
+	status := "success"
+	if r.Status != "FINISHED" {
+		status = "failure"
+	}
+	gr := Report{
+		Version: SchemaVersion,
+		Scan: Scan{
+			Analyzer:  tool,
+			Scanner:   tool,
+			Type:      scanType,
+			StartTime: r.StartTime.UTC().Format(timeLayout),
+			EndTime:   r.EndTime.UTC().Format(timeLayout),
+			Status:    status,
+		},
+		Vulnerabilities: []Vulnerability{},
+		Remediations:    []interface{}{},
+	}
+	if scanType == ScanTypeDAST {
+		gr.Scan.ScannedResources = []ScannedResource{{Method: "GET", URL: webAddress(target, r.Target), Type: "url"}}
+	}
+
+	for _, v := range r.FlattenVulnerabilities() {
+		gr.Vulnerabilities = append(gr.Vulnerabilities, convertVulnerability(r, target, scanType, v))
+	}
+
+	if err := gr.Validate(); err != nil {
+		return Report{}, err
+	}
+	return gr, nil
+}
+
+func convertVulnerability(r report.Report, target report.Target, scanType ScanType, fv report.FlatVulnerability) Vulnerability {
+	v := fv.Vulnerability
+	id := v.ID
+	if id == "" {
+		id = report.VulnerabilityIdentity(r, fv)[:32]
+	}
+	gv := Vulnerability{
+		ID:          id,
+		Name:        truncate(v.Summary, maxNameLength),
+		Description: truncate(v.FullDescription(), maxDescriptionLength),
+		Severity:    Severity(v.Score),
+		Solution:    truncate(strings.Join(v.Recommendations, "\n"), maxSolutionLength),
+		Location:    location(r, target, scanType, v),
+	}
+	if v.CWEID != 0 {
+		cwe := strconv.FormatUint(uint64(v.CWEID), 10)
+		gv.Identifiers = append(gv.Identifiers, Identifier{
+			Type:  "cwe",
+			Name:  "CWE-" + cwe,
+			Value: cwe,
+			URL:   report.CWEURL(v.CWEID),
+		})
+	}
+	if v.Fingerprint != "" {
+		gv.Identifiers = append(gv.Identifiers, Identifier{
+			Type:  "vulcan_fingerprint",
+			Name:  "Vulcan fingerprint " + v.Fingerprint,
+			Value: v.Fingerprint,
+		})
+	}
+	gv.Identifiers = append(gv.Identifiers, Identifier{
+		Type:  "vulcan_id",
+		Name:  "Vulcan ID " + id,
+		Value: id,
+	})
+	for _, ref := range v.References {
+		gv.Links = append(gv.Links, Link{URL: ref})
+	}
+	return gv
+}
+
+func location(r report.Report, target report.Target, scanType ScanType, v report.Vulnerability) Location {
+	switch scanType {
+	case ScanTypeContainerScanning:
+		image := r.Target
+		if target.Type == report.AssetTypeDockerImage {
+			image = target.Normalized()
+		}
+		return Location{
+			Image:           image,
+			OperatingSystem: "unknown",
+			Dependency:      &Dependency{Package: Package{Name: v.AffectedResource}},
+		}
+	case ScanTypeDependencyScanning:
+		file := v.AffectedResourceString
+		if file == "" {
+			file = r.Target
+		}
+		return Location{
+			File:       file,
+			Dependency: &Dependency{Package: Package{Name: v.AffectedResource}},
+		}
+	}
+	l := Location{Hostname: webAddress(target, r.Target), Path: "/"}
+	if target.Type == report.AssetTypeWebAddress && target.Path != "" {
+		l.Path = target.Path
+	}
+	if strings.HasPrefix(v.AffectedResource, "/") {
+		l.Path = v.AffectedResource
+	}
+	return l
+}
+
+// webAddress returns the scheme and host of the target.
+func webAddress(t report.Target, raw string) string {
+	switch t.Type {
+	case report.AssetTypeWebAddress:
+		u := t.Scheme + "://" + t.Host
+		if t.Port != 0 {
+			u += ":" + strconv.Itoa(t.Port)
+		}
+		return u
+	case report.AssetTypeHostname, report.AssetTypeIP:
+		return "http://" + t.Normalized()
+	}
+	return raw
+}
+
+// toolIDReplacer matches the characters not allowed in the IDs of the tools.
+var toolIDReplacer = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// toolID returns the ID of the tool with the given name.
+func toolID(name string) string {
+	id := toolIDReplacer.ReplaceAllString(strings.ToLower(name), "-")
+	if id == "" {
+		return "vulcan"
+	}
+	return id
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// Do not cut a multi-byte character in half.
+	for n > 0 && !isRuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+const (
+	maxNameLength        = 255
+	maxDescriptionLength = 1048576
+	maxSolutionLength    = 7000
+)
+
+var (
+	severities = map[string]bool{
+		SeverityInfo: true, SeverityUnknown: true, SeverityLow: true,
+		SeverityMedium: true, SeverityHigh: true, SeverityCritical: true,
+	}
+	timeRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`)
+)
+
+// Validate checks the subset of the constraints of the GitLab security report
+// schemas, version SchemaVersion, that the reports generated by Convert may
+// break. It is not a full validation against the schemas. It checks that:
+//
+//   - The report has a version and a supported scan type.
+//   - The analyzer and the scanner have ID, name, version and vendor.
+//   - The scan times have the format of the schemas and the status is
+//     "success" or "failure".
+//   - The DAST scans have scanned resources.
+//   - The vulnerabilities have an ID, at least one identifier with type, name
+//     and value, a valid severity, if any, and links with URL.
+//   - The name, description and solution of the vulnerabilities do not exceed
+//     the maximum lengths of the schemas.
+//   - The location of the vulnerabilities has the fields required by the
+//     scan type: image, operating system and dependency for container
+//     scanning, and file and dependency for dependency scanning.
+func (r Report) Validate() error {
+	if r.Version == "" {
+		return errors.New("report is missing version")
+	}
+	switch r.Scan.Type {
+	case ScanTypeDAST, ScanTypeContainerScanning, ScanTypeDependencyScanning:
+	default:
+		return fmt.Errorf("unsupported scan type %q", r.Scan.Type)
+	}
+	for _, tool := range []Tool{r.Scan.Analyzer, r.Scan.Scanner} {
+		if tool.ID == "" || tool.Name == "" || tool.Version == "" || tool.Vendor.Name == "" {
+			return errors.New("scan analyzer and scanner must have id, name, version and vendor")
+		}
+	}
+	if !timeRegexp.MatchString(r.Scan.StartTime) || !timeRegexp.MatchString(r.Scan.EndTime) {
+		return fmt.Errorf("scan times must have the format %s", timeLayout)
+	}
+	if r.Scan.Status != "success" && r.Scan.Status != "failure" {
+		return fmt.Errorf("invalid scan status %q", r.Scan.Status)
+	}
+	if r.Scan.Type == ScanTypeDAST && r.Scan.ScannedResources == nil {
+		return errors.New("dast scan is missing scanned resources")
+	}
+	for i, v := range r.Vulnerabilities {
+		if err := v.validate(r.Scan.Type); err != nil {
+			return fmt.Errorf("vulnerability %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (v Vulnerability) validate(scanType ScanType) error {
+	if v.ID == "" {
+		return errors.New("missing id")
+	}
+	if len(v.Name) > maxNameLength {
+		return fmt.Errorf("name is longer than %d", maxNameLength)
+	}
+	if len(v.Description) > maxDescriptionLength {
+		return fmt.Errorf("description is longer than %d", maxDescriptionLength)
+	}
+	if len(v.Solution) > maxSolutionLength {
+		return fmt.Errorf("solution is longer than %d", maxSolutionLength)
+	}
+	if v.Severity != "" && !severities[v.Severity] {
+		return fmt.Errorf("invalid severity %q", v.Severity)
+	}
+	if len(v.Identifiers) == 0 {
+		return errors.New("missing identifiers")
+	}
+	for _, id := range v.Identifiers {
+		if id.Type == "" || id.Name == "" || id.Value == "" {
+			return errors.New("identifiers must have type, name and value")
+		}
+	}
+	for _, l := range v.Links {
+		if l.URL == "" {
+			return errors.New("links must have url")
+		}
+	}
+	l := v.Location
+	switch scanType {
+	case ScanTypeContainerScanning:
+		if l.Image == "" || l.OperatingSystem == "" || l.Dependency == nil {
+			return errors.New("container scanning location must have image, operating system and dependency")
+		}
+	case ScanTypeDependencyScanning:
+		if l.File == "" || l.Dependency == nil {
+			return errors.New("dependency scanning location must have file and dependency")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func testReport(checktype, target string, vulns ...report.Vulnerability) report.Report {
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          "ID0",
+			ChecktypeName:    checktype,
+			ChecktypeVersion: "1",
+			Target:           target,
+			Status:           "FINISHED",
+			StartTime:        time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+			EndTime:          time.Date(2021, 5, 18, 14, 0, 50, 0, time.UTC),
+		},
+		ResultData: report.ResultData{Vulnerabilities: vulns},
+	}
+}
+
+func TestScanTypeFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		checktype string
+		target    string
+		want      ScanType
+	}{
+		{"WebAddress", "vulcan-zap", "https://example.com", ScanTypeDAST},
+		{"Hostname", "vulcan-nessus", "example.com", ScanTypeDAST},
+		{"DockerImageTarget", "vulcan-custom", "registry.example.com/app:1.0", ScanTypeContainerScanning},
+		{"TrivyChecktype", "vulcan-trivy", "example.com", ScanTypeContainerScanning},
+		{"GitRepositoryTarget", "vulcan-custom", "git@github.com:org/repo.git", ScanTypeDependencyScanning},
+		{"DependencyChecktype", "vulcan-dependency-check", "https://github.com/org/repo", ScanTypeDependencyScanning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScanTypeFor(testReport(tt.checktype, tt.target))
+			if got != tt.want {
+				t.Errorf("unexpected scan type: have: %s - want: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	scores := []float32{0, 3.9, 6.9, 8.9, 10}
+	want := []string{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+	for i, s := range scores {
+		if got := Severity(s); got != want[i] {
+			t.Errorf("unexpected severity for score %.1f: have: %s - want: %s", s, got, want[i])
+		}
+	}
+}
+
+func TestConvertDAST(t *testing.T) {
+	v := report.Vulnerability{
+		Summary:          "Cross-site Scripting",
+		Score:            6.9,
+		AffectedResource: "/search",
+		Fingerprint:      "fp1",
+		CWEID:            79,
+		Description:      "XSS description",
+		Details:          "payload reflected",
+		Recommendations:  []string{"Encode output", "Use CSP"},
+		References:       []string{"https://owasp.org/www-community/attacks/xss/"},
+	}
+	gr, err := Convert(testReport("vulcan-zap", "https://Example.com:8443/app", v))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if gr.Scan.Type != ScanTypeDAST || gr.Scan.StartTime != "2021-05-18T13:30:15" || gr.Scan.Status != "success" {
+		t.Errorf("unexpected scan: %+v", gr.Scan)
+	}
+	if len(gr.Vulnerabilities) != 1 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(gr.Vulnerabilities))
+	}
+	gv := gr.Vulnerabilities[0]
+	wantLocation := Location{Hostname: "https://example.com:8443", Path: "/search"}
+	if !reflect.DeepEqual(gv.Location, wantLocation) {
+		t.Errorf("unexpected location: have: %+v - want: %+v", gv.Location, wantLocation)
+	}
+	if gv.Severity != SeverityMedium || gv.Solution != "Encode output\nUse CSP" ||
+		gv.Description != "XSS description\n\npayload reflected" {
+		t.Errorf("unexpected vulnerability: %+v", gv)
+	}
+	var types []string
+	for _, id := range gv.Identifiers {
+		types = append(types, id.Type)
+	}
+	if !reflect.DeepEqual(types, []string{"cwe", "vulcan_fingerprint", "vulcan_id"}) {
+		t.Errorf("unexpected identifiers: %+v", gv.Identifiers)
+	}
+	if len(gv.Links) != 1 || gv.Links[0].URL != v.References[0] {
+		t.Errorf("unexpected links: %+v", gv.Links)
+	}
+
+	// The generated ID must be stable.
+	gr2, _ := Convert(testReport("vulcan-zap", "https://Example.com:8443/app", v))
+	if gr2.Vulnerabilities[0].ID != gv.ID {
+		t.Errorf("vulnerability ID is not stable: %s - %s", gr2.Vulnerabilities[0].ID, gv.ID)
+	}
+
+	b, err := json.Marshal(gr)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling: %s", err)
+	}
+	if !strings.Contains(string(b), `"scanned_resources":[{"method":"GET","url":"https://example.com:8443","type":"url"}]`) {
+		t.Errorf("unexpected JSON: %s", b)
+	}
+}
+
+func TestConvertContainerScanningFlattensChildren(t *testing.T) {
+	parent := report.Vulnerability{
+		Summary:          "Vulnerable packages",
+		Score:            8.9,
+		AffectedResource: "openssl",
+		Recommendations:  []string{"Upgrade"},
+		Vulnerabilities: []report.Vulnerability{
+			{ID: "child-1", Summary: "CVE-2022-0001", Score: 8.9, AffectedResource: "openssl"},
+			{ID: "child-2", Summary: "CVE-2022-0002", Score: 3.9, AffectedResource: "openssl"},
+		},
+	}
+	gr, err := Convert(testReport("vulcan-trivy", "alpine:3.14", parent))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(gr.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(gr.Vulnerabilities))
+	}
+	for _, gv := range gr.Vulnerabilities {
+		if gv.Location.Image != "docker.io/library/alpine:3.14" || gv.Location.Dependency.Package.Name != "openssl" {
+			t.Errorf("unexpected location: %+v", gv.Location)
+		}
+		if gv.Solution != "Upgrade" {
+			t.Errorf("solution not inherited from parent: %q", gv.Solution)
+		}
+	}
+	if gr.Vulnerabilities[1].ID != "child-2" || gr.Vulnerabilities[1].Severity != SeverityLow {
+		t.Errorf("unexpected vulnerability: %+v", gr.Vulnerabilities[1])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	gr, err := Convert(testReport("vulcan-zap", "example.com", report.Vulnerability{Summary: "s", AffectedResource: "r"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gr.Vulnerabilities[0].Severity = "Severe"
+	if err := gr.Validate(); err == nil || err.Error() != `vulnerability 0: invalid severity "Severe"` {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	gr.Vulnerabilities[0].Severity = SeverityLow
+	gr.Scan.StartTime = "2021-05-18 13:30:15"
+	if err := gr.Validate(); err == nil {
+		t.Errorf("expected validation error for invalid start time")
+	}
+}
+
+func TestConvertChildrenSharingFingerprint(t *testing.T) {
+	child := report.Vulnerability{Summary: "Outdated package", Score: 3.9, AffectedResource: "openssl", Fingerprint: "fp1"}
+	parent := report.Vulnerability{Summary: "Vulnerable packages", Vulnerabilities: []report.Vulnerability{child, child}}
+	gr, err := Convert(testReport("vulcan-trivy", "alpine:3.14", parent))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(gr.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(gr.Vulnerabilities))
+	}
+	if gr.Vulnerabilities[0].ID == gr.Vulnerabilities[1].ID {
+		t.Errorf("children sharing a fingerprint must have different IDs")
+	}
+}
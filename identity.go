@@ -0,0 +1,66 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// FlatVulnerability is a vulnerability of a report after flattening it, see
+// Vulnerability.Flatten, with its position in the report.
+type FlatVulnerability struct {
+	Vulnerability
+	// Parent is the vulnerability the flattened one is a child of, nil if
+	// it has no parent.
+	Parent *Vulnerability
+	// Index is the position of the vulnerability among the children of its
+	// parent.
+	Index int
+}
+
+// FlattenVulnerabilities returns the vulnerabilities of the result
+// flattened, see Vulnerability.Flatten, in order.
+func (r ResultData) FlattenVulnerabilities() []FlatVulnerability {
+	var flat []FlatVulnerability
+	for i := range r.Vulnerabilities {
+		v := r.Vulnerabilities[i]
+		if len(v.Vulnerabilities) == 0 {
+			flat = append(flat, FlatVulnerability{Vulnerability: v})
+			continue
+		}
+		for j, child := range v.Flatten() {
+			flat = append(flat, FlatVulnerability{Vulnerability: child, Parent: &r.Vulnerabilities[i], Index: j})
+		}
+	}
+	return flat
+}
+
+// Fingerprint returns the hex encoded SHA-256 of the parts joined by NUL
+// characters. The importers use it to fingerprint the vulnerabilities.
+func Fingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// VulnerabilityIdentity returns the hex encoded SHA-256 that identifies a
+// flattened vulnerability of a report across scans. It is derived from the
+// checktype and the normalized target of the report, see Target.Normalized,
+// and the summary, affected resource and fingerprint of the vulnerability
+// and, for the children, of their parent and their position, so the
+// children sharing a fingerprint have different identities.
+func VulnerabilityIdentity(r Report, v FlatVulnerability) string {
+	target := r.Target
+	if t, err := ParseTarget(r.Target); err == nil {
+		target = t.Normalized()
+	}
+	parts := []string{r.ChecktypeName, target, v.Summary, v.AffectedResource, v.Fingerprint}
+	if v.Parent != nil {
+		parts = append(parts, v.Parent.Summary, v.Parent.AffectedResource, v.Parent.Fingerprint, strconv.Itoa(v.Index))
+	}
+	return Fingerprint(parts...)
+}
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"testing"
+)
+
+func TestVulnerabilityIdentity(t *testing.T) {
+	child := Vulnerability{Summary: "Outdated package", AffectedResource: "openssl", Fingerprint: "fp1"}
+	r := Report{
+		CheckData: CheckData{ChecktypeName: "vulcan-trivy", Target: "alpine:3.14"},
+		ResultData: ResultData{Vulnerabilities: []Vulnerability{
+			{Summary: "Vulnerable packages", Fingerprint: "fp1", Vulnerabilities: []Vulnerability{child, child}},
+			child,
+		}},
+	}
+	flat := r.FlattenVulnerabilities()
+	if len(flat) != 3 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(flat))
+	}
+	if flat[0].Parent == nil || flat[1].Index != 1 || flat[2].Parent != nil {
+		t.Errorf("unexpected positions: %+v", flat)
+	}
+	ids := map[string]bool{}
+	for _, v := range flat {
+		ids[VulnerabilityIdentity(r, v)] = true
+	}
+	if len(ids) != len(flat) {
+		t.Errorf("vulnerabilities sharing a fingerprint must have different identities")
+	}
+
+	// The identity does not depend on how the target is written.
+	same := r
+	same.Target = "docker.io/library/alpine:3.14"
+	if VulnerabilityIdentity(r, flat[2]) != VulnerabilityIdentity(same, same.FlattenVulnerabilities()[2]) {
+		t.Errorf("identity depends on the form of the target")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("a", "bc") == Fingerprint("ab", "c") {
+		t.Errorf("parts are not separated")
+	}
+	if got, want := Fingerprint(), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Command cwegen generates the CWE catalog embedded in the report package
+// from the XML catalog published by MITRE.
+//
+// Usage:
+//
+//	cwegen [-in catalog.xml|catalog.xml.zip] [-o cwe.json]
+//
+// When no input is given, the latest catalog is downloaded from MITRE.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// catalogURL is the address of the latest XML catalog published by MITRE.
+const catalogURL = "https://cwe.mitre.org/data/xml/cwec_latest.xml.zip"
+
+// researchView is the ID of the Research Concepts view, whose ChildOf
+// relations define the parents of the weaknesses.
+const researchView = 1000
+
+// catalog is the generated catalog, see report.CWECatalogVersion.
+type catalog struct {
+	Version    string       `json:"version"`
+	Weaknesses []report.CWE `json:"weaknesses"`
+}
+
+// xmlCatalog contains the parts of the MITRE XML catalog used to generate
+// the catalog.
+type xmlCatalog struct {
+	Version    string `xml:"Version,attr"`
+	Weaknesses []struct {
+		ID          uint32 `xml:"ID,attr"`
+		Name        string `xml:"Name,attr"`
+		Description string `xml:"Description"`
+		Related     []struct {
+			Nature string `xml:"Nature,attr"`
+			CWEID  uint32 `xml:"CWE_ID,attr"`
+			ViewID uint32 `xml:"View_ID,attr"`
+		} `xml:"Related_Weaknesses>Related_Weakness"`
+	} `xml:"Weaknesses>Weakness"`
+	Categories []struct {
+		ID      uint32 `xml:"ID,attr"`
+		Name    string `xml:"Name,attr"`
+		Summary string `xml:"Summary"`
+	} `xml:"Categories>Category"`
+}
+
+func main() {
+	in := flag.String("in", "", "XML catalog, optionally zipped, defaults to downloading "+catalogURL)
+	out := flag.String("o", "cwe.json", "output file")
+	flag.Parse()
+
+	data, err := readInput(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := parseCatalog(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	output, err := json.MarshalIndent(c, "", " ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(output, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readInput reads the XML catalog from the given file or, if it is empty,
+// downloads it from MITRE. Zipped catalogs are unzipped.
+func readInput(name string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if name == "" {
+		data, err = download(catalogURL)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return data, nil
+	}
+	return unzipCatalog(data)
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// unzipCatalog returns the content of the XML file of a zipped catalog.
+func unzipCatalog(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if path.Ext(f.Name) != ".xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, errors.New("no XML catalog in the zip file")
+}
+
+// parseCatalog builds the catalog from the weaknesses and the categories of
+// the XML catalog, sorted by ID. The parents of the weaknesses are the ones
+// they are a child of in the Research Concepts view.
+func parseCatalog(data []byte) (catalog, error) {
+	var x xmlCatalog
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return catalog{}, fmt.Errorf("invalid XML catalog: %w", err)
+	}
+	if x.Version == "" || len(x.Weaknesses) == 0 {
+		return catalog{}, errors.New("invalid XML catalog: missing version or weaknesses")
+	}
+	c := catalog{Version: x.Version}
+	for _, w := range x.Weaknesses {
+		cwe := report.CWE{ID: w.ID, Name: w.Name, Description: normalizeSpace(w.Description)}
+		for _, r := range w.Related {
+			if r.Nature == "ChildOf" && r.ViewID == researchView && !containsID(cwe.Parents, r.CWEID) {
+				cwe.Parents = append(cwe.Parents, r.CWEID)
+			}
+		}
+		sort.Slice(cwe.Parents, func(i, j int) bool { return cwe.Parents[i] < cwe.Parents[j] })
+		c.Weaknesses = append(c.Weaknesses, cwe)
+	}
+	for _, cat := range x.Categories {
+		c.Weaknesses = append(c.Weaknesses, report.CWE{ID: cat.ID, Name: cat.Name, Description: normalizeSpace(cat.Summary)})
+	}
+	sort.Slice(c.Weaknesses, func(i, j int) bool { return c.Weaknesses[i].ID < c.Weaknesses[j].ID })
+	return c, nil
+}
+
+// normalizeSpace replaces the sequences of white space of a text, used to
+// wrap the lines of the XML catalog, with a single space.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func containsID(ids []uint32, id uint32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
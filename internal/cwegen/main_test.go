@@ -0,0 +1,80 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func TestParseCatalog(t *testing.T) {
+	data, err := os.ReadFile("testdata/cwec.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := parseCatalog(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Version != "4.13" {
+		t.Errorf("unexpected version: %s", c.Version)
+	}
+	want := []report.CWE{
+		{ID: 16, Name: "Configuration", Description: "Weaknesses in this category are typically introduced during the configuration of the software."},
+		{ID: 74, Name: "Improper Neutralization of Special Elements in Output Used by a Downstream Component ('Injection')", Description: "The product constructs all or part of a command, data structure, or record using externally-influenced input from an upstream component, but it does not neutralize or incorrectly neutralizes special elements."},
+		{ID: 89, Name: "Improper Neutralization of Special Elements used in an SQL Command ('SQL Injection')", Description: "The product constructs all or part of an SQL command using externally-influenced input from an upstream component, but it does not neutralize or incorrectly neutralizes special elements that could modify the intended SQL command when it is sent to a downstream component.", Parents: []uint32{943}},
+		{ID: 943, Name: "Improper Neutralization of Special Elements in Data Query Logic", Description: "The product generates a query intended to access or manipulate data in a data store such as a database, but it does not neutralize or incorrectly neutralizes special elements that can modify the intended logic of the query.", Parents: []uint32{74}},
+	}
+	if !reflect.DeepEqual(c.Weaknesses, want) {
+		t.Errorf("unexpected weaknesses:\nhave: %+v\nwant: %+v", c.Weaknesses, want)
+	}
+}
+
+func TestParseCatalogInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not xml":       "{}",
+		"no weaknesses": `<Weakness_Catalog Version="4.13"></Weakness_Catalog>`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseCatalog([]byte(data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestReadInputZip(t *testing.T) {
+	data, err := os.ReadFile("testdata/cwec.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	w, err := zw.Create("cwec_v4.13.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "cwec_latest.xml.zip")
+	if err := os.WriteFile(name, b.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readInput(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("unexpected content of the zipped catalog")
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package junit converts Vulcan reports to JUnit XML test results, so CI
+// systems can show them and fail builds because of them.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// timestampLayout is the format of the timestamps of the test suites.
+const timestampLayout = "2006-01-02T15:04:05"
+
+// TestSuites is the root element of a JUnit XML document.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite contains the test cases of a report.
+type TestSuite struct {
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Errors     int        `xml:"errors,attr"`
+	Skipped    int        `xml:"skipped,attr"`
+	Time       string     `xml:"time,attr,omitempty"`
+	Timestamp  string     `xml:"timestamp,attr,omitempty"`
+	Properties []Property `xml:"properties>property,omitempty"`
+	TestCases  []TestCase `xml:"testcase"`
+}
+
+// Property is a name-value pair describing a test suite.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// TestCase is the result of a vulnerability.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Failure   *Result  `xml:"failure,omitempty"`
+	Error     *Result  `xml:"error,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+}
+
+// Result describes a failed or errored test case.
+type Result struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// Skipped describes a skipped test case.
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// DefaultThreshold is the threshold used when the config does not set one.
+const DefaultThreshold = report.SeverityHigh
+
+// Config configures the conversion. The zero value is a valid config.
+type Config struct {
+	// Threshold is the minimum severity of the vulnerabilities that fail.
+	// Nil means DefaultThreshold.
+	Threshold *report.SeverityRank
+	// SkipBelowThreshold makes the vulnerabilities below the threshold
+	// skipped test cases instead of passed ones.
+	SkipBelowThreshold bool
+}
+
+func (c Config) threshold() report.SeverityRank {
+	if c.Threshold == nil {
+		return DefaultThreshold
+	}
+	return *c.Threshold
+}
+
+// Failed returns true if any test case failed or errored.
+func (ts TestSuites) Failed() bool {
+	return ts.Failures > 0 || ts.Errors > 0
+}
+
+// Convert returns the JUnit test results of the reports. Every report is a
+// test suite with a test case per vulnerability, see
+// report.Vulnerability.Flatten. Vulnerabilities at or above the threshold
+// fail unless they are suppressed, which are skipped. Reports not applicable
+// to their targets are skipped suites and reports with an error are errored
+// suites.
+func Convert(c Config, reports ...report.Report) TestSuites {
+	ts := TestSuites{Name: "Vulcan"}
+	for _, r := range reports {
+		s := convertReport(c, r)
+		ts.Tests += s.Tests
+		ts.Failures += s.Failures
+		ts.Errors += s.Errors
+		ts.Skipped += s.Skipped
+		ts.Suites = append(ts.Suites, s)
+	}
+	return ts
+}
+
+// Marshal returns the JUnit XML document of the reports, see Convert.
+func Marshal(c Config, reports ...report.Report) ([]byte, error) {
+	data, err := xml.MarshalIndent(Convert(c, reports...), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func convertReport(c Config, r report.Report) TestSuite {
+	s := TestSuite{
+		Name: strings.TrimSpace(r.ChecktypeName + " " + r.Target),
+		Properties: []Property{
+			{Name: "check_id", Value: r.CheckID},
+			{Name: "checktype", Value: r.ChecktypeName + ":" + r.ChecktypeVersion},
+			{Name: "target", Value: r.Target},
+			{Name: "status", Value: r.Status},
+		},
+	}
+	if !r.StartTime.IsZero() {
+		s.Timestamp = r.StartTime.UTC().Format(timestampLayout)
+		if r.EndTime.After(r.StartTime) {
+			s.Time = fmt.Sprintf("%.3f", r.EndTime.Sub(r.StartTime).Seconds())
+		}
+	}
+
+	switch {
+	case r.Error != "":
+		s.TestCases = []TestCase{{
+			Name:      s.Name,
+			ClassName: r.ChecktypeName,
+			Error:     &Result{Message: r.Error, Type: r.Status},
+		}}
+	case r.NotApplicable:
+		s.TestCases = []TestCase{{
+			Name:      s.Name,
+			ClassName: r.ChecktypeName,
+			Skipped:   &Skipped{Message: "check not applicable to the target"},
+		}}
+	default:
+		for _, v := range r.Vulnerabilities {
+			for _, leaf := range v.Flatten() {
+				s.TestCases = append(s.TestCases, testCase(c, r, leaf))
+			}
+		}
+	}
+
+	for _, tc := range s.TestCases {
+		s.Tests++
+		switch {
+		case tc.Error != nil:
+			s.Errors++
+		case tc.Failure != nil:
+			s.Failures++
+		case tc.Skipped != nil:
+			s.Skipped++
+		}
+	}
+	return s
+}
+
+func testCase(c Config, r report.Report, v report.Vulnerability) TestCase {
+	name := v.Summary
+	resource := v.AffectedResourceString
+	if resource == "" {
+		resource = v.AffectedResource
+	}
+	if resource != "" {
+		name += " (" + resource + ")"
+	}
+	tc := TestCase{Name: name, ClassName: r.ChecktypeName}
+	threshold := c.threshold()
+	switch rank := v.Severity(); {
+	case v.Suppressed():
+		tc.Skipped = &Skipped{Message: "vulnerability suppressed"}
+	case rank >= threshold:
+		tc.Failure = &Result{
+			Message: v.Summary,
+			Type:    rank.String(),
+			Body:    failureBody(r, v),
+		}
+	case c.SkipBelowThreshold:
+		tc.Skipped = &Skipped{Message: fmt.Sprintf("severity %s below threshold %s", rank, threshold)}
+	}
+	return tc
+}
+
+func failureBody(r report.Report, v report.Vulnerability) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+	field("Target", r.Target)
+	field("Affected resource", v.AffectedResource)
+	field("Score", fmt.Sprintf("%.1f (%s)", v.Score, v.Severity()))
+	field("CVSS", v.CVSS)
+	if v.CWEID != 0 {
+		field("CWE", fmt.Sprintf("CWE-%d", v.CWEID))
+	}
+	for _, section := range []struct {
+		name string
+		text string
+	}{
+		{"Description", v.Description},
+		{"Details", v.Details},
+		{"Impact", v.ImpactDetails},
+	} {
+		if section.text != "" {
+			fmt.Fprintf(&b, "\n%s:\n%s\n", section.name, section.text)
+		}
+	}
+	for _, list := range []struct {
+		name  string
+		items []string
+	}{
+		{"Recommendations", v.Recommendations},
+		{"References", v.References},
+	} {
+		if len(list.items) > 0 {
+			fmt.Fprintf(&b, "\n%s:\n", list.name)
+			for _, item := range list.items {
+				fmt.Fprintf(&b, "- %s\n", item)
+			}
+		}
+	}
+	return b.String()
+}
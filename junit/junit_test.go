@@ -0,0 +1,160 @@
+package junit
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+func testReport(target string, vulns ...report.Vulnerability) report.Report {
+	return report.Report{
+		CheckData: report.CheckData{
+			CheckID:          "ID0",
+			ChecktypeName:    "vulcan-tls",
+			ChecktypeVersion: "1",
+			Target:           target,
+			Status:           "FINISHED",
+			StartTime:        time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+			EndTime:          time.Date(2021, 5, 18, 13, 31, 0, 500000000, time.UTC),
+		},
+		ResultData: report.ResultData{Vulnerabilities: vulns},
+	}
+}
+
+func threshold(s report.SeverityRank) *report.SeverityRank {
+	return &s
+}
+
+func TestConvert(t *testing.T) {
+	weakCiphers := report.Vulnerability{
+		Summary:          "Weak SSL/TLS Ciphersuites",
+		Score:            6.9,
+		AffectedResource: "443/tcp",
+		CWEID:            326,
+		Description:      "The server supports weak ciphersuites.",
+		Recommendations:  []string{"Disable weak ciphersuites."},
+	}
+	info := report.Vulnerability{Summary: "TLS Versions", Score: 0, AffectedResource: "443/tcp"}
+	suppressed := report.Vulnerability{Summary: "Self-signed Certificate", Score: 7, AffectedResource: "443/tcp", Labels: []string{report.LabelSuppressed}}
+	parent := report.Vulnerability{
+		Summary:          "Outdated Packages",
+		Score:            9.8,
+		AffectedResource: "packages",
+		Vulnerabilities: []report.Vulnerability{
+			{Summary: "CVE-2021-44228", Score: 10, AffectedResource: "log4j-core@2.14.1"},
+			{Summary: "CVE-2021-45046", Score: 3, AffectedResource: "log4j-core@2.14.1"},
+		},
+	}
+
+	notApplicable := testReport("example.com")
+	notApplicable.NotApplicable = true
+	errored := testReport("example.org")
+	errored.Status = "FAILED"
+	errored.Error = "connection refused"
+
+	tests := []struct {
+		name         string
+		config       Config
+		reports      []report.Report
+		wantTests    int
+		wantFailures int
+		wantErrors   int
+		wantSkipped  int
+	}{
+		{
+			name:         "DefaultThreshold",
+			reports:      []report.Report{testReport("example.com", weakCiphers, info, suppressed, parent)},
+			wantTests:    5,
+			wantFailures: 1,
+			wantSkipped:  1,
+		},
+		{
+			name:         "NoneThreshold",
+			config:       Config{Threshold: threshold(report.SeverityNone)},
+			reports:      []report.Report{testReport("example.com", weakCiphers, info, suppressed, parent)},
+			wantTests:    5,
+			wantFailures: 4,
+			wantSkipped:  1,
+		},
+		{
+			name:         "MediumThreshold",
+			config:       Config{Threshold: threshold(report.SeverityMedium)},
+			reports:      []report.Report{testReport("example.com", weakCiphers, info, suppressed, parent)},
+			wantTests:    5,
+			wantFailures: 2,
+			wantSkipped:  1,
+		},
+		{
+			name:         "SkipBelowThreshold",
+			config:       Config{Threshold: threshold(report.SeverityHigh), SkipBelowThreshold: true},
+			reports:      []report.Report{testReport("example.com", weakCiphers, info, suppressed, parent)},
+			wantTests:    5,
+			wantFailures: 1,
+			wantSkipped:  4,
+		},
+		{
+			name:        "NotApplicableAndErrored",
+			config:      Config{Threshold: threshold(report.SeverityLow)},
+			reports:     []report.Report{notApplicable, errored},
+			wantTests:   2,
+			wantErrors:  1,
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := Convert(tt.config, tt.reports...)
+			if ts.Tests != tt.wantTests || ts.Failures != tt.wantFailures || ts.Errors != tt.wantErrors || ts.Skipped != tt.wantSkipped {
+				t.Errorf("counts do not match: have: %d/%d/%d/%d - want: %d/%d/%d/%d",
+					ts.Tests, ts.Failures, ts.Errors, ts.Skipped,
+					tt.wantTests, tt.wantFailures, tt.wantErrors, tt.wantSkipped)
+			}
+			if ts.Failed() != (tt.wantFailures+tt.wantErrors > 0) {
+				t.Errorf("unexpected failed status: %v", ts.Failed())
+			}
+			if len(ts.Suites) != len(tt.reports) {
+				t.Errorf("unexpected number of suites: %d", len(ts.Suites))
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	r := testReport("example.com", report.Vulnerability{
+		Summary:          "Weak SSL/TLS Ciphersuites",
+		Score:            6.9,
+		AffectedResource: "443/tcp",
+		CWEID:            326,
+		Description:      "The server supports <weak> ciphersuites.",
+		References:       []string{"https://ciphersuite.info"},
+	})
+	data, err := Marshal(Config{Threshold: threshold(report.SeverityLow)}, r)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Error("missing xml header")
+	}
+
+	var ts TestSuites
+	if err := xml.Unmarshal(data, &ts); err != nil {
+		t.Fatalf("invalid xml: %s", err)
+	}
+	s := ts.Suites[0]
+	if s.Name != "vulcan-tls example.com" || s.Time != "45.500" || s.Timestamp != "2021-05-18T13:30:15" {
+		t.Errorf("unexpected suite: %s - %s - %s", s.Name, s.Time, s.Timestamp)
+	}
+	tc := s.TestCases[0]
+	if tc.Name != "Weak SSL/TLS Ciphersuites (443/tcp)" || tc.ClassName != "vulcan-tls" || tc.Failure == nil {
+		t.Fatalf("unexpected test case: %+v", tc)
+	}
+	wantBody := "Target: example.com\nAffected resource: 443/tcp\nScore: 6.9 (medium)\nCWE: CWE-326\n\n" +
+		"Description:\nThe server supports <weak> ciphersuites.\n\nReferences:\n- https://ciphersuite.info\n"
+	if tc.Failure.Body != wantBody || tc.Failure.Type != "medium" {
+		t.Errorf("failure does not match:\nhave: %q - %s\nwant: %q - medium", tc.Failure.Body, tc.Failure.Type, wantBody)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package nessus imports Nessus v2 XML exports (.nessus files) as Vulcan
+// vulnerabilities.
+package nessus
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// LabelNessus is the label added to the imported vulnerabilities.
+const LabelNessus = "nessus"
+
+// Host is a host scanned by Nessus.
+type Host struct {
+	Name       string
+	Properties map[string]string
+}
+
+// ReportItem is the result of a plugin for a port of a host.
+type ReportItem struct {
+	Port         int      `xml:"port,attr"`
+	SvcName      string   `xml:"svc_name,attr"`
+	Protocol     string   `xml:"protocol,attr"`
+	Severity     int      `xml:"severity,attr"`
+	PluginID     string   `xml:"pluginID,attr"`
+	PluginName   string   `xml:"pluginName,attr"`
+	PluginFamily string   `xml:"pluginFamily,attr"`
+	Synopsis     string   `xml:"synopsis"`
+	Description  string   `xml:"description"`
+	Solution     string   `xml:"solution"`
+	RiskFactor   string   `xml:"risk_factor"`
+	CVSSScore    float32  `xml:"cvss_base_score"`
+	CVSSVector   string   `xml:"cvss_vector"`
+	CVSS3Score   float32  `xml:"cvss3_base_score"`
+	CVSS3Vector  string   `xml:"cvss3_vector"`
+	SeeAlso      string   `xml:"see_also"`
+	CVEs         []string `xml:"cve"`
+	CWEs         []string `xml:"cwe"`
+	PluginOutput string   `xml:"plugin_output"`
+}
+
+type hostProperties struct {
+	Tags []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"tag"`
+}
+
+// Parse reads a Nessus v2 XML export calling fn for every report item, in
+// the order they appear. The export is decoded as a stream, so only one
+// report item is kept in memory at a time.
+func Parse(r io.Reader, fn func(Host, ReportItem) error) error {
+	dec := xml.NewDecoder(r)
+	var (
+		host   *Host
+		isRoot = true
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid nessus report: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if isRoot {
+				if t.Name.Local != "NessusClientData_v2" {
+					return fmt.Errorf("invalid nessus report: unexpected root element %s", t.Name.Local)
+				}
+				isRoot = false
+				continue
+			}
+			switch t.Name.Local {
+			case "Policy":
+				// The policy is not needed and can be large.
+				if err := dec.Skip(); err != nil {
+					return fmt.Errorf("invalid nessus report: %w", err)
+				}
+			case "ReportHost":
+				host = &Host{Name: attr(t, "name"), Properties: map[string]string{}}
+			case "HostProperties":
+				if host == nil {
+					return errors.New("invalid nessus report: host properties outside of a host")
+				}
+				var props hostProperties
+				if err := dec.DecodeElement(&props, &t); err != nil {
+					return fmt.Errorf("invalid nessus report: %w", err)
+				}
+				for _, tag := range props.Tags {
+					host.Properties[tag.Name] = tag.Value
+				}
+			case "ReportItem":
+				if host == nil {
+					return errors.New("invalid nessus report: report item outside of a host")
+				}
+				var item ReportItem
+				if err := dec.DecodeElement(&item, &t); err != nil {
+					return fmt.Errorf("invalid nessus report: host %s: %w", host.Name, err)
+				}
+				if err := fn(*host, item); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "ReportHost" {
+				host = nil
+			}
+		}
+	}
+	if isRoot {
+		return errors.New("invalid nessus report: empty report")
+	}
+	return nil
+}
+
+// Score returns the score of a report item: its CVSS v3 base score, its
+// CVSS v2 base score or, when it has neither, the score of its severity.
+func Score(item ReportItem) float32 {
+	switch {
+	case item.CVSS3Score > 0:
+		return item.CVSS3Score
+	case item.CVSSScore > 0:
+		return item.CVSSScore
+	}
+	switch item.Severity {
+	case 1:
+		return report.ScoreSeverity(report.SeverityLow)
+	case 2:
+		return report.ScoreSeverity(report.SeverityMedium)
+	case 3:
+		return report.ScoreSeverity(report.SeverityHigh)
+	case 4:
+		return report.ScoreSeverity(report.SeverityCritical)
+	}
+	return report.ScoreSeverity(report.SeverityNone)
+}
+
+// CVSSVector returns the CVSS vector of a report item, preferring v3 over
+// v2. The "CVSS2#" prefix Nessus adds to v2 vectors is removed.
+func CVSSVector(item ReportItem) string {
+	if item.CVSS3Vector != "" {
+		return item.CVSS3Vector
+	}
+	return strings.TrimPrefix(item.CVSSVector, "CVSS2#")
+}
+
+// Import reads a Nessus v2 XML export and returns a vulnerability per
+// plugin, with a child per host and port the plugin reported and a table of
+// them. The vulnerabilities are sorted by score and plugin ID.
+func Import(r io.Reader) ([]report.Vulnerability, error) {
+	var ids []string
+	plugins := map[string]*report.Vulnerability{}
+	err := Parse(r, func(h Host, item ReportItem) error {
+		v, ok := plugins[item.PluginID]
+		if !ok {
+			v = pluginVulnerability(item)
+			plugins[item.PluginID] = v
+			ids = append(ids, item.PluginID)
+		}
+		child := hostVulnerability(h, item)
+		v.Vulnerabilities = append(v.Vulnerabilities, child)
+		v.Resources[0].Rows = append(v.Resources[0].Rows, map[string]string{
+			"Host":     h.Name,
+			"Port":     strconv.Itoa(item.Port),
+			"Protocol": item.Protocol,
+			"Service":  item.SvcName,
+		})
+		if child.Score > v.Score {
+			v.Score = child.Score
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var vulns []report.Vulnerability
+	for _, id := range ids {
+		v := plugins[id]
+		keys := []string{id}
+		for _, c := range v.Vulnerabilities {
+			keys = append(keys, c.Fingerprint)
+		}
+		sort.Strings(keys[1:])
+		v.Fingerprint = report.Fingerprint(keys...)
+		vulns = append(vulns, *v)
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Score != vulns[j].Score {
+			return vulns[i].Score > vulns[j].Score
+		}
+		return pluginLess(vulns[i].AffectedResource, vulns[j].AffectedResource)
+	})
+	return vulns, nil
+}
+
+func pluginVulnerability(item ReportItem) *report.Vulnerability {
+	v := &report.Vulnerability{
+		Summary:                item.PluginName,
+		CVSS:                   CVSSVector(item),
+		AffectedResource:       item.PluginID,
+		AffectedResourceString: "Nessus plugin " + item.PluginID,
+		Description:            strings.TrimSpace(item.Description),
+		ImpactDetails:          strings.TrimSpace(item.Synopsis),
+		Labels:                 []string{LabelNessus},
+		Resources: []report.ResourcesGroup{{
+			Name:   "Hosts",
+			Header: []string{"Host", "Port", "Protocol", "Service"},
+		}},
+	}
+	if v.Summary == "" {
+		v.Summary = "Nessus plugin " + item.PluginID
+	}
+	if s := strings.TrimSpace(item.Solution); s != "" && s != "n/a" {
+		v.Recommendations = []string{s}
+	}
+	v.AddReferences(strings.Fields(item.SeeAlso)...)
+	for _, cve := range item.CVEs {
+		v.AddReferences("https://nvd.nist.gov/vuln/detail/" + strings.TrimSpace(cve))
+	}
+	for _, cwe := range item.CWEs {
+		if n, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(cwe), "CWE-"), 10, 32); err == nil {
+			v.CWEID = uint32(n)
+			break
+		}
+	}
+	return v
+}
+
+func hostVulnerability(h Host, item ReportItem) report.Vulnerability {
+	resource := h.Name
+	if item.Port != 0 {
+		resource = fmt.Sprintf("%s:%d", h.Name, item.Port)
+	}
+	if item.Protocol != "" {
+		resource += "/" + item.Protocol
+	}
+	resourceString := h.Name
+	if fqdn := h.Properties["host-fqdn"]; fqdn != "" && fqdn != h.Name {
+		resourceString += " (" + fqdn + ")"
+	}
+	if item.Port != 0 {
+		resourceString += fmt.Sprintf(" %d/%s", item.Port, item.Protocol)
+		if item.SvcName != "" {
+			resourceString += " (" + item.SvcName + ")"
+		}
+	}
+	summary := item.PluginName
+	if summary == "" {
+		summary = "Nessus plugin " + item.PluginID
+	}
+	return report.Vulnerability{
+		Summary:                summary,
+		Score:                  Score(item),
+		CVSS:                   CVSSVector(item),
+		AffectedResource:       resource,
+		AffectedResourceString: resourceString,
+		Details:                strings.TrimSpace(item.PluginOutput),
+		Fingerprint:            report.Fingerprint(item.PluginID, h.Name, strconv.Itoa(item.Port), item.Protocol),
+	}
+}
+
+// pluginLess sorts plugin IDs numerically.
+func pluginLess(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
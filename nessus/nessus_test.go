@@ -0,0 +1,167 @@
+package nessus
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+const testNessusReport = `<?xml version="1.0" ?>
+<NessusClientData_v2>
+  <Policy><policyName>Basic Network Scan</policyName><Preferences><ServerPreferences/></Preferences></Policy>
+  <Report name="Weekly scan" xmlns:cm="http://www.nessus.org/cm">
+    <ReportHost name="192.168.1.10">
+      <HostProperties>
+        <tag name="host-ip">192.168.1.10</tag>
+        <tag name="host-fqdn">web.example.com</tag>
+      </HostProperties>
+      <ReportItem port="443" svc_name="www" protocol="tcp" severity="2" pluginID="51192" pluginName="SSL Certificate Cannot Be Trusted" pluginFamily="General">
+        <synopsis>The SSL certificate for this service cannot be trusted.</synopsis>
+        <description>The server's X.509 certificate cannot be trusted.</description>
+        <solution>Purchase or generate a proper SSL certificate for this service.</solution>
+        <risk_factor>Medium</risk_factor>
+        <cvss_base_score>6.4</cvss_base_score>
+        <cvss_vector>CVSS2#AV:N/AC:L/Au:N/C:P/I:P/A:N</cvss_vector>
+        <cvss3_base_score>6.5</cvss3_base_score>
+        <cvss3_vector>CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:N</cvss3_vector>
+        <see_also>https://www.itu.int/rec/T-REC-X.509/en
+https://en.wikipedia.org/wiki/X.509</see_also>
+        <cwe>295</cwe>
+        <plugin_output>The following certificate was at the top of the certificate chain.</plugin_output>
+      </ReportItem>
+      <ReportItem port="22" svc_name="ssh" protocol="tcp" severity="3" pluginID="187315" pluginName="SSH Terrapin Prefix Truncation Weakness (CVE-2023-48795)" pluginFamily="Misc.">
+        <description>The remote SSH server is vulnerable to a man-in-the-middle prefix truncation weakness.</description>
+        <solution>n/a</solution>
+        <cvss3_base_score>5.9</cvss3_base_score>
+        <cvss3_vector>CVSS:3.0/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:H/A:N</cvss3_vector>
+        <cve>CVE-2023-48795</cve>
+        <plugin_output>Supports following ChaCha20-Poly1305 Client to Server algorithm : chacha20-poly1305@openssh.com</plugin_output>
+      </ReportItem>
+      <ReportItem port="0" svc_name="general" protocol="tcp" severity="0" pluginID="19506" pluginName="Nessus Scan Information" pluginFamily="Settings">
+        <description>This plugin displays information about the Nessus scan.</description>
+        <plugin_output>Nessus version : 10.6.4</plugin_output>
+      </ReportItem>
+    </ReportHost>
+    <ReportHost name="192.168.1.11">
+      <HostProperties><tag name="host-ip">192.168.1.11</tag></HostProperties>
+      <ReportItem port="8443" svc_name="www" protocol="tcp" severity="2" pluginID="51192" pluginName="SSL Certificate Cannot Be Trusted" pluginFamily="General">
+        <description>The server's X.509 certificate cannot be trusted.</description>
+        <cvss3_base_score>6.5</cvss3_base_score>
+        <cvss3_vector>CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:N</cvss3_vector>
+      </ReportItem>
+    </ReportHost>
+  </Report>
+</NessusClientData_v2>`
+
+func TestImport(t *testing.T) {
+	vulns, err := Import(strings.NewReader(testNessusReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var plugins []string
+	for _, v := range vulns {
+		plugins = append(plugins, v.AffectedResource)
+		if err := report.ValidateVulnerability(v); err != nil {
+			t.Errorf("invalid vulnerability %q: %s", v.Summary, err)
+		}
+	}
+	if want := []string{"51192", "187315", "19506"}; !reflect.DeepEqual(plugins, want) {
+		t.Fatalf("plugins do not match: have: %v - want: %v", plugins, want)
+	}
+
+	cert := vulns[0]
+	if cert.Score != 6.5 || cert.CVSS != "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:N" || cert.CWEID != 295 {
+		t.Errorf("unexpected score, vector or CWE: %v - %s - %d", cert.Score, cert.CVSS, cert.CWEID)
+	}
+	if want := []string{"https://www.itu.int/rec/T-REC-X.509/en", "https://en.wikipedia.org/wiki/X.509"}; !reflect.DeepEqual(cert.References, want) {
+		t.Errorf("references do not match: have: %v - want: %v", cert.References, want)
+	}
+	if want := []string{"Purchase or generate a proper SSL certificate for this service."}; !reflect.DeepEqual(cert.Recommendations, want) {
+		t.Errorf("recommendations do not match: have: %v - want: %v", cert.Recommendations, want)
+	}
+	if len(cert.Vulnerabilities) != 2 || len(cert.Resources[0].Rows) != 2 {
+		t.Fatalf("unexpected children: %d", len(cert.Vulnerabilities))
+	}
+	child := cert.Vulnerabilities[0]
+	if child.AffectedResource != "192.168.1.10:443/tcp" || child.AffectedResourceString != "192.168.1.10 (web.example.com) 443/tcp (www)" {
+		t.Errorf("unexpected child affected resource: %s - %s", child.AffectedResource, child.AffectedResourceString)
+	}
+	if child.Details != "The following certificate was at the top of the certificate chain." {
+		t.Errorf("unexpected details: %q", child.Details)
+	}
+
+	ssh := vulns[1]
+	if len(ssh.Recommendations) != 0 || !reflect.DeepEqual(ssh.References, []string{"https://nvd.nist.gov/vuln/detail/CVE-2023-48795"}) {
+		t.Errorf("unexpected recommendations or references: %v - %v", ssh.Recommendations, ssh.References)
+	}
+	if info := vulns[2]; info.Score != 0 || info.Vulnerabilities[0].AffectedResource != "192.168.1.10/tcp" {
+		t.Errorf("unexpected informational vulnerability: %+v", info)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", ""},
+		{"UnexpectedRoot", "<NessusClientData/>"},
+		{"Malformed", "<NessusClientData_v2><Report><ReportHost name=\"a\"><ReportItem>"},
+		{"ItemOutsideHost", "<NessusClientData_v2><Report><ReportItem pluginID=\"1\"/></Report></NessusClientData_v2>"},
+		{"InvalidPort", "<NessusClientData_v2><Report><ReportHost name=\"a\"><ReportItem port=\"x\"/></ReportHost></Report></NessusClientData_v2>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Import(strings.NewReader(tt.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestParseStream checks a large export is decoded item by item as it is
+// read.
+func TestParseStream(t *testing.T) {
+	const hosts, items = 200, 50
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprint(pw, "<NessusClientData_v2><Report name=\"large\">")
+		for h := 0; h < hosts; h++ {
+			fmt.Fprintf(pw, "<ReportHost name=\"10.0.%d.%d\"><HostProperties/>", h/256, h%256)
+			for i := 0; i < items; i++ {
+				fmt.Fprintf(pw, "<ReportItem port=\"%d\" protocol=\"tcp\" severity=\"1\" pluginID=\"%d\" pluginName=\"Plugin %d\"><plugin_output>%s</plugin_output></ReportItem>",
+					1000+i, i, i, strings.Repeat("x", 1024))
+			}
+			fmt.Fprint(pw, "</ReportHost>")
+		}
+		fmt.Fprint(pw, "</Report></NessusClientData_v2>")
+		pw.Close()
+	}()
+
+	n := 0
+	err := Parse(pr, func(h Host, item ReportItem) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != hosts*items {
+		t.Errorf("unexpected number of items: have: %d - want: %d", n, hosts*items)
+	}
+}
+
+func TestParseCallbackError(t *testing.T) {
+	errStop := fmt.Errorf("stop")
+	err := Parse(strings.NewReader(testNessusReport), func(Host, ReportItem) error {
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("unexpected error: have: %v - want: %v", err, errStop)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package nuclei imports the JSONL output of Nuclei scans as Vulcan
+// vulnerabilities.
+package nuclei
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// LabelNuclei is the label added to the imported vulnerabilities, along
+// with the tags of their templates.
+const LabelNuclei = "nuclei"
+
+// Severities of the Nuclei templates.
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+	SeverityUnknown  = "unknown"
+)
+
+// Result is a line of the JSONL output of Nuclei.
+type Result struct {
+	TemplateID       string   `json:"template-id"`
+	TemplatePath     string   `json:"template-path,omitempty"`
+	TemplateURL      string   `json:"template-url,omitempty"`
+	Info             Info     `json:"info"`
+	Type             string   `json:"type"`
+	Host             string   `json:"host"`
+	MatchedAt        string   `json:"matched-at,omitempty"`
+	MatcherName      string   `json:"matcher-name,omitempty"`
+	ExtractorName    string   `json:"extractor-name,omitempty"`
+	ExtractedResults []string `json:"extracted-results,omitempty"`
+	IP               string   `json:"ip,omitempty"`
+	Request          string   `json:"request,omitempty"`
+	Response         string   `json:"response,omitempty"`
+	CURLCommand      string   `json:"curl-command,omitempty"`
+	MatcherStatus    *bool    `json:"matcher-status,omitempty"`
+	Timestamp        string   `json:"timestamp,omitempty"`
+}
+
+// Info is the information of a Nuclei template.
+type Info struct {
+	Name           string          `json:"name"`
+	Author         StringSlice     `json:"author,omitempty"`
+	Tags           StringSlice     `json:"tags,omitempty"`
+	Description    string          `json:"description,omitempty"`
+	Reference      StringSlice     `json:"reference,omitempty"`
+	Severity       string          `json:"severity"`
+	Remediation    string          `json:"remediation,omitempty"`
+	Classification *Classification `json:"classification,omitempty"`
+}
+
+// Classification contains the classification metadata of a template.
+type Classification struct {
+	CVEID       StringSlice `json:"cve-id,omitempty"`
+	CWEID       StringSlice `json:"cwe-id,omitempty"`
+	CVSSMetrics string      `json:"cvss-metrics,omitempty"`
+	CVSSScore   float32     `json:"cvss-score,omitempty"`
+}
+
+// StringSlice is a list of strings that Nuclei encodes either as a JSON
+// array or, depending on the version and the template, as a single string
+// with comma separated values.
+type StringSlice []string
+
+// UnmarshalJSON decodes an array of strings, a comma separated string or
+// null.
+func (s *StringSlice) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err == nil {
+		*s = values
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.New("expected a string or an array of strings")
+	}
+	*s = nil
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+// Decode reads the JSONL output of Nuclei. Results with a false matcher
+// status, reported when Nuclei runs with the -matcher-status flag, are
+// discarded.
+func Decode(r io.Reader) ([]Result, error) {
+	var results []Result
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var res Result
+		err := dec.Decode(&res)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid nuclei result %d: %w", n, err)
+		}
+		if res.TemplateID == "" {
+			return nil, fmt.Errorf("invalid nuclei result %d: missing template-id", n)
+		}
+		if res.MatcherStatus != nil && !*res.MatcherStatus {
+			continue
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Import reads the JSONL output of Nuclei and converts it to Vulcan
+// vulnerabilities, see Convert.
+func Import(r io.Reader) ([]report.Vulnerability, error) {
+	results, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(results), nil
+}
+
+// Score returns the score of a Nuclei severity.
+func Score(severity string) float32 {
+	switch strings.ToLower(severity) {
+	case SeverityLow:
+		return report.ScoreSeverity(report.SeverityLow)
+	case SeverityMedium:
+		return report.ScoreSeverity(report.SeverityMedium)
+	case SeverityHigh:
+		return report.ScoreSeverity(report.SeverityHigh)
+	case SeverityCritical:
+		return report.ScoreSeverity(report.SeverityCritical)
+	}
+	return report.ScoreSeverity(report.SeverityNone)
+}
+
+// Convert returns a vulnerability for every Nuclei result. Results reported
+// more than once, with the same template, matcher, location and extracted
+// values, are converted only once.
+func Convert(results []Result) []report.Vulnerability {
+	var vulns []report.Vulnerability
+	seen := map[string]bool{}
+	for _, res := range results {
+		v := convertResult(res)
+		if seen[v.Fingerprint] {
+			continue
+		}
+		seen[v.Fingerprint] = true
+		vulns = append(vulns, v)
+	}
+	return vulns
+}
+
+func convertResult(res Result) report.Vulnerability {
+	resource := res.MatchedAt
+	if resource == "" {
+		resource = res.Host
+	}
+	summary := res.Info.Name
+	if summary == "" {
+		summary = res.TemplateID
+	}
+	v := report.Vulnerability{
+		Summary:          summary,
+		Score:            Score(res.Info.Severity),
+		AffectedResource: resource,
+		Description:      strings.TrimSpace(res.Info.Description),
+		Details:          details(res),
+		Labels:           append([]string{LabelNuclei}, res.Info.Tags...),
+		References:       append([]string(nil), res.Info.Reference...),
+	}
+	if res.Info.Remediation != "" {
+		v.Recommendations = []string{strings.TrimSpace(res.Info.Remediation)}
+	}
+	if c := res.Info.Classification; c != nil {
+		if c.CVSSScore > 0 {
+			v.Score = c.CVSSScore
+		}
+		v.CVSS = c.CVSSMetrics
+		for _, id := range c.CWEID {
+			id = strings.TrimPrefix(strings.ToUpper(id), "CWE-")
+			if n, err := strconv.ParseUint(id, 10, 32); err == nil {
+				v.CWEID = uint32(n)
+				break
+			}
+		}
+		for _, cve := range c.CVEID {
+			v.AddReferences("https://nvd.nist.gov/vuln/detail/" + strings.ToUpper(cve))
+		}
+	}
+	if len(res.ExtractedResults) > 0 {
+		name := "Extracted Results"
+		if res.ExtractorName != "" {
+			name += ": " + res.ExtractorName
+		}
+		rg := report.ResourcesGroup{Name: name, Header: []string{"Value"}}
+		for _, e := range res.ExtractedResults {
+			rg.Rows = append(rg.Rows, map[string]string{"Value": e})
+		}
+		v.Resources = []report.ResourcesGroup{rg}
+	}
+	for _, a := range []struct{ name, data string }{
+		{"request.txt", res.Request},
+		{"response.txt", res.Response},
+	} {
+		if a.data != "" {
+			v.Attachments = append(v.Attachments, report.Attachment{
+				Name:        a.name,
+				ContentType: "text/plain",
+				Data:        []byte(a.data),
+			})
+		}
+	}
+
+	extracted := append([]string(nil), res.ExtractedResults...)
+	sort.Strings(extracted)
+	parts := append([]string{res.TemplateID, res.MatcherName, resource}, extracted...)
+	v.Fingerprint = report.Fingerprint(parts...)
+	return v
+}
+
+// details describes what the template matched.
+func details(res Result) string {
+	var lines []string
+	for _, f := range []struct{ name, value string }{
+		{"Template", res.TemplateID},
+		{"Matcher", res.MatcherName},
+		{"Matched at", res.MatchedAt},
+		{"Host", res.Host},
+		{"IP", res.IP},
+		{"Protocol", res.Type},
+		{"CURL command", res.CURLCommand},
+	} {
+		if f.value != "" {
+			lines = append(lines, f.name+": "+f.value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,110 @@
+package nuclei
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+const testNucleiOutput = `{"template-id":"CVE-2021-44228","info":{"name":"Apache Log4j2 Remote Code Injection","author":["melbadry9","dhiyaneshDK"],"tags":["cve","rce","log4j"],"description":"Apache Log4j2 JNDI features do not protect against attacker controlled LDAP endpoints.\n","reference":["https://logging.apache.org/log4j/2.x/security.html"],"severity":"critical","remediation":"Upgrade to Log4j 2.3.1, 2.12.3 or 2.17.0.","classification":{"cve-id":["cve-2021-44228"],"cwe-id":["cwe-502"],"cvss-metrics":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H","cvss-score":10}},"type":"http","host":"https://example.com","matched-at":"https://example.com/api/login","matcher-name":"dns","extracted-results":["10.0.0.1"],"ip":"93.184.216.34","request":"GET /api/login HTTP/1.1\r\nHost: example.com\r\n\r\n","response":"HTTP/1.1 200 OK\r\n\r\n","matcher-status":true}
+{"template-id":"tech-detect","info":{"name":"Wappalyzer Technology Detection","author":"hakluke","tags":"tech,discovery","severity":"info","classification":{"cve-id":null,"cwe-id":"cwe-200"}},"type":"http","host":"https://example.com","matched-at":"https://example.com","matcher-name":"nginx"}
+{"template-id":"tech-detect","info":{"name":"Wappalyzer Technology Detection","author":"hakluke","tags":"tech,discovery","severity":"info"},"type":"http","host":"https://example.com","matched-at":"https://example.com","matcher-name":"nginx"}
+{"template-id":"exposed-panel","info":{"name":"Exposed Panel","severity":"medium"},"type":"http","host":"https://example.com","matched-at":"https://example.com/admin","matcher-status":false}
+`
+
+func TestImport(t *testing.T) {
+	vulns, err := Import(strings.NewReader(testNucleiOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(vulns) != 2 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(vulns))
+	}
+	for _, v := range vulns {
+		if err := report.ValidateVulnerability(v); err != nil {
+			t.Errorf("invalid vulnerability %q: %s", v.Summary, err)
+		}
+	}
+
+	log4j := vulns[0]
+	want := report.Vulnerability{
+		Summary:          "Apache Log4j2 Remote Code Injection",
+		Score:            10,
+		CVSS:             "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+		AffectedResource: "https://example.com/api/login",
+		Fingerprint:      log4j.Fingerprint,
+		CWEID:            502,
+		Description:      "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP endpoints.",
+		Details: "Template: CVE-2021-44228\nMatcher: dns\nMatched at: https://example.com/api/login\n" +
+			"Host: https://example.com\nIP: 93.184.216.34\nProtocol: http",
+		Labels:          []string{"nuclei", "cve", "rce", "log4j"},
+		Recommendations: []string{"Upgrade to Log4j 2.3.1, 2.12.3 or 2.17.0."},
+		References: []string{
+			"https://logging.apache.org/log4j/2.x/security.html",
+			"https://nvd.nist.gov/vuln/detail/CVE-2021-44228",
+		},
+		Resources: []report.ResourcesGroup{{
+			Name:   "Extracted Results",
+			Header: []string{"Value"},
+			Rows:   []map[string]string{{"Value": "10.0.0.1"}},
+		}},
+		Attachments: []report.Attachment{
+			{Name: "request.txt", ContentType: "text/plain", Data: []byte("GET /api/login HTTP/1.1\r\nHost: example.com\r\n\r\n")},
+			{Name: "response.txt", ContentType: "text/plain", Data: []byte("HTTP/1.1 200 OK\r\n\r\n")},
+		},
+	}
+	if !reflect.DeepEqual(log4j, want) {
+		t.Errorf("vulnerability does not match:\nhave: %+v\nwant: %+v", log4j, want)
+	}
+
+	tech := vulns[1]
+	if tech.Score != 0 || tech.CWEID != 200 {
+		t.Errorf("unexpected score or CWE: %v - %d", tech.Score, tech.CWEID)
+	}
+	if want := []string{"nuclei", "tech", "discovery"}; !reflect.DeepEqual(tech.Labels, want) {
+		t.Errorf("labels do not match: have: %v - want: %v", tech.Labels, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Malformed", "{\"template-id\":\"a\"}\n{"},
+		{"MissingTemplateID", `{"info":{"name":"a"}}`},
+		{"InvalidStringSlice", `{"template-id":"a","info":{"tags":1}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(tt.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     float32
+	}{
+		{"info", 0},
+		{"LOW", report.ScoreSeverity(report.SeverityLow)},
+		{"medium", report.ScoreSeverity(report.SeverityMedium)},
+		{"high", report.ScoreSeverity(report.SeverityHigh)},
+		{"critical", report.ScoreSeverity(report.SeverityCritical)},
+		{"unknown", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			if got := Score(tt.severity); got != tt.want {
+				t.Errorf("score does not match: have: %v - want: %v", got, tt.want)
+			}
+		})
+	}
+}
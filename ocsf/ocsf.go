@@ -0,0 +1,278 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package ocsf converts Vulcan reports to Open Cybersecurity Schema Framework
+// (OCSF) Vulnerability Finding events.
+package ocsf
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// SchemaVersion is the version of the OCSF schema the events conform to.
+const SchemaVersion = "1.1.0"
+
+// Vulnerability Finding class and Create activity identifiers.
+const (
+	CategoryUID  = 2
+	CategoryName = "Findings"
+	ClassUID     = 2002
+	ClassName    = "Vulnerability Finding"
+	ActivityID   = 1
+	ActivityName = "Create"
+	TypeUID      = ClassUID*100 + ActivityID
+	TypeName     = ClassName + ": " + ActivityName
+)
+
+// Severity identifiers defined by OCSF.
+const (
+	SeverityIDUnknown       = 0
+	SeverityIDInformational = 1
+	SeverityIDLow           = 2
+	SeverityIDMedium        = 3
+	SeverityIDHigh          = 4
+	SeverityIDCritical      = 5
+)
+
+// StatusIDNew is the OCSF status identifier of a new finding.
+const StatusIDNew = 1
+
+var severityNames = map[int]string{
+	SeverityIDUnknown:       "Unknown",
+	SeverityIDInformational: "Informational",
+	SeverityIDLow:           "Low",
+	SeverityIDMedium:        "Medium",
+	SeverityIDHigh:          "High",
+	SeverityIDCritical:      "Critical",
+}
+
+// VulnerabilityFinding is an OCSF Vulnerability Finding event.
+type VulnerabilityFinding struct {
+	ActivityID   int    `json:"activity_id"`
+	ActivityName string `json:"activity_name"`
+	CategoryUID  int    `json:"category_uid"`
+	CategoryName string `json:"category_name"`
+	ClassUID     int    `json:"class_uid"`
+	ClassName    string `json:"class_name"`
+	TypeUID      int    `json:"type_uid"`
+	TypeName     string `json:"type_name"`
+
+	SeverityID int    `json:"severity_id"`
+	Severity   string `json:"severity"`
+	StatusID   int    `json:"status_id"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+
+	Time      int64 `json:"time"`
+	StartTime int64 `json:"start_time,omitempty"`
+	EndTime   int64 `json:"end_time,omitempty"`
+
+	Metadata        Metadata          `json:"metadata"`
+	FindingInfo     FindingInfo       `json:"finding_info"`
+	Resources       []Resource        `json:"resources,omitempty"`
+	Vulnerabilities []Vulnerability   `json:"vulnerabilities"`
+	Unmapped        map[string]string `json:"unmapped,omitempty"`
+}
+
+// Metadata describes the event and the product that generated it.
+type Metadata struct {
+	Version string   `json:"version"`
+	Product Product  `json:"product"`
+	Labels  []string `json:"labels,omitempty"`
+}
+
+// Product identifies the product that reported the finding.
+type Product struct {
+	Name       string  `json:"name"`
+	VendorName string  `json:"vendor_name"`
+	Version    string  `json:"version,omitempty"`
+	Feature    Feature `json:"feature"`
+}
+
+// Feature identifies the feature of the product that reported the finding.
+type Feature struct {
+	UID     string `json:"uid,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// FindingInfo contains the description of the finding.
+type FindingInfo struct {
+	UID           string   `json:"uid"`
+	Title         string   `json:"title"`
+	Desc          string   `json:"desc,omitempty"`
+	Types         []string `json:"types,omitempty"`
+	CreatedTime   int64    `json:"created_time,omitempty"`
+	FirstSeenTime int64    `json:"first_seen_time,omitempty"`
+	LastSeenTime  int64    `json:"last_seen_time,omitempty"`
+	SrcURL        string   `json:"src_url,omitempty"`
+}
+
+// Resource is a resource affected by the finding.
+type Resource struct {
+	UID  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// Vulnerability contains the details of the vulnerability of the finding.
+type Vulnerability struct {
+	Title       string       `json:"title"`
+	Desc        string       `json:"desc,omitempty"`
+	Severity    string       `json:"severity"`
+	CWE         *CWE         `json:"cwe,omitempty"`
+	Remediation *Remediation `json:"remediation,omitempty"`
+	References  []string     `json:"references,omitempty"`
+}
+
+// CWE identifies the weakness of a vulnerability.
+type CWE struct {
+	UID     string `json:"uid"`
+	Caption string `json:"caption,omitempty"`
+	SrcURL  string `json:"src_url,omitempty"`
+}
+
+// Remediation describes how to fix a vulnerability.
+type Remediation struct {
+	Desc       string   `json:"desc"`
+	References []string `json:"references,omitempty"`
+}
+
+// SeverityID maps a Vulcan severity rank to an OCSF severity identifier.
+func SeverityID(rank report.SeverityRank) int {
+	switch rank {
+	case report.SeverityNone:
+		return SeverityIDInformational
+	case report.SeverityLow:
+		return SeverityIDLow
+	case report.SeverityMedium:
+		return SeverityIDMedium
+	case report.SeverityHigh:
+		return SeverityIDHigh
+	case report.SeverityCritical:
+		return SeverityIDCritical
+	}
+	return SeverityIDUnknown
+}
+
+// Convert returns one Vulnerability Finding event for every vulnerability of
+// the report. Vulnerabilities with children are flattened into one event per
+// child, see report.Vulnerability.Flatten.
+func Convert(r report.Report) []VulnerabilityFinding {
+	var findings []VulnerabilityFinding
+	for _, v := range r.FlattenVulnerabilities() {
+		findings = append(findings, convertVulnerability(r, v))
+	}
+	return findings
+}
+
+func convertVulnerability(r report.Report, fv report.FlatVulnerability) VulnerabilityFinding {
+	v := fv.Vulnerability
+	severityID := SeverityID(report.RankSeverity(v.Score))
+	eventTime := r.EndTime
+	if eventTime.IsZero() {
+		eventTime = r.StartTime
+	}
+
+	f := VulnerabilityFinding{
+		ActivityID:   ActivityID,
+		ActivityName: ActivityName,
+		CategoryUID:  CategoryUID,
+		CategoryName: CategoryName,
+		ClassUID:     ClassUID,
+		ClassName:    ClassName,
+		TypeUID:      TypeUID,
+		TypeName:     TypeName,
+		SeverityID:   severityID,
+		Severity:     severityNames[severityID],
+		StatusID:     StatusIDNew,
+		Status:       "New",
+		Message:      v.Summary,
+		Time:         millis(eventTime),
+		StartTime:    millis(r.StartTime),
+		EndTime:      millis(r.EndTime),
+		Metadata: Metadata{
+			Version: SchemaVersion,
+			Product: Product{
+				Name:       "Vulcan",
+				VendorName: "Adevinta",
+				Feature: Feature{
+					UID:     r.CheckID,
+					Name:    r.ChecktypeName,
+					Version: r.ChecktypeVersion,
+				},
+			},
+			Labels: v.Labels,
+		},
+		FindingInfo: FindingInfo{
+			UID:           findingUID(r, fv),
+			Title:         v.Summary,
+			Desc:          v.Description,
+			CreatedTime:   millis(eventTime),
+			FirstSeenTime: millis(r.StartTime),
+			LastSeenTime:  millis(eventTime),
+		},
+		Resources: resources(r, v),
+		Vulnerabilities: []Vulnerability{{
+			Title:      v.Summary,
+			Desc:       v.FullDescription(),
+			Severity:   severityNames[severityID],
+			References: v.References,
+		}},
+	}
+	if v.CWEID != 0 {
+		cwe := &CWE{UID: strconv.FormatUint(uint64(v.CWEID), 10), SrcURL: report.CWEURL(v.CWEID)}
+		if c, ok := report.LookupCWE(v.CWEID); ok {
+			cwe.Caption = c.Name
+		}
+		f.Vulnerabilities[0].CWE = cwe
+		f.FindingInfo.SrcURL = cwe.SrcURL
+	}
+	if len(v.Recommendations) > 0 {
+		f.Vulnerabilities[0].Remediation = &Remediation{
+			Desc:       strings.Join(v.Recommendations, "\n"),
+			References: v.References,
+		}
+	}
+	if v.Fingerprint != "" {
+		f.Unmapped = map[string]string{"vulcan_fingerprint": v.Fingerprint}
+	}
+	return f
+}
+
+func resources(r report.Report, v report.Vulnerability) []Resource {
+	target := Resource{UID: r.Target, Name: r.Target, Type: "Target"}
+	if t, err := report.ParseTarget(r.Target); err == nil {
+		target.UID, target.Type = t.Normalized(), string(t.Type)
+	}
+	res := []Resource{target}
+	if v.AffectedResource != "" {
+		name := v.AffectedResourceString
+		if name == "" {
+			name = v.AffectedResource
+		}
+		res = append(res, Resource{UID: v.AffectedResource, Name: name, Type: "AffectedResource"})
+	}
+	return res
+}
+
+// findingUID returns the ID of the vulnerability or, if it does not have
+// one, an ID derived from its identity, see report.VulnerabilityIdentity.
+func findingUID(r report.Report, v report.FlatVulnerability) string {
+	if v.ID != "" {
+		return v.ID
+	}
+	return report.VulnerabilityIdentity(r, v)[:32]
+}
+
+func millis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
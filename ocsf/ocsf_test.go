@@ -0,0 +1,124 @@
+package ocsf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+var testCheckData = report.CheckData{
+	CheckID:          "ID0",
+	ChecktypeName:    "vulcan-zap",
+	ChecktypeVersion: "1",
+	Target:           "HTTPS://example.com",
+	Status:           "FINISHED",
+	StartTime:        time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+	EndTime:          time.Date(2021, 5, 18, 14, 0, 50, 0, time.UTC),
+}
+
+func TestSeverityID(t *testing.T) {
+	ranks := []report.SeverityRank{report.SeverityNone, report.SeverityLow, report.SeverityMedium, report.SeverityHigh, report.SeverityCritical, 42}
+	want := []int{SeverityIDInformational, SeverityIDLow, SeverityIDMedium, SeverityIDHigh, SeverityIDCritical, SeverityIDUnknown}
+	for i, rank := range ranks {
+		if got := SeverityID(rank); got != want[i] {
+			t.Errorf("unexpected severity id for rank %d: have: %d - want: %d", rank, got, want[i])
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	r := report.Report{
+		CheckData: testCheckData,
+		ResultData: report.ResultData{
+			Vulnerabilities: []report.Vulnerability{
+				{
+					ID:               "vuln-1",
+					Summary:          "SQL Injection",
+					Score:            8.9,
+					AffectedResource: "/login",
+					Fingerprint:      "fp1",
+					CWEID:            89,
+					Description:      "desc",
+					Recommendations:  []string{"Use prepared statements"},
+					References:       []string{"https://example.com/sqli"},
+					Labels:           []string{"web"},
+				},
+				{
+					Summary: "Parent",
+					Score:   6.9,
+					Vulnerabilities: []report.Vulnerability{
+						{Summary: "Child 1", Score: 6.9, AffectedResource: "a"},
+						{Summary: "Child 2", Score: 0, AffectedResource: "b"},
+					},
+				},
+			},
+		},
+	}
+
+	findings := Convert(r)
+	if len(findings) != 3 {
+		t.Fatalf("unexpected number of findings: have: %d - want: 3", len(findings))
+	}
+
+	f := findings[0]
+	if f.ClassUID != 2002 || f.TypeUID != 200201 || f.CategoryUID != 2 {
+		t.Errorf("unexpected class: %d %d %d", f.ClassUID, f.TypeUID, f.CategoryUID)
+	}
+	if f.SeverityID != SeverityIDHigh || f.Severity != "High" {
+		t.Errorf("unexpected severity: %d %s", f.SeverityID, f.Severity)
+	}
+	if f.Time != testCheckData.EndTime.UnixNano()/1e6 || f.StartTime != testCheckData.StartTime.UnixNano()/1e6 {
+		t.Errorf("unexpected times: %d %d", f.Time, f.StartTime)
+	}
+	if f.FindingInfo.UID != "vuln-1" || f.FindingInfo.Title != "SQL Injection" {
+		t.Errorf("unexpected finding info: %+v", f.FindingInfo)
+	}
+	wantResources := []Resource{
+		{UID: "https://example.com/", Name: "HTTPS://example.com", Type: "WebAddress"},
+		{UID: "/login", Name: "/login", Type: "AffectedResource"},
+	}
+	if !reflect.DeepEqual(f.Resources, wantResources) {
+		t.Errorf("unexpected resources: have: %+v - want: %+v", f.Resources, wantResources)
+	}
+	fv := f.Vulnerabilities[0]
+	if fv.CWE == nil || fv.CWE.UID != "89" || fv.CWE.Caption == "" {
+		t.Errorf("unexpected cwe: %+v", fv.CWE)
+	}
+	if fv.Remediation == nil || fv.Remediation.Desc != "Use prepared statements" {
+		t.Errorf("unexpected remediation: %+v", fv.Remediation)
+	}
+	if f.Unmapped["vulcan_fingerprint"] != "fp1" {
+		t.Errorf("fingerprint not preserved: %+v", f.Unmapped)
+	}
+
+	if findings[2].FindingInfo.Title != "Child 2" || findings[2].SeverityID != SeverityIDInformational {
+		t.Errorf("unexpected child finding: %+v", findings[2].FindingInfo)
+	}
+	if findings[1].FindingInfo.UID == findings[2].FindingInfo.UID {
+		t.Errorf("children findings must have different uids")
+	}
+
+	if _, err := json.Marshal(findings); err != nil {
+		t.Errorf("unexpected error marshaling findings: %s", err)
+	}
+}
+
+func TestConvertChildrenSharingFingerprint(t *testing.T) {
+	child := report.Vulnerability{Summary: "Missing header", Score: 3.9, AffectedResource: "/login", Fingerprint: "fp1"}
+	r := report.Report{
+		CheckData: testCheckData,
+		ResultData: report.ResultData{Vulnerabilities: []report.Vulnerability{
+			{Summary: "Missing headers", Vulnerabilities: []report.Vulnerability{child, child}},
+		}},
+	}
+	findings := Convert(r)
+	if len(findings) != 2 {
+		t.Fatalf("unexpected number of findings: %d", len(findings))
+	}
+	if findings[0].FindingInfo.UID == findings[1].FindingInfo.UID {
+		t.Errorf("children sharing a fingerprint must have different uids")
+	}
+}
@@ -0,0 +1,497 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package osv reads advisories in the Open Source Vulnerability (OSV) format
+// and matches them against the packages affected by Vulcan vulnerabilities.
+package osv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// Types of the ranges of affected versions.
+const (
+	RangeSemver    = "SEMVER"
+	RangeEcosystem = "ECOSYSTEM"
+	RangeGit       = "GIT"
+)
+
+// LabelOSV is the label added to the vulnerabilities built from advisories.
+const LabelOSV = "osv"
+
+// ecosystems maps OSV ecosystems to package URL types.
+var ecosystems = map[string]string{
+	"Alpine":    "apk",
+	"crates.io": "cargo",
+	"Debian":    "deb",
+	"Go":        "golang",
+	"Hex":       "hex",
+	"Maven":     "maven",
+	"npm":       "npm",
+	"NuGet":     "nuget",
+	"Packagist": "composer",
+	"Pub":       "pub",
+	"PyPI":      "pypi",
+	"RubyGems":  "gem",
+	"Ubuntu":    "deb",
+}
+
+// Advisory is an OSV advisory.
+type Advisory struct {
+	SchemaVersion    string           `json:"schema_version,omitempty"`
+	ID               string           `json:"id"`
+	Modified         time.Time        `json:"modified"`
+	Published        time.Time        `json:"published"`
+	Withdrawn        *time.Time       `json:"withdrawn,omitempty"`
+	Aliases          []string         `json:"aliases,omitempty"`
+	Related          []string         `json:"related,omitempty"`
+	Summary          string           `json:"summary,omitempty"`
+	Details          string           `json:"details,omitempty"`
+	Severity         []Severity       `json:"severity,omitempty"`
+	Affected         []Affected       `json:"affected,omitempty"`
+	References       []Reference      `json:"references,omitempty"`
+	DatabaseSpecific DatabaseSpecific `json:"database_specific"`
+}
+
+// Severity is a severity score of an advisory.
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected describes the versions of a package affected by an advisory.
+type Affected struct {
+	Package  Package    `json:"package"`
+	Severity []Severity `json:"severity,omitempty"`
+	Ranges   []Range    `json:"ranges,omitempty"`
+	Versions []string   `json:"versions,omitempty"`
+}
+
+// Package identifies a package in an ecosystem.
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	PURL      string `json:"purl,omitempty"`
+}
+
+// Range is a range of affected versions described by the events that
+// introduce and fix the vulnerability.
+type Range struct {
+	Type   string  `json:"type"`
+	Repo   string  `json:"repo,omitempty"`
+	Events []Event `json:"events"`
+}
+
+// Event is a change in the status of the affected versions. Only one of its
+// fields is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Reference is a link to more information about an advisory.
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// DatabaseSpecific contains the fields that are not part of the OSV schema
+// but are commonly provided by the databases, like GitHub's.
+type DatabaseSpecific struct {
+	Severity string   `json:"severity,omitempty"`
+	CWEIDs   []string `json:"cwe_ids,omitempty"`
+}
+
+// Parse parses an OSV advisory.
+func Parse(data []byte) (Advisory, error) {
+	var a Advisory
+	if err := json.Unmarshal(data, &a); err != nil {
+		return Advisory{}, fmt.Errorf("invalid osv advisory: %w", err)
+	}
+	if a.ID == "" {
+		return Advisory{}, errors.New("invalid osv advisory: missing id")
+	}
+	return a, nil
+}
+
+// Database is a set of advisories.
+type Database struct {
+	advisories []Advisory
+}
+
+// NewDatabase returns a database with the given advisories.
+func NewDatabase(advisories ...Advisory) *Database {
+	return &Database{advisories: advisories}
+}
+
+// LoadDir returns a database with the advisories stored in the JSON files of
+// a directory and its subdirectories.
+func LoadDir(dir string) (*Database, error) {
+	db := NewDatabase()
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		a, err := Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		db.Add(a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Add adds advisories to the database.
+func (db *Database) Add(advisories ...Advisory) {
+	db.advisories = append(db.advisories, advisories...)
+}
+
+// Len returns the number of advisories in the database.
+func (db *Database) Len() int {
+	return len(db.advisories)
+}
+
+// Match returns the advisories affecting the installed version of a
+// package, sorted by ID. Withdrawn advisories are ignored.
+func (db *Database) Match(p report.AffectedPackage) ([]Advisory, error) {
+	purl, err := report.ParsePackageURL(p.PURL)
+	if err != nil {
+		return nil, err
+	}
+	version := p.Version()
+	if version == "" {
+		return nil, fmt.Errorf("package %s has no installed version", p.PURL)
+	}
+	var matches []Advisory
+	for _, a := range db.advisories {
+		if a.Withdrawn != nil {
+			continue
+		}
+		for _, aff := range a.Affected {
+			if aff.matchesPackage(purl) && aff.Affects(purl.Type, version) {
+				matches = append(matches, a)
+				break
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+	return matches, nil
+}
+
+// Vulnerabilities returns a vulnerability for every advisory affecting the
+// installed version of a package, see Advisory.Vulnerability.
+func (db *Database) Vulnerabilities(p report.AffectedPackage) ([]report.Vulnerability, error) {
+	advisories, err := db.Match(p)
+	if err != nil {
+		return nil, err
+	}
+	var vulns []report.Vulnerability
+	for _, a := range advisories {
+		v, err := a.Vulnerability(p)
+		if err != nil {
+			return nil, err
+		}
+		vulns = append(vulns, v)
+	}
+	return vulns, nil
+}
+
+// PackageURL returns the package URL, without version, of the package.
+func (p Package) PackageURL() (report.PackageURL, error) {
+	if p.PURL != "" {
+		purl, err := report.ParsePackageURL(p.PURL)
+		if err != nil {
+			return report.PackageURL{}, err
+		}
+		purl.Version = ""
+		return purl, nil
+	}
+	ecosystem, _, _ := strings.Cut(p.Ecosystem, ":")
+	typ, ok := ecosystems[ecosystem]
+	if !ok {
+		return report.PackageURL{}, fmt.Errorf("unsupported osv ecosystem %s", p.Ecosystem)
+	}
+	purl := report.PackageURL{Type: typ, Name: p.Name}
+	switch typ {
+	case "maven":
+		purl.Namespace, purl.Name, _ = strings.Cut(p.Name, ":")
+	case "npm", "golang", "composer":
+		if i := strings.LastIndex(p.Name, "/"); i >= 0 {
+			purl.Namespace, purl.Name = p.Name[:i], p.Name[i+1:]
+		}
+	case "deb":
+		purl.Namespace = strings.ToLower(ecosystem)
+	case "apk":
+		purl.Namespace = "alpine"
+	}
+	// Parse the URL again to normalize it according to its type.
+	return report.ParsePackageURL(purl.String())
+}
+
+func (aff Affected) matchesPackage(purl report.PackageURL) bool {
+	p, err := aff.Package.PackageURL()
+	if err != nil {
+		return false
+	}
+	// The namespace of OS packages is the distribution, which is not
+	// relevant to identify the package.
+	if p.Type == "deb" || p.Type == "apk" || p.Type == "rpm" {
+		return p.Type == purl.Type && p.Name == purl.Name
+	}
+	return p.Type == purl.Type && p.Namespace == purl.Namespace && p.Name == purl.Name
+}
+
+// Affects returns true if the version is affected. The version is compared
+// using the rules of the versioning scheme, see report.CompareVersions.
+// Ranges of git commits are ignored.
+func (aff Affected) Affects(scheme, version string) bool {
+	for _, v := range aff.Versions {
+		if v == version {
+			return true
+		}
+	}
+	for _, r := range aff.Ranges {
+		if r.Type != RangeGit && r.Affects(scheme, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Affects returns true if the version is in the range, following the
+// evaluation algorithm of the OSV schema.
+func (r Range) Affects(scheme, version string) bool {
+	affected := false
+	for _, e := range r.sortedEvents(scheme) {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || report.CompareVersions(scheme, version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if report.CompareVersions(scheme, version, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if report.CompareVersions(scheme, version, e.LastAffected) > 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if report.CompareVersions(scheme, version, e.Limit) >= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+func (e Event) version() string {
+	for _, v := range []string{e.Introduced, e.Fixed, e.LastAffected, e.Limit} {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (r Range) sortedEvents(scheme string) []Event {
+	events := append([]Event(nil), r.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		vi, vj := events[i].version(), events[j].version()
+		if vi == "0" || vj == "0" {
+			return vi == "0" && vj != "0"
+		}
+		return report.CompareVersions(scheme, vi, vj) < 0
+	})
+	return events
+}
+
+// VersRange returns the affected versions of the ranges in vers syntax, or
+// an empty string if they can not be expressed as intervals.
+func (aff Affected) VersRange(scheme string) string {
+	var constraints []string
+	for _, r := range aff.Ranges {
+		if r.Type == RangeGit {
+			continue
+		}
+		lower := ""
+		open := false
+		for _, e := range r.sortedEvents(scheme) {
+			switch {
+			case e.Introduced != "":
+				if open {
+					return ""
+				}
+				lower, open = e.Introduced, true
+			case e.Fixed != "" || e.Limit != "" || e.LastAffected != "":
+				if !open {
+					return ""
+				}
+				if lower != "0" {
+					constraints = append(constraints, ">="+lower)
+				}
+				if e.LastAffected != "" {
+					constraints = append(constraints, "<="+e.LastAffected)
+				} else {
+					constraints = append(constraints, "<"+e.version())
+				}
+				open = false
+			}
+		}
+		if open {
+			if lower == "0" {
+				return "vers:" + scheme + "/*"
+			}
+			constraints = append(constraints, ">="+lower)
+		}
+	}
+	if len(constraints) == 0 {
+		return ""
+	}
+	return "vers:" + scheme + "/" + strings.Join(constraints, "|")
+}
+
+// FixedVersions returns the versions fixing the vulnerability.
+func (aff Affected) FixedVersions() []string {
+	var fixed []string
+	for _, r := range aff.Ranges {
+		if r.Type == RangeGit {
+			continue
+		}
+		for _, e := range r.Events {
+			if e.Fixed != "" {
+				fixed = append(fixed, e.Fixed)
+			}
+		}
+	}
+	return fixed
+}
+
+// Score returns the score of the advisory and the CVSS vector it comes from,
+// if any. The score is the base score of the vector, v3 preferred over v2,
+// or, if it can not be computed, it is derived from the severity provided by
+// the database.
+func (a Advisory) Score() (float32, string) {
+	vector := ""
+	for _, s := range a.Severity {
+		if s.Type == "CVSS_V3" || (s.Type == "CVSS_V2" && vector == "") {
+			vector = s.Score
+		}
+	}
+	if vector != "" {
+		if score, err := report.CVSSBaseScore(vector); err == nil {
+			return score, vector
+		}
+	}
+	switch strings.ToUpper(a.DatabaseSpecific.Severity) {
+	case "LOW":
+		return report.ScoreSeverity(report.SeverityLow), vector
+	case "MODERATE", "MEDIUM":
+		return report.ScoreSeverity(report.SeverityMedium), vector
+	case "HIGH":
+		return report.ScoreSeverity(report.SeverityHigh), vector
+	case "CRITICAL":
+		return report.ScoreSeverity(report.SeverityCritical), vector
+	}
+	return report.ScoreSeverity(report.SeverityNone), vector
+}
+
+// AliasURL returns the URL of the page describing an advisory ID.
+func AliasURL(id string) string {
+	switch {
+	case strings.HasPrefix(id, "CVE-"):
+		return "https://nvd.nist.gov/vuln/detail/" + id
+	case strings.HasPrefix(id, "GHSA-"):
+		return "https://github.com/advisories/" + id
+	}
+	return "https://osv.dev/vulnerability/" + id
+}
+
+// Vulnerability returns the vulnerability of a package described by the
+// advisory. The aliases of the advisory are added as references and the
+// affected package includes the fixed versions and the vulnerable range.
+func (a Advisory) Vulnerability(p report.AffectedPackage) (report.Vulnerability, error) {
+	purl, err := report.ParsePackageURL(p.PURL)
+	if err != nil {
+		return report.Vulnerability{}, err
+	}
+	version := p.Version()
+	purl.Version = ""
+	pkg := &report.AffectedPackage{PURL: purl.String(), InstalledVersion: version}
+	for _, aff := range a.Affected {
+		if !aff.matchesPackage(purl) {
+			continue
+		}
+		pkg.FixedVersions = append(pkg.FixedVersions, aff.FixedVersions()...)
+		if pkg.VulnerableRange == "" {
+			pkg.VulnerableRange = aff.VersRange(purl.Type)
+		}
+	}
+	if pkg.Validate() != nil {
+		// The range can not be expressed in a way consistent with the
+		// installed and fixed versions, e.g. because the installed version
+		// is only listed explicitly.
+		pkg.VulnerableRange = ""
+	}
+
+	summary := a.ID
+	if a.Summary != "" {
+		summary += ": " + a.Summary
+	}
+	score, vector := a.Score()
+	v := report.Vulnerability{
+		Summary:     summary,
+		Score:       score,
+		CVSS:        vector,
+		Description: a.Details,
+		Package:     pkg,
+		Labels:      []string{LabelOSV},
+		References:  []string{AliasURL(a.ID)},
+	}
+	for _, alias := range a.Aliases {
+		v.References = append(v.References, AliasURL(alias))
+	}
+	for _, r := range a.References {
+		v.AddReferences(r.URL)
+	}
+	for _, id := range a.DatabaseSpecific.CWEIDs {
+		if n, err := strconv.ParseUint(strings.TrimPrefix(id, "CWE-"), 10, 32); err == nil {
+			v.CWEID = uint32(n)
+			break
+		}
+	}
+	if len(pkg.FixedVersions) > 0 {
+		v.Recommendations = []string{fmt.Sprintf("Upgrade %s to version %s.", purl.Name, strings.Join(pkg.FixedVersions, " or "))}
+	}
+	if err := v.DeriveAffectedResource(); err != nil {
+		return report.Vulnerability{}, err
+	}
+	v.Fingerprint = report.Fingerprint(v.AffectedResource, a.ID)
+	return v, nil
+}
@@ -0,0 +1,210 @@
+package osv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+const testLodashAdvisory = `{
+  "schema_version": "1.4.0",
+  "id": "GHSA-35jh-r3h4-6jhm",
+  "modified": "2024-01-10T05:04:39Z",
+  "published": "2021-05-06T16:05:51Z",
+  "aliases": ["CVE-2021-23337"],
+  "summary": "Command Injection in lodash",
+  "details": "lodash versions prior to 4.17.21 are vulnerable to Command Injection via the template function.",
+  "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H"}],
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "lodash"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+    },
+    {
+      "package": {"ecosystem": "npm", "name": "lodash-es"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+    }
+  ],
+  "references": [
+    {"type": "ADVISORY", "url": "https://nvd.nist.gov/vuln/detail/CVE-2021-23337"},
+    {"type": "WEB", "url": "https://snyk.io/vuln/SNYK-JS-LODASH-1040724"}
+  ],
+  "database_specific": {"cwe_ids": ["CWE-77", "CWE-94"], "severity": "HIGH"}
+}`
+
+const testBabelAdvisory = `{
+  "id": "GHSA-67hx-6x53-jw92",
+  "modified": "2023-10-16T16:01:31Z",
+  "summary": "Babel vulnerable to arbitrary code execution when compiling specifically crafted malicious code",
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "@babel/traverse"},
+      "ranges": [
+        {"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "7.23.2"}]},
+        {"type": "SEMVER", "events": [{"introduced": "8.0.0-alpha.0"}, {"fixed": "8.0.0-alpha.4"}]}
+      ]
+    }
+  ],
+  "database_specific": {"severity": "CRITICAL"}
+}`
+
+const testWithdrawnAdvisory = `{
+  "id": "GHSA-xxxx-withdrawn",
+  "modified": "2023-10-16T16:01:31Z",
+  "withdrawn": "2023-10-17T00:00:00Z",
+  "affected": [{"package": {"ecosystem": "npm", "name": "lodash"}, "versions": ["4.17.20"]}]
+}`
+
+func testDatabase(t *testing.T) *Database {
+	db := NewDatabase()
+	for _, data := range []string{testLodashAdvisory, testBabelAdvisory, testWithdrawnAdvisory} {
+		a, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		db.Add(a)
+	}
+	return db
+}
+
+func TestMatch(t *testing.T) {
+	db := testDatabase(t)
+	tests := []struct {
+		name    string
+		pkg     report.AffectedPackage
+		wantIDs []string
+	}{
+		{"Vulnerable", report.AffectedPackage{PURL: "pkg:npm/lodash", InstalledVersion: "4.17.20"}, []string{"GHSA-35jh-r3h4-6jhm"}},
+		{"VersionInPURL", report.AffectedPackage{PURL: "pkg:npm/lodash-es@4.0.0"}, []string{"GHSA-35jh-r3h4-6jhm"}},
+		{"Fixed", report.AffectedPackage{PURL: "pkg:npm/lodash", InstalledVersion: "4.17.21"}, nil},
+		{"OtherEcosystem", report.AffectedPackage{PURL: "pkg:pypi/lodash", InstalledVersion: "1.0.0"}, nil},
+		{"Scoped", report.AffectedPackage{PURL: "pkg:npm/%40babel/traverse", InstalledVersion: "7.22.0"}, []string{"GHSA-67hx-6x53-jw92"}},
+		{"SecondRange", report.AffectedPackage{PURL: "pkg:npm/%40babel/traverse", InstalledVersion: "8.0.0-alpha.3"}, []string{"GHSA-67hx-6x53-jw92"}},
+		{"BetweenRanges", report.AffectedPackage{PURL: "pkg:npm/%40babel/traverse", InstalledVersion: "7.24.0"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advisories, err := db.Match(tt.pkg)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var ids []string
+			for _, a := range advisories {
+				ids = append(ids, a.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("advisories do not match: have: %v - want: %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestVulnerabilities(t *testing.T) {
+	db := testDatabase(t)
+	vulns, err := db.Vulnerabilities(report.AffectedPackage{PURL: "pkg:npm/lodash", InstalledVersion: "4.17.20"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(vulns) != 1 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(vulns))
+	}
+	v := vulns[0]
+	if err := report.ValidateVulnerability(v); err != nil {
+		t.Errorf("invalid vulnerability: %s", err)
+	}
+	want := report.Vulnerability{
+		Summary:                "GHSA-35jh-r3h4-6jhm: Command Injection in lodash",
+		Score:                  7.2,
+		CVSS:                   "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H",
+		AffectedResource:       "pkg:npm/lodash@4.17.20",
+		AffectedResourceString: "lodash@4.17.20",
+		Fingerprint:            v.Fingerprint,
+		Package: &report.AffectedPackage{
+			PURL:             "pkg:npm/lodash",
+			InstalledVersion: "4.17.20",
+			FixedVersions:    []string{"4.17.21"},
+			VulnerableRange:  "vers:npm/<4.17.21",
+		},
+		CWEID:       77,
+		Description: "lodash versions prior to 4.17.21 are vulnerable to Command Injection via the template function.",
+		Labels:      []string{LabelOSV},
+		Recommendations: []string{
+			"Upgrade lodash to version 4.17.21.",
+		},
+		References: []string{
+			"https://github.com/advisories/GHSA-35jh-r3h4-6jhm",
+			"https://nvd.nist.gov/vuln/detail/CVE-2021-23337",
+			"https://snyk.io/vuln/SNYK-JS-LODASH-1040724",
+		},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("vulnerability does not match:\nhave: %+v\nwant: %+v", v, want)
+	}
+	if v.Fingerprint == "" {
+		t.Error("missing fingerprint")
+	}
+}
+
+func TestAffectedVersRange(t *testing.T) {
+	a, err := Parse([]byte(testBabelAdvisory))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := "vers:npm/<7.23.2|>=8.0.0-alpha.0|<8.0.0-alpha.4"
+	if got := a.Affected[0].VersRange("npm"); got != want {
+		t.Errorf("range does not match: have: %s - want: %s", got, want)
+	}
+	r, err := report.ParseVersRange(want)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for version, affected := range map[string]bool{"7.0.0": true, "7.23.2": false, "8.0.0-alpha.1": true, "8.0.0": false} {
+		if r.Contains(version) != affected {
+			t.Errorf("unexpected containment of %s: have: %v - want: %v", version, !affected, affected)
+		}
+	}
+}
+
+func TestRangeAffectsLastAffected(t *testing.T) {
+	r := Range{Type: RangeSemver, Events: []Event{{LastAffected: "1.4.0"}, {Introduced: "1.0.0"}}}
+	for version, want := range map[string]bool{"0.9.0": false, "1.0.0": true, "1.4.0": true, "1.4.1": false} {
+		if r.Affects("npm", version) != want {
+			t.Errorf("unexpected result for %s: have: %v - want: %v", version, !want, want)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "npm"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"npm/lodash.json": testLodashAdvisory,
+		"npm/babel.json":  testBabelAdvisory,
+		"README.md":       "not an advisory",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if db.Len() != 2 {
+		t.Errorf("unexpected number of advisories: %d", db.Len())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"summary": "no id"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDir(dir); err == nil {
+		t.Error("expected error loading an invalid advisory")
+	}
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactionRule defines the content to redact from a report. If the pattern
+// has a subexpression named "secret", only the text it matches is redacted,
+// otherwise the whole match is.
+type RedactionRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Built-in redaction rules.
+var (
+	RuleAWSAccessKeyID = RedactionRule{
+		Name:    "aws-access-key-id",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	}
+	RuleAWSSecretAccessKey = RedactionRule{
+		Name:    "aws-secret-access-key",
+		Pattern: regexp.MustCompile(`(?i)secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+]{40})\b`),
+	}
+	RuleJWT = RedactionRule{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+	RulePrivateKey = RedactionRule{
+		Name:    "private-key",
+		Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+	}
+	// RuleURLUserinfo redacts the user and password of the URLs. Userinfo
+	// made only of "*" is considered already redacted.
+	RuleURLUserinfo = RedactionRule{
+		Name:    "url-userinfo",
+		Pattern: regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://(?P<secret>[^/?#@\s\[\]]*[^/?#@\s\[\]*][^/?#@\s\[\]]*)@`),
+	}
+	RuleEmail = RedactionRule{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	}
+)
+
+// DefaultRedactionRules returns the built-in redaction rules.
+func DefaultRedactionRules() []RedactionRule {
+	return []RedactionRule{RulePrivateKey, RuleJWT, RuleAWSAccessKeyID, RuleAWSSecretAccessKey, RuleURLUserinfo, RuleEmail}
+}
+
+// NewRedactionRule returns a redaction rule with the given name and regular
+// expression.
+func NewRedactionRule(name, expr string) (RedactionRule, error) {
+	if name == "" {
+		return RedactionRule{}, fmt.Errorf("redaction rule %q is missing name", expr)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return RedactionRule{}, fmt.Errorf("redaction rule %s: %w", name, err)
+	}
+	return RedactionRule{Name: name, Pattern: re}, nil
+}
+
+// redact returns the text with the matches of the rule masked and the
+// number of matches.
+func (rule RedactionRule) redact(s string, style MaskStyle) (string, int) {
+	secret := rule.Pattern.SubexpIndex("secret")
+	var b strings.Builder
+	last, n := 0, 0
+	for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if secret > 0 && loc[2*secret] >= 0 {
+			start, end = loc[2*secret], loc[2*secret+1]
+		}
+		if start == end {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(style.mask(rule.Name, s[start:end]))
+		last = end
+		n++
+	}
+	if n == 0 {
+		return s, 0
+	}
+	b.WriteString(s[last:])
+	return b.String(), n
+}
+
+// MaskStyle defines how the redacted content is replaced.
+type MaskStyle int
+
+const (
+	// MaskLabel replaces the content with the name of the rule, e.g.
+	// "[REDACTED:jwt]".
+	MaskLabel MaskStyle = iota
+	// MaskStars replaces every character of the content with "*".
+	MaskStars
+	// MaskPartial replaces the content with "*", keeping its last four
+	// characters if it is at least 12 characters long.
+	MaskPartial
+	// MaskHash replaces the content with the name of the rule and the first
+	// 12 hex characters of its SHA-256, e.g. "[REDACTED:jwt:2c26b46b68ff]",
+	// so equal contents can be correlated. Note low entropy contents, like
+	// emails, can be guessed from their hash.
+	MaskHash
+)
+
+func (style MaskStyle) mask(rule, s string) string {
+	switch style {
+	case MaskStars:
+		return strings.Repeat("*", utf8.RuneCountInString(s))
+	case MaskPartial:
+		runes := []rune(s)
+		if len(runes) < 12 {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case MaskHash:
+		sum := sha256.Sum256([]byte(s))
+		return "[REDACTED:" + rule + ":" + hex.EncodeToString(sum[:])[:12] + "]"
+	}
+	return "[REDACTED:" + rule + "]"
+}
+
+// RedactionOptions defines how a report is redacted.
+type RedactionOptions struct {
+	// Rules are applied in order. Nil means DefaultRedactionRules.
+	Rules []RedactionRule
+	// Mask is the style used to replace the redacted content.
+	Mask MaskStyle
+}
+
+// Redaction records the content a rule redacted in a field of a report.
+// Fields are identified by their JSON path, e.g.
+// "vulnerabilities[0].resources[1].Rows[2].Password".
+type Redaction struct {
+	Rule  string `json:"rule"`
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+// Redact redacts the report options, tag, notes, error and data, and the
+// summary, description, details, impact details, affected resource string,
+// recommendations, references, resources and attachments of its
+// vulnerabilities, returning what was redacted. Only the credentials of the
+// URLs, see RuleURLUserinfo, are redacted from the affected resources, as
+// they identify the vulnerabilities, and they are always redacted from the
+// affected resources and their strings, regardless of the rules. The
+// fingerprints, the binary data and the attachments stored externally, see
+// ResolveAttachments, are not redacted. The report is modified without
+// altering the vulnerabilities it may share with other copies of it.
+func (r *Report) Redact(opts RedactionOptions) []Redaction {
+	rd := redactor{opts: opts}
+	if rd.opts.Rules == nil {
+		rd.opts.Rules = DefaultRedactionRules()
+	}
+	r.Options = rd.redact("options", r.Options)
+	r.Tag = rd.redact("tag", r.Tag)
+	r.Notes = rd.redact("notes", r.Notes)
+	r.Error = rd.redact("error", r.Error)
+	r.Data = rd.redactBytes("data", r.Data)
+	r.Vulnerabilities = rd.redactVulnerabilities("vulnerabilities", r.Vulnerabilities)
+	return rd.redactions
+}
+
+// urlUserinfoRules are the rules always applied to the affected resources.
+var urlUserinfoRules = []RedactionRule{RuleURLUserinfo}
+
+type redactor struct {
+	opts       RedactionOptions
+	redactions []Redaction
+}
+
+func (rd *redactor) redact(field, s string) string {
+	return rd.redactRules(field, s, rd.opts.Rules)
+}
+
+func (rd *redactor) redactRules(field, s string, rules []RedactionRule) string {
+	for _, rule := range rules {
+		var n int
+		if s, n = rule.redact(s, rd.opts.Mask); n > 0 {
+			rd.redactions = append(rd.redactions, Redaction{Rule: rule.Name, Field: field, Count: n})
+		}
+	}
+	return s
+}
+
+func (rd *redactor) redactBytes(field string, data []byte) []byte {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return data
+	}
+	s := string(data)
+	if redacted := rd.redact(field, s); redacted != s {
+		return []byte(redacted)
+	}
+	return data
+}
+
+func (rd *redactor) redactStrings(field string, ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	redacted := make([]string, len(ss))
+	for i, s := range ss {
+		redacted[i] = rd.redact(fmt.Sprintf("%s[%d]", field, i), s)
+	}
+	return redacted
+}
+
+func (rd *redactor) redactVulnerabilities(field string, vulns []Vulnerability) []Vulnerability {
+	if vulns == nil {
+		return nil
+	}
+	redacted := make([]Vulnerability, len(vulns))
+	for i, v := range vulns {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		v.Summary = rd.redact(path+".summary", v.Summary)
+		v.Description = rd.redact(path+".description", v.Description)
+		v.Details = rd.redact(path+".details", v.Details)
+		v.ImpactDetails = rd.redact(path+".impact_details", v.ImpactDetails)
+		v.AffectedResource = rd.redactRules(path+".affected_resource", v.AffectedResource, urlUserinfoRules)
+		v.AffectedResourceString = rd.redactRules(path+".affected_resource_string", v.AffectedResourceString, urlUserinfoRules)
+		v.AffectedResourceString = rd.redact(path+".affected_resource_string", v.AffectedResourceString)
+		v.Recommendations = rd.redactStrings(path+".recommendations", v.Recommendations)
+		v.References = rd.redactStrings(path+".references", v.References)
+		v.Resources = rd.redactResources(path+".resources", v.Resources)
+		v.Attachments = rd.redactAttachments(path+".attachments", v.Attachments)
+		v.Vulnerabilities = rd.redactVulnerabilities(path+".vulnerabilities", v.Vulnerabilities)
+		redacted[i] = v
+	}
+	return redacted
+}
+
+func (rd *redactor) redactResources(field string, groups []ResourcesGroup) []ResourcesGroup {
+	if groups == nil {
+		return nil
+	}
+	redacted := make([]ResourcesGroup, len(groups))
+	for i, g := range groups {
+		rows := g.Rows
+		if rows != nil {
+			rows = make([]map[string]string, len(g.Rows))
+			for j, row := range g.Rows {
+				rows[j] = make(map[string]string, len(row))
+				for _, col := range columns(g.Header, row) {
+					rows[j][col] = rd.redact(fmt.Sprintf("%s[%d].Rows[%d].%s", field, i, j, col), row[col])
+				}
+			}
+		}
+		g.Rows = rows
+		redacted[i] = g
+	}
+	return redacted
+}
+
+// columns returns the columns of a row, first the ones in the header, in
+// order, and then the rest sorted, so the redactions are recorded in a
+// stable order.
+func columns(header []string, row map[string]string) []string {
+	var cols, extra []string
+	inHeader := map[string]bool{}
+	for _, col := range header {
+		if _, ok := row[col]; ok && !inHeader[col] {
+			cols = append(cols, col)
+		}
+		inHeader[col] = true
+	}
+	for col := range row {
+		if !inHeader[col] {
+			extra = append(extra, col)
+		}
+	}
+	sort.Strings(extra)
+	return append(cols, extra...)
+}
+
+func (rd *redactor) redactAttachments(field string, attachments []Attachment) []Attachment {
+	if attachments == nil {
+		return nil
+	}
+	redacted := make([]Attachment, len(attachments))
+	for i, a := range attachments {
+		data := rd.redactBytes(fmt.Sprintf("%s[%d].data", field, i), a.Data)
+		if !bytes.Equal(data, a.Data) {
+			a.Data = data
+			if a.Digest != "" {
+				a.Digest = Digest(data)
+				a.Size = len(data)
+			}
+		}
+		redacted[i] = a
+	}
+	return redacted
+}
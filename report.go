@@ -5,7 +5,7 @@ Copyright 2019 Adevinta
 package report
 
 import (
-	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -46,62 +46,22 @@ type CheckData struct {
 
 // AddVulnerabilities is a handy method to add one or more Vulnerabilities to the ResultData.Vulnerability array.
 // It's equivalent to r.Vulnerabilities = append(r.Vulnerabilities,v).
+// It is not safe for concurrent use, see Collector.
 func (r *ResultData) AddVulnerabilities(v ...Vulnerability) {
 	r.Vulnerabilities = append(r.Vulnerabilities, v...)
 }
 
 // MarshalJSONTimeAsString marshals a Report to JSON using time as string
 // A custom marshaler is used to rewrite times for Athena and Rails.
+// Times are converted to UTC and truncated to seconds, see LegacyTimeCodec.
 // TODO: Discuss if this is necessary or if we can drop it.
 func (r *Report) MarshalJSONTimeAsString() ([]byte, error) {
-	return json.Marshal(struct {
-		Report
-		StartTime string `json:"start_time"`
-		EndTime   string `json:"end_time"`
-	}{
-		Report:    *r,
-		StartTime: formatTime(&r.StartTime, layout),
-		EndTime:   formatTime(&r.EndTime, layout),
-	})
+	return r.MarshalJSONWithTimeCodec(LegacyTimeCodec)
 }
 
 // UnmarshalJSONTimeAsString unmarshals a JSON to a Report using time as string
 func (r *Report) UnmarshalJSONTimeAsString(data []byte) error {
-	aux := &struct {
-		*Report
-		StartTime string `json:"start_time"`
-		EndTime   string `json:"end_time"`
-	}{
-		Report: r,
-	}
-	if err := json.Unmarshal(data, &aux); err != nil {
-		return err
-	}
-
-	if len(aux.StartTime) > 0 {
-		startTime, err := time.Parse(layout, aux.StartTime)
-		if err != nil {
-			return err
-		}
-		r.StartTime = startTime
-	}
-
-	if len(aux.EndTime) > 0 {
-		endTime, err := time.Parse(layout, aux.EndTime)
-		if err != nil {
-			return err
-		}
-		r.EndTime = endTime
-	}
-
-	return nil
-}
-
-func formatTime(t *time.Time, layout string) string {
-	if t != nil {
-		return t.Format(layout)
-	}
-	return ""
+	return r.UnmarshalJSONWithTimeCodec(data, LegacyTimeCodec)
 }
 
 func (r Report) Validate() error {
@@ -114,10 +74,13 @@ type Vulnerability struct {
 
 	Summary                string  `json:"summary"`                  // Mandatory. Vulnerability title.
 	Score                  float32 `json:"score"`                    // Vulnerability severity score. According to CVSSv3 base score.
+	CVSS                   string  `json:"cvss,omitempty"`           // Optional CVSS vector the score was calculated from, e.g.: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H".
 	AffectedResource       string  `json:"affected_resource"`        // Indicates the concrete resource affected by the vulnerability.
 	AffectedResourceString string  `json:"affected_resource_string"` // Optionally indicates a human-readable meaningful version of the AffectedResource.
 	Fingerprint            string  `json:"fingerprint"`              // Fingerprint defines the context in where the vulnerability has been found.
 
+	Package *AffectedPackage `json:"package,omitempty"` // Optional software package affected by the vulnerability.
+
 	CWEID         uint32   `json:"cwe_id,omitempty"`         // CWE-ID.
 	Description   string   `json:"description,omitempty"`    // Vulnerability description.
 	Details       string   `json:"details,omitempty"`        // Vulnerability details generated when running the check against the target.
@@ -132,12 +95,36 @@ type Vulnerability struct {
 	Vulnerabilities []Vulnerability `json:"vulnerabilities"` // Mandatory. Array of identified vulnerabilities.
 }
 
+// LabelSuppressed is the label that marks a vulnerability as suppressed, that
+// is, it has been reviewed and it is not considered a risk for the target.
+const LabelSuppressed = "suppressed"
+
+// Suppressed returns true if the vulnerability is labeled as suppressed.
+func (v Vulnerability) Suppressed() bool {
+	for _, l := range v.Labels {
+		if l == LabelSuppressed {
+			return true
+		}
+	}
+	return false
+}
+
 // AddVulnerabilities is a handy method to add one or more Vulnerabilities to the Vulnerability.Vulnerabilities array.
 // It's equivalent to v.Vulnerabilities = append(v.Vulnerabilities,vulnerabilities)
 func (v *Vulnerability) AddVulnerabilities(vulnerabilities ...Vulnerability) {
 	v.Vulnerabilities = append(v.Vulnerabilities, vulnerabilities...)
 }
 
+// AddReferences adds the non empty references not already present to the
+// Vulnerability.References array, keeping their order.
+func (v *Vulnerability) AddReferences(references ...string) {
+	for _, ref := range references {
+		if ref != "" && !containsString(v.References, ref) {
+			v.References = append(v.References, ref)
+		}
+	}
+}
+
 // AggregateScore recalculates the score field for a parent vulnerability.
 func (v *Vulnerability) AggregateScore() {
 	if len(v.Vulnerabilities) > 0 {
@@ -145,6 +132,53 @@ func (v *Vulnerability) AggregateScore() {
 	}
 }
 
+// Flatten returns the vulnerability itself if it has no children, or its
+// children completed with the information of the parent they do not define.
+func (v Vulnerability) Flatten() []Vulnerability {
+	if len(v.Vulnerabilities) == 0 {
+		return []Vulnerability{v}
+	}
+	var flat []Vulnerability
+	for _, child := range v.Vulnerabilities {
+		if child.Description == "" {
+			child.Description = v.Description
+		}
+		if child.ImpactDetails == "" {
+			child.ImpactDetails = v.ImpactDetails
+		}
+		if child.CWEID == 0 {
+			child.CWEID = v.CWEID
+		}
+		if len(child.Recommendations) == 0 {
+			child.Recommendations = v.Recommendations
+		}
+		if len(child.References) == 0 {
+			child.References = v.References
+		}
+		if len(child.Labels) == 0 {
+			child.Labels = v.Labels
+		}
+		if child.Package == nil {
+			child.Package = v.Package
+		}
+		flat = append(flat, child)
+	}
+	return flat
+}
+
+// FullDescription returns the description, the details and the impact
+// details of the vulnerability, the ones that are not empty, separated by
+// blank lines.
+func (v Vulnerability) FullDescription() string {
+	var parts []string
+	for _, p := range []string{v.Description, v.Details, v.ImpactDetails} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 // Severity returns the severity rank for a vulnerability.
 func (v Vulnerability) Severity() SeverityRank {
 	return RankSeverity(v.Score)
@@ -155,11 +189,16 @@ func (v Vulnerability) Validate() error {
 	return ValidateVulnerability(v)
 }
 
-// Attachment found when running the check
+// Attachment found when running the check.
+// When the attachment has been moved to an AttachmentStore, Data is empty and
+// the content is referenced by its Digest.
 type Attachment struct {
 	Name        string `json:"name"`
 	ContentType string `json:"content_type"`
 	Data        []byte `json:"data"`
+
+	Digest string `json:"digest,omitempty"` // SHA-256 digest of the data, in the form "sha256:<hex>".
+	Size   int    `json:"size,omitempty"`   // Size of the data in bytes, kept when the data is stored externally.
 }
 
 // ResourcesGroup a self-defined table for resources sharing the same attributes.
@@ -404,6 +404,20 @@ func TestValidateReport(t *testing.T) {
 			wantErr:   true,
 			errString: "report is missing target",
 		},
+		{
+			name: "ReportFreeFormTarget",
+			r: Report{
+				CheckData: CheckData{
+					CheckID:          "ID0",
+					ChecktypeName:    "CT0",
+					ChecktypeVersion: "CTV0",
+					Target:           "https://",
+					Status:           "FINISHED",
+					StartTime:        mustConvertStrToDateTime(st),
+					EndTime:          mustConvertStrToDateTime(et),
+				},
+			},
+		},
 		{
 			name: "ReportMissingStartTime",
 			r: Report{
@@ -435,3 +449,51 @@ func TestValidateReport(t *testing.T) {
 		})
 	}
 }
+
+func TestVulnerabilityFlatten(t *testing.T) {
+	parent := vulnerabilityWithScore(8.9)
+	parent.Description = "parent description"
+	parent.CWEID = 79
+	parent.Recommendations = []string{"fix it"}
+	child := vulnerabilityWithScore(3.9)
+	child.Description = "child description"
+	parent.AddVulnerabilities(child, vulnerabilityWithScore(6.9))
+
+	flat := parent.Flatten()
+	if len(flat) != 2 {
+		t.Fatalf("unexpected number of flattened vulnerabilities: have: %d - want: 2", len(flat))
+	}
+	if flat[0].Description != "child description" || flat[1].Description != "parent description" {
+		t.Errorf("unexpected descriptions: %q %q", flat[0].Description, flat[1].Description)
+	}
+	for _, v := range flat {
+		if v.CWEID != 79 || !reflect.DeepEqual(v.Recommendations, []string{"fix it"}) {
+			t.Errorf("parent information not inherited: %+v", v)
+		}
+	}
+
+	single := vulnerabilityWithScore(3.9)
+	if flat := single.Flatten(); len(flat) != 1 || !reflect.DeepEqual(flat[0], single) {
+		t.Errorf("vulnerability without children should flatten to itself: %+v", flat)
+	}
+}
+
+func TestVulnerabilitySuppressed(t *testing.T) {
+	v := vulnerabilityWithScore(3.9)
+	if v.Suppressed() {
+		t.Errorf("vulnerability without suppressed label reported as suppressed")
+	}
+	v.Labels = append(v.Labels, LabelSuppressed)
+	if !v.Suppressed() {
+		t.Errorf("vulnerability with suppressed label not reported as suppressed")
+	}
+}
+
+func TestVulnerabilityAddReferences(t *testing.T) {
+	v := Vulnerability{References: []string{"https://example.com/a"}}
+	v.AddReferences("https://example.com/b", "", "https://example.com/a", "https://example.com/b")
+	want := []string{"https://example.com/a", "https://example.com/b"}
+	if !reflect.DeepEqual(v.References, want) {
+		t.Errorf("got references %v, want %v", v.References, want)
+	}
+}
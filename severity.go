@@ -0,0 +1,159 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var severityNames = [...]string{
+	SeverityNone:     "none",
+	SeverityLow:      "low",
+	SeverityMedium:   "medium",
+	SeverityHigh:     "high",
+	SeverityCritical: "critical",
+}
+
+// String returns the name of the severity rank, e.g. "high".
+func (s SeverityRank) String() string {
+	if s < SeverityNone || s > SeverityCritical {
+		return "SeverityRank(" + strconv.Itoa(int(s)) + ")"
+	}
+	return severityNames[s]
+}
+
+// ParseSeverityRank parses a severity rank given by name, ignoring case, or
+// by number.
+func ParseSeverityRank(s string) (SeverityRank, error) {
+	for i, name := range severityNames {
+		if strings.EqualFold(s, name) {
+			return SeverityRank(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= int(SeverityNone) && n <= int(SeverityCritical) {
+		return SeverityRank(n), nil
+	}
+	return 0, fmt.Errorf("invalid severity %q", s)
+}
+
+// UnmarshalText decodes a severity rank given by name, ignoring case, or by
+// number, so the severity ranks encoded as SeverityName are accepted too.
+// Severity ranks are encoded as numbers by default.
+func (s *SeverityRank) UnmarshalText(text []byte) error {
+	rank, err := ParseSeverityRank(string(text))
+	if err != nil {
+		return err
+	}
+	*s = rank
+	return nil
+}
+
+// UnmarshalJSON decodes a severity rank given as a JSON string or number.
+func (s *SeverityRank) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid severity %s", data)
+		}
+		text = n.String()
+	}
+	return s.UnmarshalText([]byte(text))
+}
+
+// SeverityName is a severity rank encoded as its name, e.g. "high", in JSON,
+// also as a map key, and in any other format supporting
+// encoding.TextMarshaler. Use it instead of SeverityRank to opt in to the
+// name encoding.
+type SeverityName SeverityRank
+
+// String returns the name of the severity rank.
+func (s SeverityName) String() string {
+	return SeverityRank(s).String()
+}
+
+// MarshalText encodes the severity rank as its name.
+func (s SeverityName) MarshalText() ([]byte, error) {
+	if SeverityRank(s) < SeverityNone || SeverityRank(s) > SeverityCritical {
+		return nil, fmt.Errorf("invalid severity %d", int(s))
+	}
+	return []byte(severityNames[s]), nil
+}
+
+// UnmarshalText decodes a severity rank given by name, ignoring case, or by
+// number.
+func (s *SeverityName) UnmarshalText(text []byte) error {
+	return (*SeverityRank)(s).UnmarshalText(text)
+}
+
+// UnmarshalJSON decodes a severity rank given as a JSON string or number.
+func (s *SeverityName) UnmarshalJSON(data []byte) error {
+	return (*SeverityRank)(s).UnmarshalJSON(data)
+}
+
+// SeverityFormat defines how a severity rank is encoded in JSON.
+type SeverityFormat int
+
+const (
+	// SeverityFormatName encodes severity ranks as their names, e.g. "high".
+	SeverityFormatName SeverityFormat = iota
+	// SeverityFormatNumber encodes severity ranks as numbers, e.g. 3.
+	SeverityFormatNumber
+)
+
+// encode returns the value the severity rank is encoded as in JSON.
+func (f SeverityFormat) encode(s SeverityRank) interface{} {
+	if f == SeverityFormatNumber {
+		return int(s)
+	}
+	return SeverityName(s)
+}
+
+// severityVulnerability is a vulnerability with its severity.
+type severityVulnerability struct {
+	Vulnerability
+	Severity        interface{}             `json:"severity"`
+	Vulnerabilities []severityVulnerability `json:"vulnerabilities"`
+}
+
+func newSeverityVulnerability(v Vulnerability, f SeverityFormat) severityVulnerability {
+	sv := severityVulnerability{Vulnerability: v, Severity: f.encode(v.Severity())}
+	if v.Vulnerabilities != nil {
+		sv.Vulnerabilities = newSeverityVulnerabilities(v.Vulnerabilities, f)
+	}
+	return sv
+}
+
+func newSeverityVulnerabilities(vulns []Vulnerability, f SeverityFormat) []severityVulnerability {
+	svs := make([]severityVulnerability, 0, len(vulns))
+	for _, v := range vulns {
+		svs = append(svs, newSeverityVulnerability(v, f))
+	}
+	return svs
+}
+
+// MarshalJSONWithSeverity marshals a Vulnerability to JSON adding to it, and
+// to its children, a "severity" field with the severity rank of its score
+// encoded in the given format. The field is ignored when unmarshaling.
+func (v Vulnerability) MarshalJSONWithSeverity(f SeverityFormat) ([]byte, error) {
+	return json.Marshal(newSeverityVulnerability(v, f))
+}
+
+// MarshalJSONWithSeverity marshals a Report to JSON adding to every
+// vulnerability a "severity" field with the severity rank of its score
+// encoded in the given format. The field is ignored when unmarshaling.
+func (r *Report) MarshalJSONWithSeverity(f SeverityFormat) ([]byte, error) {
+	aux := struct {
+		Report
+		Vulnerabilities []severityVulnerability `json:"vulnerabilities"`
+	}{Report: *r}
+	if r.Vulnerabilities != nil {
+		aux.Vulnerabilities = newSeverityVulnerabilities(r.Vulnerabilities, f)
+	}
+	return json.Marshal(aux)
+}
@@ -0,0 +1,223 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSeverityRankString(t *testing.T) {
+	tests := []struct {
+		rank SeverityRank
+		want string
+	}{
+		{rank: SeverityNone, want: "none"},
+		{rank: SeverityLow, want: "low"},
+		{rank: SeverityMedium, want: "medium"},
+		{rank: SeverityHigh, want: "high"},
+		{rank: SeverityCritical, want: "critical"},
+		{rank: 7, want: "SeverityRank(7)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.rank.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverityRank(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    SeverityRank
+		wantErr bool
+	}{
+		{s: "none", want: SeverityNone},
+		{s: "Low", want: SeverityLow},
+		{s: "MEDIUM", want: SeverityMedium},
+		{s: "high", want: SeverityHigh},
+		{s: "4", want: SeverityCritical},
+		{s: "5", wantErr: true},
+		{s: "-1", wantErr: true},
+		{s: "severe", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseSeverityRank(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityRankJSON(t *testing.T) {
+	type doc struct {
+		Severity SeverityRank         `json:"severity"`
+		Counts   map[SeverityRank]int `json:"counts"`
+	}
+	// Severity ranks are encoded as numbers by default.
+	in := doc{Severity: SeverityHigh, Counts: map[SeverityRank]int{SeverityLow: 1}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := `{"severity":3,"counts":{"1":1}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	data, err = json.Marshal(doc{Severity: 9})
+	if err != nil {
+		t.Fatalf("marshal invalid severity: %v", err)
+	}
+	if want := `{"severity":9,"counts":null}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    doc
+		wantErr bool
+	}{
+		{
+			name: "names",
+			data: `{"severity":"High","counts":{"low":1}}`,
+			want: doc{Severity: SeverityHigh, Counts: map[SeverityRank]int{SeverityLow: 1}},
+		},
+		{
+			name: "numbers",
+			data: `{"severity":3,"counts":{"1":1}}`,
+			want: doc{Severity: SeverityHigh, Counts: map[SeverityRank]int{SeverityLow: 1}},
+		},
+		{
+			name:    "invalid name",
+			data:    `{"severity":"severe"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid number",
+			data:    `{"severity":5}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid type",
+			data:    `{"severity":true}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got doc
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityNameJSON(t *testing.T) {
+	type doc struct {
+		Severity SeverityName         `json:"severity"`
+		Counts   map[SeverityName]int `json:"counts"`
+	}
+	in := doc{Severity: SeverityName(SeverityHigh), Counts: map[SeverityName]int{SeverityName(SeverityLow): 1}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := `{"severity":"high","counts":{"low":1}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	if _, err := json.Marshal(doc{Severity: 9}); err == nil {
+		t.Error("got no error marshaling an invalid severity")
+	}
+	var got doc
+	if err := json.Unmarshal([]byte(`{"severity":4,"counts":{"medium":1}}`), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := doc{Severity: SeverityName(SeverityCritical), Counts: map[SeverityName]int{SeverityName(SeverityMedium): 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalJSONWithSeverity(t *testing.T) {
+	v := Vulnerability{
+		Summary: "Vulnerable package",
+		Score:   8.9,
+		Vulnerabilities: []Vulnerability{
+			{Summary: "CVE-1", Score: 8.9},
+			{Summary: "CVE-2", Score: 3.9},
+		},
+	}
+	r := Report{ResultData: ResultData{Vulnerabilities: []Vulnerability{v}}}
+
+	tests := []struct {
+		name   string
+		format SeverityFormat
+		want   []interface{}
+	}{
+		{name: "name", format: SeverityFormatName, want: []interface{}{"high", "high", "low"}},
+		{name: "number", format: SeverityFormatNumber, want: []interface{}{float64(3), float64(3), float64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := r.MarshalJSONWithSeverity(tt.format)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var got struct {
+				Target          string `json:"target"`
+				Vulnerabilities []struct {
+					Severity        interface{} `json:"severity"`
+					Vulnerabilities []struct {
+						Severity interface{} `json:"severity"`
+					} `json:"vulnerabilities"`
+				} `json:"vulnerabilities"`
+			}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			var severities []interface{}
+			for _, v := range got.Vulnerabilities {
+				severities = append(severities, v.Severity)
+				for _, c := range v.Vulnerabilities {
+					severities = append(severities, c.Severity)
+				}
+			}
+			if !reflect.DeepEqual(severities, tt.want) {
+				t.Errorf("got severities %v, want %v", severities, tt.want)
+			}
+
+			// The report is decoded ignoring the severities.
+			var rt Report
+			if err := json.Unmarshal(data, &rt); err != nil {
+				t.Fatalf("unmarshal report: %v", err)
+			}
+			if !reflect.DeepEqual(rt.Vulnerabilities, r.Vulnerabilities) {
+				t.Errorf("got vulnerabilities %+v, want %+v", rt.Vulnerabilities, r.Vulnerabilities)
+			}
+		})
+	}
+
+	data, err := v.Vulnerabilities[1].MarshalJSONWithSeverity(SeverityFormatName)
+	if err != nil {
+		t.Fatalf("marshal vulnerability: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal vulnerability: %v", err)
+	}
+	if got["severity"] != "low" || got["vulnerabilities"] != nil {
+		t.Errorf("got vulnerability %s, want low severity and null children", data)
+	}
+}
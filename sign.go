@@ -0,0 +1,147 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SignatureAlgorithmEd25519 identifies the Ed25519 signatures.
+const SignatureAlgorithmEd25519 = "ed25519"
+
+var (
+	// ErrUnknownKey is returned by a KeyResolver when the requested key ID
+	// is not known.
+	ErrUnknownKey = errors.New("unknown key")
+	// ErrInvalidSignature is returned when the signature of a report does
+	// not match its content or the key.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Signature is a detached signature of a report. The signed payload is the
+// canonical JSON object with the algorithm, the key ID and the canonical JSON
+// serialization of the report, see CanonicalJSON, so the algorithm and the
+// key ID can not be replaced without invalidating the signature.
+type Signature struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Value     []byte `json:"value"`
+}
+
+// SignedReport is a report together with its signature.
+type SignedReport struct {
+	Report    Report    `json:"report"`
+	Signature Signature `json:"signature"`
+}
+
+// KeyResolver returns the public keys used to verify the signatures of the
+// reports.
+type KeyResolver interface {
+	PublicKey(keyID string) (ed25519.PublicKey, error)
+}
+
+// KeyResolverFunc is an adapter to use a function as a KeyResolver.
+type KeyResolverFunc func(keyID string) (ed25519.PublicKey, error)
+
+// PublicKey returns f(keyID).
+func (f KeyResolverFunc) PublicKey(keyID string) (ed25519.PublicKey, error) {
+	return f(keyID)
+}
+
+// KeyRing is a KeyResolver that maps key IDs to public keys.
+type KeyRing map[string]ed25519.PublicKey
+
+// PublicKey returns the public key with the given ID, or an error wrapping
+// ErrUnknownKey if it is not in the key ring.
+func (k KeyRing) PublicKey(keyID string) (ed25519.PublicKey, error) {
+	key, ok := k[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	return key, nil
+}
+
+// SignReport returns the detached signature of a report made with the given
+// private key, identified by keyID.
+func SignReport(r Report, keyID string, key ed25519.PrivateKey) (Signature, error) {
+	if keyID == "" {
+		return Signature{}, errors.New("signing key is missing ID")
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return Signature{}, fmt.Errorf("signing key %q has an invalid size %d", keyID, len(key))
+	}
+	data, err := signedPayload(r, SignatureAlgorithmEd25519, keyID)
+	if err != nil {
+		return Signature{}, err
+	}
+	return Signature{
+		KeyID:     keyID,
+		Algorithm: SignatureAlgorithmEd25519,
+		Value:     ed25519.Sign(key, data),
+	}, nil
+}
+
+// VerifyReport verifies the detached signature of a report using the public
+// key the resolver returns for its key ID. It returns an error wrapping
+// ErrInvalidSignature if the signature does not match.
+func VerifyReport(r Report, sig Signature, resolver KeyResolver) error {
+	if resolver == nil {
+		return errors.New("missing key resolver")
+	}
+	if sig.Algorithm != SignatureAlgorithmEd25519 {
+		return fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+	key, err := resolver.PublicKey(sig.KeyID)
+	if err != nil {
+		return err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("public key %q has an invalid size %d", sig.KeyID, len(key))
+	}
+	data, err := signedPayload(r, sig.Algorithm, sig.KeyID)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, data, sig.Value) {
+		return fmt.Errorf("%w: key %q", ErrInvalidSignature, sig.KeyID)
+	}
+	return nil
+}
+
+// signedPayload returns the payload signed for a report with the given
+// algorithm and key ID.
+func signedPayload(r Report, algorithm, keyID string) ([]byte, error) {
+	content, err := CanonicalJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(struct {
+		Algorithm string          `json:"algorithm"`
+		KeyID     string          `json:"key_id"`
+		Report    json.RawMessage `json:"report"`
+	}{algorithm, keyID, content})
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJSON(data)
+}
+
+// NewSignedReport returns a report signed with the given private key,
+// identified by keyID.
+func NewSignedReport(r Report, keyID string, key ed25519.PrivateKey) (SignedReport, error) {
+	sig, err := SignReport(r, keyID, key)
+	if err != nil {
+		return SignedReport{}, err
+	}
+	return SignedReport{Report: r, Signature: sig}, nil
+}
+
+// Verify verifies the signature of the report, see VerifyReport.
+func (s SignedReport) Verify(resolver KeyResolver) error {
+	return VerifyReport(s.Report, s.Signature, resolver)
+}
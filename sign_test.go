@@ -0,0 +1,220 @@
+package report
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func testSigningKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func TestSignAndVerifyReport(t *testing.T) {
+	key := testSigningKey(1)
+	other := testSigningKey(2)
+	keys := KeyRing{
+		"key1":   key.Public().(ed25519.PublicKey),
+		"key2":   other.Public().(ed25519.PublicKey),
+		"alias1": key.Public().(ed25519.PublicKey),
+	}
+	r := reportWithAttachments(Attachment{Name: "a.txt", ContentType: "text/plain", Data: []byte("a")})
+
+	tests := []struct {
+		name    string
+		modify  func(r *Report, sig *Signature)
+		wantErr error
+		errStr  string
+	}{
+		{
+			name:   "valid",
+			modify: func(r *Report, sig *Signature) {},
+		},
+		{
+			name: "reordered labels",
+			modify: func(r *Report, sig *Signature) {
+				v := r.Vulnerabilities[0]
+				v.Labels = []string{"potential", "docker"}
+				r.Vulnerabilities = []Vulnerability{v}
+			},
+		},
+		{
+			name: "modified report",
+			modify: func(r *Report, sig *Signature) {
+				r.Status = StatusFailed
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "modified attachment",
+			modify: func(r *Report, sig *Signature) {
+				v := r.Vulnerabilities[0]
+				v.Attachments = []Attachment{{Name: "a.txt", ContentType: "text/plain", Data: []byte("b")}}
+				r.Vulnerabilities = []Vulnerability{v}
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "other key",
+			modify: func(r *Report, sig *Signature) {
+				sig.KeyID = "key2"
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "other key ID of the same key",
+			modify: func(r *Report, sig *Signature) {
+				sig.KeyID = "alias1"
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "unknown key",
+			modify: func(r *Report, sig *Signature) {
+				sig.KeyID = "key3"
+			},
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name: "unsupported algorithm",
+			modify: func(r *Report, sig *Signature) {
+				sig.Algorithm = "rsa"
+			},
+			errStr: `unsupported signature algorithm "rsa"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := SignReport(r, "key1", key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			signed := r
+			tt.modify(&signed, &sig)
+			err = VerifyReport(signed, sig, keys)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got error %v, want %v", err, tt.wantErr)
+				}
+			case tt.errStr != "":
+				if err == nil || err.Error() != tt.errStr {
+					t.Errorf("got error %v, want %s", err, tt.errStr)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyReportAttachmentsRoundTrip(t *testing.T) {
+	key := testSigningKey(1)
+	keys := KeyRing{"key1": key.Public().(ed25519.PublicKey)}
+	r := reportWithAttachments(Attachment{Name: "a.txt", ContentType: "text/plain", Data: []byte("a")})
+	sig, err := SignReport(r, "key1", key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	external := cloneReport(t, r)
+	if err := external.ExternalizeAttachments(store, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !external.Vulnerabilities[0].Attachments[0].IsExternal() {
+		t.Fatalf("attachment not externalized")
+	}
+	if err := VerifyReport(external, sig, keys); err != nil {
+		t.Errorf("unexpected error verifying the externalized report: %v", err)
+	}
+	externalSig, err := SignReport(external, "key1", key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resolved := cloneReport(t, external)
+	if err := resolved.ResolveAttachments(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := VerifyReport(resolved, externalSig, keys); err != nil {
+		t.Errorf("unexpected error verifying the resolved report: %v", err)
+	}
+	if len(r.Vulnerabilities[0].Attachments[0].Data) == 0 {
+		t.Errorf("signing modified the attachments of the report")
+	}
+}
+
+func cloneReport(t *testing.T, r Report) Report {
+	t.Helper()
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var clone Report
+	if err := json.Unmarshal(data, &clone); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return clone
+}
+
+func TestVerifyReportNilResolver(t *testing.T) {
+	sig, err := SignReport(Report{}, "key1", testSigningKey(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := VerifyReport(Report{}, sig, nil); err == nil || err.Error() != "missing key resolver" {
+		t.Errorf("got error %v, want missing key resolver", err)
+	}
+}
+
+func TestSignReportInvalidKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyID  string
+		key    ed25519.PrivateKey
+		errStr string
+	}{
+		{name: "missing key ID", key: testSigningKey(1), errStr: "signing key is missing ID"},
+		{name: "invalid key", keyID: "key1", key: ed25519.PrivateKey("short"), errStr: `signing key "key1" has an invalid size 5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SignReport(Report{}, tt.keyID, tt.key)
+			if err == nil || err.Error() != tt.errStr {
+				t.Errorf("got error %v, want %s", err, tt.errStr)
+			}
+		})
+	}
+}
+
+func TestSignedReportJSON(t *testing.T) {
+	key := testSigningKey(1)
+	r := reportWithAttachments(Attachment{Name: "a.txt", ContentType: "text/plain", Data: []byte("a")})
+	signed, err := NewSignedReport(r, "key1", key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded SignedReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resolver := KeyResolverFunc(func(keyID string) (ed25519.PublicKey, error) {
+		if keyID != "key1" {
+			return nil, ErrUnknownKey
+		}
+		return key.Public().(ed25519.PublicKey), nil
+	})
+	if err := decoded.Verify(resolver); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
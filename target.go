@@ -0,0 +1,345 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AssetType defines the kind of asset a check target refers to.
+type AssetType string
+
+const (
+	AssetTypeHostname      AssetType = "Hostname"
+	AssetTypeIP            AssetType = "IP"
+	AssetTypeIPRange       AssetType = "IPRange"
+	AssetTypeWebAddress    AssetType = "WebAddress"
+	AssetTypeDockerImage   AssetType = "DockerImage"
+	AssetTypeGitRepository AssetType = "GitRepository"
+	AssetTypeAWSAccount    AssetType = "AWSAccount"
+)
+
+const defaultDockerRegistry = "docker.io"
+
+var (
+	hostnameLabelRegexp = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?$`)
+	awsAccountIDRegexp  = regexp.MustCompile(`^[0-9]{12}$`)
+	dockerPathRegexp    = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	dockerTagRegexp     = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	dockerDigestRegexp  = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	gitSCPLikeURLRegexp = regexp.MustCompile(`^([A-Za-z0-9_.-]+@)?([A-Za-z0-9.-]+):(.+)$`)
+)
+
+// Target is a check target classified into an asset type, with its
+// structured components. Only the components that apply to the asset type
+// are set.
+type Target struct {
+	Raw  string    // The target as received.
+	Type AssetType // The asset type of the target.
+
+	Scheme string // WebAddress and GitRepository scheme.
+	Host   string // Hostname, WebAddress and GitRepository host, IP address.
+	Port   int    // Port, if explicitly present.
+	Path   string // WebAddress and GitRepository path.
+	Query  string // WebAddress query.
+
+	Prefix netip.Prefix // IPRange network.
+
+	Registry   string // DockerImage registry.
+	Repository string // DockerImage repository.
+	Tag        string // DockerImage tag.
+	Digest     string // DockerImage digest.
+
+	Partition string // AWSAccount partition.
+	AccountID string // AWSAccount ID.
+}
+
+// ParseTarget classifies a target string into an asset type, validating it
+// according to the rules of that type.
+func ParseTarget(s string) (Target, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Target{}, errors.New("target is empty")
+	}
+	t := Target{Raw: s}
+	var err error
+	switch {
+	case strings.HasPrefix(s, "arn:"):
+		err = t.parseAWSAccountARN(s)
+	case awsAccountIDRegexp.MatchString(s):
+		t.Type, t.Partition, t.AccountID = AssetTypeAWSAccount, "aws", s
+	case strings.Contains(s, "://"):
+		err = t.parseURL(s)
+	case strings.HasPrefix(s, "git@"):
+		err = t.parseSCPLikeGitURL(s)
+	default:
+		err = t.parseHostOrImage(s)
+	}
+	if err != nil {
+		return Target{}, fmt.Errorf("invalid target %q: %w", s, err)
+	}
+	return t, nil
+}
+
+func (t *Target) parseAWSAccountARN(s string) error {
+	// arn:partition:iam::account-id:root
+	parts := strings.Split(s, ":")
+	if len(parts) != 6 || parts[2] != "iam" || parts[3] != "" || parts[5] != "root" {
+		return errors.New("AWS account ARN must have the form arn:<partition>:iam::<account-id>:root")
+	}
+	if !strings.HasPrefix(parts[1], "aws") {
+		return fmt.Errorf("unknown AWS partition %q", parts[1])
+	}
+	if !awsAccountIDRegexp.MatchString(parts[4]) {
+		return fmt.Errorf("invalid AWS account ID %q", parts[4])
+	}
+	t.Type, t.Partition, t.AccountID = AssetTypeAWSAccount, parts[1], parts[4]
+	return nil
+}
+
+func (t *Target) parseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	switch scheme {
+	case "http", "https":
+		t.Type = AssetTypeWebAddress
+		if strings.HasSuffix(u.Path, ".git") {
+			t.Type = AssetTypeGitRepository
+		}
+	case "ssh", "git", "git+ssh":
+		t.Type = AssetTypeGitRepository
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("missing host")
+	}
+	t.Scheme = scheme
+	if t.Host, err = normalizeHost(u.Hostname()); err != nil {
+		return err
+	}
+	if p := u.Port(); p != "" {
+		if t.Port, err = parsePort(p); err != nil {
+			return err
+		}
+	}
+	t.Path = u.EscapedPath()
+	t.Query = u.RawQuery
+	if t.Type == AssetTypeGitRepository {
+		t.Path = normalizeGitPath(t.Path)
+		if t.Path == "" {
+			return errors.New("missing repository path")
+		}
+	}
+	return nil
+}
+
+func (t *Target) parseSCPLikeGitURL(s string) error {
+	m := gitSCPLikeURLRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return errors.New("invalid git repository address")
+	}
+	host, err := normalizeHost(m[2])
+	if err != nil {
+		return err
+	}
+	t.Type, t.Scheme, t.Host = AssetTypeGitRepository, "ssh", host
+	t.Path = normalizeGitPath("/" + strings.TrimPrefix(m[3], "/"))
+	if t.Path == "" {
+		return errors.New("missing repository path")
+	}
+	return nil
+}
+
+func (t *Target) parseHostOrImage(s string) error {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		t.Type, t.Prefix = AssetTypeIPRange, p.Masked()
+		return nil
+	}
+	if a, err := netip.ParseAddr(s); err == nil {
+		t.Type, t.Host = AssetTypeIP, a.Unmap().String()
+		return nil
+	}
+	if strings.ContainsAny(s, "/@") {
+		return t.parseDockerImage(s)
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		// Not a host:port pair, so it must be a plain hostname.
+		host, err := normalizeHost(s)
+		if err != nil {
+			return err
+		}
+		t.Type, t.Host = AssetTypeHostname, host
+		return nil
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		// A non numeric suffix is a tag of an image in the default registry.
+		return t.parseDockerImage(s)
+	}
+	if t.Host, err = normalizeHost(host); err != nil {
+		return err
+	}
+	t.Type = AssetTypeHostname
+	t.Port, err = parsePort(port)
+	return err
+}
+
+func (t *Target) parseDockerImage(s string) error {
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, t.Digest = name[:i], name[i+1:]
+		if !dockerDigestRegexp.MatchString(t.Digest) {
+			return fmt.Errorf("invalid image digest %q", t.Digest)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, t.Tag = name[:i], name[i+1:]
+		if !dockerTagRegexp.MatchString(t.Tag) {
+			return fmt.Errorf("invalid image tag %q", t.Tag)
+		}
+	}
+	t.Registry = defaultDockerRegistry
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			t.Registry, name = strings.ToLower(first), name[i+1:]
+		}
+	}
+	if t.Registry == defaultDockerRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if !dockerPathRegexp.MatchString(name) {
+		return fmt.Errorf("invalid image repository %q", name)
+	}
+	if t.Tag == "" && t.Digest == "" {
+		t.Tag = "latest"
+	}
+	t.Type, t.Repository = AssetTypeDockerImage, name
+	return nil
+}
+
+func normalizeHost(host string) (string, error) {
+	if a, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return a.Unmap().String(), nil
+	}
+	h := strings.TrimSuffix(strings.ToLower(host), ".")
+	if h == "" || len(h) > 253 {
+		return "", fmt.Errorf("invalid hostname %q", host)
+	}
+	for _, label := range strings.Split(h, ".") {
+		if !hostnameLabelRegexp.MatchString(label) {
+			return "", fmt.Errorf("invalid hostname %q", host)
+		}
+	}
+	return h, nil
+}
+
+func parsePort(p string) (int, error) {
+	port, err := strconv.Atoi(p)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", p)
+	}
+	return port, nil
+}
+
+func normalizeGitPath(p string) string {
+	p = strings.TrimSuffix(p, "/")
+	p = strings.TrimSuffix(p, ".git")
+	if p == "/" {
+		return ""
+	}
+	return p
+}
+
+// Normalized returns the canonical string representation of the target,
+// so equivalent targets have the same representation.
+func (t Target) Normalized() string {
+	switch t.Type {
+	case AssetTypeHostname:
+		if t.Port != 0 {
+			return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+		}
+		return t.Host
+	case AssetTypeIP:
+		return t.Host
+	case AssetTypeIPRange:
+		return t.Prefix.String()
+	case AssetTypeWebAddress:
+		s := t.Scheme + "://" + t.hostPort()
+		if t.Path == "" {
+			s += "/"
+		}
+		s += t.Path
+		if t.Query != "" {
+			s += "?" + t.Query
+		}
+		return s
+	case AssetTypeGitRepository:
+		// Git repositories are identified by host and path, regardless of the
+		// protocol used to clone them. Ports other than the default one of
+		// the protocol are kept, as they may refer to another server.
+		return "https://" + t.hostPort() + t.Path
+	case AssetTypeDockerImage:
+		s := t.Registry + "/" + t.Repository
+		if t.Tag != "" {
+			s += ":" + t.Tag
+		}
+		if t.Digest != "" {
+			s += "@" + t.Digest
+		}
+		return s
+	case AssetTypeAWSAccount:
+		return "arn:" + t.Partition + ":iam::" + t.AccountID + ":root"
+	}
+	return t.Raw
+}
+
+// defaultPorts are the default ports of the schemes of the targets.
+var defaultPorts = map[string]int{
+	"http":    80,
+	"https":   443,
+	"ssh":     22,
+	"git+ssh": 22,
+	"git":     9418,
+}
+
+// hostPort returns the host of the target followed by its port, unless it
+// is the default one of its scheme.
+func (t Target) hostPort() string {
+	host := t.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if t.Port == 0 || t.Port == defaultPorts[t.Scheme] {
+		return host
+	}
+	return host + ":" + strconv.Itoa(t.Port)
+}
+
+// String returns the target as received.
+func (t Target) String() string {
+	return t.Raw
+}
+
+// Equal returns true if both targets refer to the same asset.
+func (t Target) Equal(o Target) bool {
+	return t.Type == o.Type && t.Normalized() == o.Normalized()
+}
+
+// ParsedTarget returns the target of the check classified into an asset type.
+func (c CheckData) ParsedTarget() (Target, error) {
+	return ParseTarget(c.Target)
+}
@@ -0,0 +1,198 @@
+package report
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		wantType       AssetType
+		wantNormalized string
+		wantErr        bool
+	}{
+		{
+			name:           "Hostname",
+			target:         "WWW.Example.com.",
+			wantType:       AssetTypeHostname,
+			wantNormalized: "www.example.com",
+		},
+		{
+			name:           "HostnameWithPort",
+			target:         "example.com:8080",
+			wantType:       AssetTypeHostname,
+			wantNormalized: "example.com:8080",
+		},
+		{
+			name:           "IPv4",
+			target:         "192.168.1.10",
+			wantType:       AssetTypeIP,
+			wantNormalized: "192.168.1.10",
+		},
+		{
+			name:           "IPv6",
+			target:         "2001:DB8:0:0::1",
+			wantType:       AssetTypeIP,
+			wantNormalized: "2001:db8::1",
+		},
+		{
+			name:           "IPRange",
+			target:         "10.0.1.7/16",
+			wantType:       AssetTypeIPRange,
+			wantNormalized: "10.0.0.0/16",
+		},
+		{
+			name:           "WebAddress",
+			target:         "HTTPS://Example.com:443",
+			wantType:       AssetTypeWebAddress,
+			wantNormalized: "https://example.com/",
+		},
+		{
+			name:           "WebAddressWithPathAndQuery",
+			target:         "http://example.com:8080/a%20b?x=1#frag",
+			wantType:       AssetTypeWebAddress,
+			wantNormalized: "http://example.com:8080/a%20b?x=1",
+		},
+		{
+			name:           "GitRepositoryHTTPS",
+			target:         "https://github.com/adevinta/vulcan-report.git",
+			wantType:       AssetTypeGitRepository,
+			wantNormalized: "https://github.com/adevinta/vulcan-report",
+		},
+		{
+			name:           "GitRepositorySCPLike",
+			target:         "git@github.com:adevinta/vulcan-report.git",
+			wantType:       AssetTypeGitRepository,
+			wantNormalized: "https://github.com/adevinta/vulcan-report",
+		},
+		{
+			name:           "DockerImageOfficial",
+			target:         "nginx",
+			wantType:       AssetTypeHostname,
+			wantNormalized: "nginx",
+		},
+		{
+			name:           "DockerImageOfficialWithTag",
+			target:         "nginx:1.25",
+			wantType:       AssetTypeDockerImage,
+			wantNormalized: "docker.io/library/nginx:1.25",
+		},
+		{
+			name:           "DockerImageWithRegistryPort",
+			target:         "registry.example.com:5000/team/app",
+			wantType:       AssetTypeDockerImage,
+			wantNormalized: "registry.example.com:5000/team/app:latest",
+		},
+		{
+			name:           "DockerImageWithDigest",
+			target:         "team/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			wantType:       AssetTypeDockerImage,
+			wantNormalized: "docker.io/team/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		},
+		{
+			name:           "AWSAccountID",
+			target:         "123456789012",
+			wantType:       AssetTypeAWSAccount,
+			wantNormalized: "arn:aws:iam::123456789012:root",
+		},
+		{
+			name:           "AWSAccountARN",
+			target:         "arn:aws:iam::123456789012:root",
+			wantType:       AssetTypeAWSAccount,
+			wantNormalized: "arn:aws:iam::123456789012:root",
+		},
+		{
+			name:    "InvalidAWSAccountARN",
+			target:  "arn:aws:iam::1234:root",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidHostname",
+			target:  "exa mple.com",
+			wantErr: true,
+		},
+		{
+			name:    "UnsupportedScheme",
+			target:  "ftp://example.com",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidImageRepository",
+			target:  "Team/App:latest",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidPort",
+			target:  "example.com:70000",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := ParseTarget(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: have: %v - want error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if target.Type != tt.wantType {
+				t.Errorf("asset type does not match: have: %s - want: %s", target.Type, tt.wantType)
+			}
+			if target.Normalized() != tt.wantNormalized {
+				t.Errorf("normalized target does not match: have: %s - want: %s", target.Normalized(), tt.wantNormalized)
+			}
+		})
+	}
+}
+
+func TestTargetEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"SameHostDifferentCase", "Example.COM", "example.com", true},
+		{"DefaultPort", "https://example.com:443/", "https://example.com", true},
+		{"DifferentScheme", "http://example.com", "https://example.com", false},
+		{"DefaultRegistry", "nginx:latest", "docker.io/library/nginx:latest", true},
+		{"GitProtocols", "ssh://git@github.com/org/repo.git", "https://github.com/org/repo.git", true},
+		{"GitDefaultPort", "ssh://git@github.com:22/org/repo.git", "https://github.com:443/org/repo.git", true},
+		{"GitExplicitPort", "https://git.example.com:8443/repo.git", "https://git.example.com/repo.git", false},
+		{"GitSSHExplicitPort", "ssh://git@git.example.com:2222/repo.git", "ssh://git@git.example.com/repo.git", false},
+		{"AccountIDAndARN", "123456789012", "arn:aws:iam::123456789012:root", true},
+		{"DifferentTypes", "10.0.0.1", "10.0.0.1/32", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ParseTarget(tt.a)
+			if err != nil {
+				t.Fatalf("unexpected error parsing %q: %s", tt.a, err)
+			}
+			b, err := ParseTarget(tt.b)
+			if err != nil {
+				t.Fatalf("unexpected error parsing %q: %s", tt.b, err)
+			}
+			if a.Equal(b) != tt.want {
+				t.Errorf("unexpected equality of %s and %s: have: %v - want: %v", a.Normalized(), b.Normalized(), !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportTarget(t *testing.T) {
+	r := Report{CheckData: cd0}
+	r.Target = "https://"
+	if err := ValidateReport(r); err != nil {
+		t.Errorf("unexpected error without target validation: %v", err)
+	}
+	err := ValidateReportWithOptions(r, ValidationOptions{ValidateTarget: true})
+	if want := `invalid target "https://": missing host`; err == nil || err.Error() != want {
+		t.Errorf("unexpected error with target validation: have: %v - want: %s", err, want)
+	}
+	r.Target = "https://example.com"
+	if err := ValidateReportWithOptions(r, ValidationOptions{ValidateTarget: true}); err != nil {
+		t.Errorf("unexpected error with target validation: %v", err)
+	}
+}
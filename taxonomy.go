@@ -0,0 +1,292 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// TaxonomyOWASPTop10 is the OWASP Top 10 Web Application Security Risks.
+	TaxonomyOWASPTop10 = "OWASP Top 10"
+	// TaxonomyCWETop25 is the CWE Top 25 Most Dangerous Software Weaknesses.
+	TaxonomyCWETop25 = "CWE Top 25"
+)
+
+// TaxonomyEdition is a published edition of a taxonomy that groups
+// weaknesses in categories.
+type TaxonomyEdition struct {
+	Taxonomy   string
+	Version    string
+	Published  time.Time
+	Categories []TaxonomyCategory
+}
+
+// TaxonomyCategory is a category of a taxonomy edition and the CWEs mapped
+// to it.
+type TaxonomyCategory struct {
+	ID   string
+	Name string
+	CWEs []uint32
+}
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// taxonomyEditions contains the known editions of every taxonomy sorted by
+// publication date.
+var taxonomyEditions = map[string][]TaxonomyEdition{
+	TaxonomyOWASPTop10: {
+		{
+			Taxonomy:  TaxonomyOWASPTop10,
+			Version:   "2017",
+			Published: utcDate(2017, time.November, 20),
+			Categories: []TaxonomyCategory{
+				{ID: "A1:2017", Name: "Injection", CWEs: []uint32{77, 89, 564, 917}},
+				{ID: "A2:2017", Name: "Broken Authentication", CWEs: []uint32{287, 384, 798}},
+				{ID: "A3:2017", Name: "Sensitive Data Exposure", CWEs: []uint32{220, 310, 311, 312, 319, 326, 327, 359}},
+				{ID: "A4:2017", Name: "XML External Entities (XXE)", CWEs: []uint32{611}},
+				{ID: "A5:2017", Name: "Broken Access Control", CWEs: []uint32{22, 284, 285, 639}},
+				{ID: "A6:2017", Name: "Security Misconfiguration", CWEs: []uint32{2, 16, 388}},
+				{ID: "A7:2017", Name: "Cross-Site Scripting (XSS)", CWEs: []uint32{79}},
+				{ID: "A8:2017", Name: "Insecure Deserialization", CWEs: []uint32{502}},
+				{ID: "A9:2017", Name: "Using Components with Known Vulnerabilities", CWEs: []uint32{937, 1035, 1104}},
+				{ID: "A10:2017", Name: "Insufficient Logging & Monitoring", CWEs: []uint32{223, 778}},
+			},
+		},
+		{
+			Taxonomy:  TaxonomyOWASPTop10,
+			Version:   "2021",
+			Published: utcDate(2021, time.September, 24),
+			Categories: []TaxonomyCategory{
+				{ID: "A01:2021", Name: "Broken Access Control", CWEs: []uint32{
+					22, 23, 35, 59, 200, 201, 219, 264, 275, 276, 284, 285, 352, 359, 377, 402, 425, 441, 497,
+					538, 540, 548, 552, 566, 601, 639, 651, 668, 706, 862, 863, 913, 922, 1275,
+				}},
+				{ID: "A02:2021", Name: "Cryptographic Failures", CWEs: []uint32{
+					261, 296, 310, 319, 321, 322, 323, 324, 325, 326, 327, 328, 329, 330, 331, 335, 336, 337,
+					338, 340, 347, 523, 720, 757, 759, 760, 780, 818, 916,
+				}},
+				{ID: "A03:2021", Name: "Injection", CWEs: []uint32{
+					20, 74, 75, 77, 78, 79, 80, 83, 87, 88, 89, 90, 91, 93, 94, 95, 96, 97, 98, 99, 100, 113,
+					116, 138, 184, 470, 471, 564, 610, 643, 644, 652, 917,
+				}},
+				{ID: "A04:2021", Name: "Insecure Design", CWEs: []uint32{
+					73, 183, 209, 213, 235, 256, 257, 266, 269, 280, 311, 312, 313, 316, 419, 430, 434, 444,
+					451, 472, 501, 522, 525, 539, 579, 598, 602, 642, 646, 650, 653, 656, 657, 799, 807, 840,
+					841, 927, 1021, 1173,
+				}},
+				{ID: "A05:2021", Name: "Security Misconfiguration", CWEs: []uint32{
+					2, 11, 13, 15, 16, 260, 315, 520, 526, 537, 541, 547, 611, 614, 756, 776, 942, 1004, 1032,
+					1174,
+				}},
+				{ID: "A06:2021", Name: "Vulnerable and Outdated Components", CWEs: []uint32{937, 1035, 1104}},
+				{ID: "A07:2021", Name: "Identification and Authentication Failures", CWEs: []uint32{
+					255, 259, 287, 288, 290, 294, 295, 297, 300, 302, 304, 306, 307, 346, 384, 521, 613, 620,
+					640, 798, 940, 1216,
+				}},
+				{ID: "A08:2021", Name: "Software and Data Integrity Failures", CWEs: []uint32{
+					345, 353, 426, 494, 502, 565, 784, 829, 830, 915,
+				}},
+				{ID: "A09:2021", Name: "Security Logging and Monitoring Failures", CWEs: []uint32{117, 223, 532, 778}},
+				{ID: "A10:2021", Name: "Server-Side Request Forgery (SSRF)", CWEs: []uint32{918}},
+			},
+		},
+	},
+	TaxonomyCWETop25: {
+		cweTop25Edition("2021", utcDate(2021, time.July, 20), []uint32{
+			787, 79, 125, 20, 78, 89, 416, 22, 352, 434, 306, 190, 502, 287, 476, 798, 119, 862, 276,
+			200, 522, 732, 611, 918, 77,
+		}),
+		cweTop25Edition("2022", utcDate(2022, time.June, 28), []uint32{
+			787, 79, 89, 20, 125, 78, 416, 22, 352, 434, 476, 502, 190, 287, 798, 862, 77, 306, 119,
+			276, 918, 362, 400, 611, 94,
+		}),
+		cweTop25Edition("2023", utcDate(2023, time.June, 29), []uint32{
+			787, 79, 89, 416, 78, 20, 125, 22, 352, 434, 862, 476, 287, 190, 502, 77, 119, 798, 918,
+			306, 362, 269, 94, 863, 276,
+		}),
+		cweTop25Edition("2024", utcDate(2024, time.November, 20), []uint32{
+			79, 787, 89, 352, 22, 125, 78, 416, 862, 434, 94, 20, 77, 287, 269, 502, 200, 863, 918,
+			119, 476, 798, 190, 400, 306,
+		}),
+	},
+}
+
+// cweTop25Edition builds an edition of the CWE Top 25 where every CWE is a
+// category identified by its rank.
+func cweTop25Edition(version string, published time.Time, ranking []uint32) TaxonomyEdition {
+	e := TaxonomyEdition{Taxonomy: TaxonomyCWETop25, Version: version, Published: published}
+	for i, id := range ranking {
+		c, _ := LookupCWE(id)
+		e.Categories = append(e.Categories, TaxonomyCategory{
+			ID:   fmt.Sprintf("#%d", i+1),
+			Name: fmt.Sprintf("CWE-%d: %s", id, c.Name),
+			CWEs: []uint32{id},
+		})
+	}
+	return e
+}
+
+// TaxonomyEditions returns the known editions of a taxonomy sorted by
+// publication date.
+func TaxonomyEditions(taxonomy string) []TaxonomyEdition {
+	return taxonomyEditions[taxonomy]
+}
+
+// TaxonomyEditionAt returns the edition of a taxonomy that was in force at
+// the given time, that is, the last one published before it.
+func TaxonomyEditionAt(taxonomy string, t time.Time) (TaxonomyEdition, bool) {
+	editions := taxonomyEditions[taxonomy]
+	i := sort.Search(len(editions), func(i int) bool { return editions[i].Published.After(t) })
+	if i == 0 {
+		return TaxonomyEdition{}, false
+	}
+	return editions[i-1], true
+}
+
+// LatestTaxonomyEdition returns the last published edition of a taxonomy.
+func LatestTaxonomyEdition(taxonomy string) (TaxonomyEdition, bool) {
+	editions := taxonomyEditions[taxonomy]
+	if len(editions) == 0 {
+		return TaxonomyEdition{}, false
+	}
+	return editions[len(editions)-1], true
+}
+
+// ClassifyCWE returns the categories of the edition a CWE belongs to. When
+// the CWE is not explicitly mapped, the categories of its closest ancestors
+// in the embedded CWE catalog are returned.
+func (e TaxonomyEdition) ClassifyCWE(id uint32) []TaxonomyCategory {
+	if id == 0 {
+		return nil
+	}
+	level := []uint32{id}
+	seen := map[uint32]bool{id: true}
+	for len(level) > 0 {
+		var categories []TaxonomyCategory
+		for _, c := range e.Categories {
+			if containsCWE(c.CWEs, level) {
+				categories = append(categories, c)
+			}
+		}
+		if len(categories) > 0 {
+			return categories
+		}
+		var next []uint32
+		for _, c := range level {
+			for _, p := range CWEParents(c) {
+				if !seen[p.ID] {
+					seen[p.ID] = true
+					next = append(next, p.ID)
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+func containsCWE(cwes []uint32, ids []uint32) bool {
+	for _, c := range cwes {
+		for _, id := range ids {
+			if c == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Classify returns the categories of the edition a vulnerability belongs to
+// according to its CWE-ID. A vulnerability without CWE-ID is classified
+// using the CWE-IDs of its children.
+func (e TaxonomyEdition) Classify(v Vulnerability) []TaxonomyCategory {
+	if v.CWEID != 0 {
+		return e.ClassifyCWE(v.CWEID)
+	}
+	var categories []TaxonomyCategory
+	seen := map[string]bool{}
+	for _, child := range v.Vulnerabilities {
+		for _, c := range e.Classify(child) {
+			if !seen[c.ID] {
+				seen[c.ID] = true
+				categories = append(categories, c)
+			}
+		}
+	}
+	return categories
+}
+
+// TaxonomySummary aggregates the vulnerabilities of a report by the
+// categories of a taxonomy edition.
+type TaxonomySummary struct {
+	Edition      TaxonomyEdition
+	Categories   []CategorySummary // Categories with at least one vulnerability, in edition order.
+	Unclassified int               // Number of vulnerabilities not mapped to any category.
+}
+
+// CategorySummary contains the vulnerabilities of a report that belong to a
+// taxonomy category.
+type CategorySummary struct {
+	Category        TaxonomyCategory
+	Count           int
+	MaxScore        float32
+	Vulnerabilities []Vulnerability
+}
+
+// Summarize aggregates the vulnerabilities of the report by the categories
+// of the edition.
+func (e TaxonomyEdition) Summarize(r Report) TaxonomySummary {
+	s := TaxonomySummary{Edition: e}
+	byID := map[string]*CategorySummary{}
+	for _, v := range r.Vulnerabilities {
+		categories := e.Classify(v)
+		if len(categories) == 0 {
+			s.Unclassified++
+			continue
+		}
+		for _, c := range categories {
+			cs, ok := byID[c.ID]
+			if !ok {
+				cs = &CategorySummary{Category: c}
+				byID[c.ID] = cs
+			}
+			cs.Count++
+			cs.Vulnerabilities = append(cs.Vulnerabilities, v)
+			if v.Score > cs.MaxScore {
+				cs.MaxScore = v.Score
+			}
+		}
+	}
+	for _, c := range e.Categories {
+		if cs, ok := byID[c.ID]; ok {
+			s.Categories = append(s.Categories, *cs)
+		}
+	}
+	return s
+}
+
+// SummarizeByTaxonomy aggregates the vulnerabilities of the report by the
+// categories of the taxonomy edition in force when the check started. If
+// the report has no start time, the latest edition is used.
+func SummarizeByTaxonomy(r Report, taxonomy string) (TaxonomySummary, error) {
+	var (
+		e  TaxonomyEdition
+		ok bool
+	)
+	if r.StartTime.IsZero() {
+		e, ok = LatestTaxonomyEdition(taxonomy)
+	} else {
+		e, ok = TaxonomyEditionAt(taxonomy, r.StartTime)
+	}
+	if !ok {
+		return TaxonomySummary{}, fmt.Errorf("no edition of %s in force at %s", taxonomy, r.StartTime.Format(time.RFC3339))
+	}
+	return e.Summarize(r), nil
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func vulnerabilityWithCWE(score float32, cweID uint32) Vulnerability {
+	v := vulnerabilityWithScore(score)
+	v.CWEID = cweID
+	return v
+}
+
+func categoryIDs(categories []TaxonomyCategory) []string {
+	var ids []string
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestTaxonomyEditionAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		taxonomy    string
+		t           time.Time
+		wantVersion string
+		wantOK      bool
+	}{
+		{"OWASPBeforeFirstEdition", TaxonomyOWASPTop10, utcDate(2016, time.January, 1), "", false},
+		{"OWASP2017", TaxonomyOWASPTop10, utcDate(2019, time.May, 1), "2017", true},
+		{"OWASP2021", TaxonomyOWASPTop10, utcDate(2021, time.September, 24), "2021", true},
+		{"CWETop25_2022", TaxonomyCWETop25, utcDate(2023, time.January, 1), "2022", true},
+		{"UnknownTaxonomy", "unknown", utcDate(2023, time.January, 1), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := TaxonomyEditionAt(tt.taxonomy, tt.t)
+			if ok != tt.wantOK || e.Version != tt.wantVersion {
+				t.Errorf("unexpected edition: have: %s %v - want: %s %v", e.Version, ok, tt.wantVersion, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTaxonomyClassify(t *testing.T) {
+	owasp2017, _ := TaxonomyEditionAt(TaxonomyOWASPTop10, utcDate(2020, time.January, 1))
+	owasp2021, _ := LatestTaxonomyEdition(TaxonomyOWASPTop10)
+	top25, _ := TaxonomyEditionAt(TaxonomyCWETop25, utcDate(2023, time.December, 1))
+
+	tests := []struct {
+		name    string
+		edition TaxonomyEdition
+		v       Vulnerability
+		want    []string
+	}{
+		{"OWASP2021Direct", owasp2021, vulnerabilityWithCWE(8.9, 89), []string{"A03:2021"}},
+		{"OWASP2021SSRF", owasp2021, vulnerabilityWithCWE(8.9, 918), []string{"A10:2021"}},
+		{"OWASP2017Ancestor", owasp2017, vulnerabilityWithCWE(8.9, 78), []string{"A1:2017"}},
+		{"OWASP2017XSS", owasp2017, vulnerabilityWithCWE(6.9, 79), []string{"A7:2017"}},
+		{"NoCWE", owasp2021, vulnerabilityWithScore(3.9), nil},
+		{"Top25Rank", top25, vulnerabilityWithCWE(8.9, 89), []string{"#3"}},
+		{"Top25Ancestor", top25, vulnerabilityWithCWE(8.9, 23), []string{"#8"}},
+		{"Top25NotRanked", top25, vulnerabilityWithCWE(8.9, 614), nil},
+		{
+			name:    "ParentFromChildren",
+			edition: owasp2021,
+			v: Vulnerability{
+				Summary:          "parent",
+				AffectedResource: "example.com",
+				Vulnerabilities: []Vulnerability{
+					vulnerabilityWithCWE(3.9, 79),
+					vulnerabilityWithCWE(3.9, 89),
+					vulnerabilityWithCWE(3.9, 614),
+				},
+			},
+			want: []string{"A03:2021", "A05:2021"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := categoryIDs(tt.edition.Classify(tt.v))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected categories: have: %v - want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeByTaxonomy(t *testing.T) {
+	r := Report{
+		CheckData: cd0,
+		ResultData: ResultData{
+			Vulnerabilities: []Vulnerability{
+				vulnerabilityWithCWE(8.9, 89),
+				vulnerabilityWithCWE(6.9, 79),
+				vulnerabilityWithCWE(3.9, 22),
+				vulnerabilityWithCWE(3.9, 352),
+				vulnerabilityWithScore(0),
+			},
+		},
+	}
+
+	s, err := SummarizeByTaxonomy(r, TaxonomyOWASPTop10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.Edition.Version != "2017" {
+		t.Errorf("unexpected edition for a report from 2021-05-18: %s", s.Edition.Version)
+	}
+	var got []string
+	for _, c := range s.Categories {
+		got = append(got, c.Category.ID)
+	}
+	want := []string{"A1:2017", "A5:2017", "A7:2017"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected categories: have: %v - want: %v", got, want)
+	}
+	if s.Categories[0].MaxScore != 8.9 || s.Categories[1].Count != 1 {
+		t.Errorf("unexpected category summaries: %+v", s.Categories)
+	}
+	if s.Unclassified != 2 {
+		t.Errorf("unexpected unclassified count: have: %d - want: 2", s.Unclassified)
+	}
+
+	r.StartTime = utcDate(2010, time.January, 1)
+	if _, err := SummarizeByTaxonomy(r, TaxonomyOWASPTop10); err == nil {
+		t.Errorf("expected error for a report before the first edition")
+	}
+}
+
+func TestCWETop25EditionsInCatalog(t *testing.T) {
+	for _, e := range TaxonomyEditions(TaxonomyCWETop25) {
+		if len(e.Categories) != 25 {
+			t.Errorf("edition %s has %d categories", e.Version, len(e.Categories))
+		}
+		for _, c := range e.Categories {
+			if _, ok := LookupCWE(c.CWEs[0]); !ok {
+				t.Errorf("CWE-%d of edition %s is missing from the catalog", c.CWEs[0], e.Version)
+			}
+		}
+	}
+}
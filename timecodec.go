@@ -0,0 +1,166 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTimePrecisionLoss is reported when encoding a time drops part of its precision.
+	ErrTimePrecisionLoss = errors.New("time precision is lost")
+	// ErrTimeZoneLoss is reported when encoding a time drops its time zone.
+	ErrTimeZoneLoss = errors.New("time zone is lost")
+)
+
+// TimeCodec defines how the times of a Report are encoded as strings.
+type TimeCodec struct {
+	// Layout used to format and parse the times, as defined by the time package.
+	Layout string
+	// Location the times are converted to before formatting, and assumed
+	// when parsing a time without zone information. Nil means keeping the
+	// location of the time when formatting and UTC when parsing.
+	Location *time.Location
+	// Precision the times are truncated to before formatting. Zero means no
+	// truncation.
+	Precision time.Duration
+}
+
+var (
+	// LegacyTimeCodec is the codec compatible with Athena and Rails. It
+	// encodes times in UTC with second precision and without zone.
+	LegacyTimeCodec = TimeCodec{Layout: layout, Location: time.UTC, Precision: time.Second}
+	// RFC3339TimeCodec encodes times as RFC 3339 strings without losing
+	// precision nor time zone.
+	RFC3339TimeCodec = TimeCodec{Layout: time.RFC3339Nano}
+)
+
+// Format returns the string representation of t.
+func (c TimeCodec) Format(t time.Time) string {
+	if c.Location != nil {
+		t = t.In(c.Location)
+	}
+	if c.Precision > 0 {
+		t = t.Truncate(c.Precision)
+	}
+	return t.Format(c.Layout)
+}
+
+// Parse parses a time formatted by the codec.
+func (c TimeCodec) Parse(s string) (time.Time, error) {
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.ParseInLocation(c.Layout, s, loc)
+}
+
+// CheckRoundTrip returns an error wrapping ErrTimeZoneLoss or
+// ErrTimePrecisionLoss if formatting and parsing t back with the codec does
+// not return the same time. The time zone is lost if the decoded time has
+// another offset or, when t has a named location like "Europe/Madrid",
+// another location. The precision is lost if the decoded time has another
+// clock time than t in the location of the codec, because of the precision
+// of the codec or the resolution of its layout.
+func (c TimeCodec) CheckRoundTrip(t time.Time) error {
+	rt, err := c.Parse(c.Format(t))
+	if err != nil {
+		return err
+	}
+	if zoneLost(t, rt) {
+		return fmt.Errorf("%w: %s is decoded as %s", ErrTimeZoneLoss, t.Format(time.RFC3339Nano), rt.Format(time.RFC3339Nano))
+	}
+	encoded := t
+	if c.Location != nil {
+		encoded = t.In(c.Location)
+	}
+	if encoded.Format(wallClockLayout) != rt.Format(wallClockLayout) {
+		return fmt.Errorf("%w: %s is encoded as %s", ErrTimePrecisionLoss, t.Format(time.RFC3339Nano), rt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+// wallClockLayout formats the date and clock time of a time with full
+// precision and without zone.
+const wallClockLayout = "2006-01-02T15:04:05.999999999"
+
+// zoneLost returns true if rt has not the time zone of t: its offset and,
+// if t has a named location, its location, as the offset does not define
+// the daylight saving time rules. Fixed zones and the local location are
+// compared only by offset.
+func zoneLost(t, rt time.Time) bool {
+	name, offset := t.Zone()
+	_, rtOffset := rt.Zone()
+	if offset != rtOffset {
+		return true
+	}
+	loc := t.Location()
+	named := loc != time.Local && loc.String() != name
+	return named && rt.Location().String() != loc.String()
+}
+
+// RoundTripWarnings returns the information that would be lost when
+// encoding the times of the report with the codec. The warnings do not
+// prevent the report from being encoded.
+func (c TimeCodec) RoundTripWarnings(r Report) []error {
+	var warnings []error
+	if err := c.CheckRoundTrip(r.StartTime); err != nil {
+		warnings = append(warnings, fmt.Errorf("start time: %w", err))
+	}
+	if err := c.CheckRoundTrip(r.EndTime); err != nil {
+		warnings = append(warnings, fmt.Errorf("end time: %w", err))
+	}
+	return warnings
+}
+
+// MarshalJSONWithTimeCodec marshals a Report to JSON encoding the times as
+// strings with the given codec.
+func (r *Report) MarshalJSONWithTimeCodec(c TimeCodec) ([]byte, error) {
+	return json.Marshal(struct {
+		Report
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+	}{
+		Report:    *r,
+		StartTime: c.Format(r.StartTime),
+		EndTime:   c.Format(r.EndTime),
+	})
+}
+
+// UnmarshalJSONWithTimeCodec unmarshals a JSON to a Report decoding the
+// times from strings with the given codec.
+func (r *Report) UnmarshalJSONWithTimeCodec(data []byte, c TimeCodec) error {
+	aux := &struct {
+		*Report
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+	}{
+		Report: r,
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.StartTime) > 0 {
+		startTime, err := c.Parse(aux.StartTime)
+		if err != nil {
+			return err
+		}
+		r.StartTime = startTime
+	}
+
+	if len(aux.EndTime) > 0 {
+		endTime, err := c.Parse(aux.EndTime)
+		if err != nil {
+			return err
+		}
+		r.EndTime = endTime
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeCodecRoundTrip(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*60*60)
+	tests := []struct {
+		name    string
+		codec   TimeCodec
+		t       time.Time
+		wantErr error
+	}{
+		{
+			name:  "LegacyUTC",
+			codec: LegacyTimeCodec,
+			t:     time.Date(2021, 5, 18, 13, 30, 15, 0, time.UTC),
+		},
+		{
+			name:    "LegacySubSecond",
+			codec:   LegacyTimeCodec,
+			t:       time.Date(2021, 5, 18, 13, 30, 15, 500, time.UTC),
+			wantErr: ErrTimePrecisionLoss,
+		},
+		{
+			name:    "LegacyNonUTC",
+			codec:   LegacyTimeCodec,
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 0, madrid),
+			wantErr: ErrTimeZoneLoss,
+		},
+		{
+			name:    "LayoutWithoutZoneKeepingLocation",
+			codec:   TimeCodec{Layout: layout},
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 0, madrid),
+			wantErr: ErrTimeZoneLoss,
+		},
+		{
+			name:  "RFC3339NonUTC",
+			codec: RFC3339TimeCodec,
+			t:     time.Date(2021, 5, 18, 15, 30, 15, 123456789, madrid),
+		},
+		{
+			name:    "RFC3339WithPrecision",
+			codec:   TimeCodec{Layout: time.RFC3339Nano, Precision: time.Millisecond},
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 123456789, madrid),
+			wantErr: ErrTimePrecisionLoss,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.codec.CheckRoundTrip(tt.t)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("unexpected round trip error: have: %v - want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONTimeAsStringNonUTC(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*60*60)
+	r := Report{CheckData: cd0}
+	r.StartTime = r.StartTime.In(madrid)
+	r.EndTime = r.EndTime.In(madrid)
+
+	b, err := r.MarshalJSONTimeAsString()
+	if err != nil {
+		t.Fatalf("unexpected error with MarshalJSONTimeAsString: %s", err)
+	}
+	var got Report
+	if err := got.UnmarshalJSONTimeAsString(b); err != nil {
+		t.Fatalf("unexpected error with UnmarshalJSONTimeAsString: %s", err)
+	}
+	if !got.StartTime.Equal(r.StartTime) || !got.EndTime.Equal(r.EndTime) {
+		t.Errorf("times changed in round trip: have: %s-%s want: %s-%s", got.StartTime, got.EndTime, r.StartTime, r.EndTime)
+	}
+}
+
+func TestMarshalJSONWithRFC3339TimeCodec(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*60*60)
+	r := Report{CheckData: cd0}
+	r.StartTime = time.Date(2021, 5, 18, 15, 30, 15, 123456789, madrid)
+
+	if warnings := RFC3339TimeCodec.RoundTripWarnings(r); len(warnings) > 0 {
+		t.Errorf("unexpected round trip warnings: %v", warnings)
+	}
+	if warnings := LegacyTimeCodec.RoundTripWarnings(r); len(warnings) != 1 {
+		t.Errorf("unexpected number of round trip warnings: have: %d - want: 1", len(warnings))
+	}
+
+	b, err := r.MarshalJSONWithTimeCodec(RFC3339TimeCodec)
+	if err != nil {
+		t.Fatalf("unexpected error with MarshalJSONWithTimeCodec: %s", err)
+	}
+	var got Report
+	if err := got.UnmarshalJSONWithTimeCodec(b, RFC3339TimeCodec); err != nil {
+		t.Fatalf("unexpected error with UnmarshalJSONWithTimeCodec: %s", err)
+	}
+	if !got.StartTime.Equal(r.StartTime) || got.StartTime.Format(time.RFC3339Nano) != r.StartTime.Format(time.RFC3339Nano) {
+		t.Errorf("start time changed in round trip: have: %s - want: %s", got.StartTime, r.StartTime)
+	}
+}
+
+func TestTimeCodecCheckRoundTrip(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*60*60)
+	minuteCodec := TimeCodec{Layout: time.RFC3339, Precision: time.Minute}
+	noSecondsCodec := TimeCodec{Layout: "2006-01-02 15:04", Location: time.UTC}
+	tests := []struct {
+		name    string
+		c       TimeCodec
+		t       time.Time
+		wantErr error
+	}{
+		{
+			name: "rfc3339",
+			c:    RFC3339TimeCodec,
+			t:    time.Date(2021, 5, 18, 15, 30, 15, 123456789, madrid),
+		},
+		{
+			name:    "minute precision",
+			c:       minuteCodec,
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 0, time.UTC),
+			wantErr: ErrTimePrecisionLoss,
+		},
+		{
+			name: "minute precision without seconds",
+			c:    minuteCodec,
+			t:    time.Date(2021, 5, 18, 15, 30, 0, 0, madrid),
+		},
+		{
+			name:    "layout resolution",
+			c:       noSecondsCodec,
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 0, time.UTC),
+			wantErr: ErrTimePrecisionLoss,
+		},
+		{
+			name:    "legacy with subseconds",
+			c:       LegacyTimeCodec,
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 500000000, time.UTC),
+			wantErr: ErrTimePrecisionLoss,
+		},
+		{
+			name:    "legacy with zone",
+			c:       LegacyTimeCodec,
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 0, madrid),
+			wantErr: ErrTimeZoneLoss,
+		},
+		{
+			name:    "layout without zone",
+			c:       TimeCodec{Layout: layout},
+			t:       time.Date(2021, 5, 18, 15, 30, 15, 0, madrid),
+			wantErr: ErrTimeZoneLoss,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.c.CheckRoundTrip(tt.t)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: have: %v - want: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("named location", func(t *testing.T) {
+		loc, err := time.LoadLocation("Europe/Madrid")
+		if err != nil {
+			t.Skipf("time zone database not available: %v", err)
+		}
+		err = RFC3339TimeCodec.CheckRoundTrip(time.Date(2021, 5, 18, 15, 30, 15, 0, loc))
+		if !errors.Is(err, ErrTimeZoneLoss) {
+			t.Errorf("unexpected error: have: %v - want: %v", err, ErrTimeZoneLoss)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Statuses of a check.
+const (
+	StatusCreated      = "CREATED"
+	StatusQueued       = "QUEUED"
+	StatusAssigned     = "ASSIGNED"
+	StatusRunning      = "RUNNING"
+	StatusPurging      = "PURGING"
+	StatusMalformed    = "MALFORMED"
+	StatusAborted      = "ABORTED"
+	StatusKilled       = "KILLED"
+	StatusFailed       = "FAILED"
+	StatusFinished     = "FINISHED"
+	StatusTimeout      = "TIMEOUT"
+	StatusInconclusive = "INCONCLUSIVE"
+)
+
+// finalStatuses are the statuses of the checks that are not running anymore.
+var finalStatuses = map[string]bool{
+	StatusMalformed:    true,
+	StatusAborted:      true,
+	StatusKilled:       true,
+	StatusFailed:       true,
+	StatusFinished:     true,
+	StatusTimeout:      true,
+	StatusInconclusive: true,
+}
+
+// runningStatuses are the statuses of the checks that have not finished.
+var runningStatuses = map[string]bool{
+	StatusCreated:  true,
+	StatusQueued:   true,
+	StatusAssigned: true,
+	StatusRunning:  true,
+	StatusPurging:  true,
+}
+
+// IsFinalStatus returns true if the status is the one of a check that is
+// not running anymore.
+func IsFinalStatus(status string) bool {
+	return finalStatuses[status]
+}
+
+// Duration returns the time the check took to run. It returns false if the
+// start or the end time are missing or the end time is before the start
+// time.
+func (c CheckData) Duration() (time.Duration, bool) {
+	if c.StartTime.IsZero() || c.EndTime.IsZero() || c.EndTime.Before(c.StartTime) {
+		return 0, false
+	}
+	return c.EndTime.Sub(c.StartTime), true
+}
+
+// validateTiming checks the end time of a report is not before its start
+// time and, if enabled in the options, the presence of the end time
+// according to the status and the plausibility of the times.
+func validateTiming(r Report, opts ValidationOptions) error {
+	if !r.EndTime.IsZero() && r.EndTime.Before(r.StartTime) {
+		return fmt.Errorf("report end time %s is before start time %s", r.EndTime.Format(time.RFC3339), r.StartTime.Format(time.RFC3339))
+	}
+	if opts.ValidateEndTime {
+		if finalStatuses[r.Status] && r.EndTime.IsZero() {
+			return fmt.Errorf("report with status %s is missing end time", r.Status)
+		}
+		if runningStatuses[r.Status] && !r.EndTime.IsZero() {
+			return fmt.Errorf("report with status %s has end time", r.Status)
+		}
+	}
+
+	now := time.Now()
+	if opts.Now != nil {
+		now = opts.Now()
+	}
+	if opts.MaxClockSkew > 0 {
+		limit := now.Add(opts.MaxClockSkew)
+		if r.StartTime.After(limit) {
+			return fmt.Errorf("report start time %s is in the future", r.StartTime.Format(time.RFC3339))
+		}
+		if r.EndTime.After(limit) {
+			return fmt.Errorf("report end time %s is in the future", r.EndTime.Format(time.RFC3339))
+		}
+	}
+	if opts.MaxAge > 0 && r.StartTime.Before(now.Add(-opts.MaxAge)) {
+		return fmt.Errorf("report start time %s is older than %s", r.StartTime.Format(time.RFC3339), opts.MaxAge)
+	}
+	if d, ok := r.Duration(); ok && opts.MaxDuration > 0 && d > opts.MaxDuration {
+		return fmt.Errorf("report duration %s exceeds the limit of %s", d, opts.MaxDuration)
+	}
+	return nil
+}
+
+// RuntimeStats contains statistics about the time the checks of a checktype
+// took to run.
+type RuntimeStats struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Max   time.Duration `json:"max"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+}
+
+// RuntimeStatsByChecktype returns the runtime statistics of the reports per
+// checktype name. The reports without a duration are ignored. The
+// percentiles are computed using the nearest-rank method.
+func RuntimeStatsByChecktype(reports []Report) map[string]RuntimeStats {
+	durations := map[string][]time.Duration{}
+	for _, r := range reports {
+		if d, ok := r.Duration(); ok {
+			durations[r.ChecktypeName] = append(durations[r.ChecktypeName], d)
+		}
+	}
+	stats := map[string]RuntimeStats{}
+	for checktype, ds := range durations {
+		stats[checktype] = runtimeStats(ds)
+	}
+	return stats
+}
+
+func runtimeStats(ds []time.Duration) RuntimeStats {
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	var sum time.Duration
+	for _, d := range ds {
+		sum += d
+	}
+	return RuntimeStats{
+		Count: len(ds),
+		Min:   ds[0],
+		Max:   ds[len(ds)-1],
+		Mean:  sum / time.Duration(len(ds)),
+		P50:   percentile(ds, 50),
+		P95:   percentile(ds, 95),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,171 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	start := mustConvertStrToDateTime(st)
+	end := mustConvertStrToDateTime(et)
+	tests := []struct {
+		name   string
+		c      CheckData
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "finished", c: CheckData{StartTime: start, EndTime: end}, want: 30*time.Minute + 35*time.Second, wantOK: true},
+		{name: "same time", c: CheckData{StartTime: start, EndTime: start}, want: 0, wantOK: true},
+		{name: "missing end time", c: CheckData{StartTime: start}},
+		{name: "missing start time", c: CheckData{EndTime: end}},
+		{name: "end before start", c: CheckData{StartTime: end, EndTime: start}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.c.Duration()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateReportTiming(t *testing.T) {
+	now := mustConvertStrToDateTime(et).Add(time.Hour)
+	clock := func() time.Time { return now }
+	report := func(status string, start, end time.Time) Report {
+		c := cd0
+		c.Status, c.StartTime, c.EndTime = status, start, end
+		return Report{CheckData: c}
+	}
+	start := mustConvertStrToDateTime(st)
+	end := mustConvertStrToDateTime(et)
+
+	tests := []struct {
+		name      string
+		r         Report
+		opts      ValidationOptions
+		errString string
+	}{
+		{
+			name: "valid",
+			r:    report(StatusFinished, start, end),
+			opts: ValidationOptions{ValidateEndTime: true, MaxClockSkew: time.Minute, MaxAge: 24 * time.Hour, MaxDuration: time.Hour, Now: clock},
+		},
+		{
+			name:      "end before start",
+			r:         report(StatusFinished, end, start),
+			errString: "report end time 2021-05-18T13:30:15Z is before start time 2021-05-18T14:00:50Z",
+		},
+		{
+			name: "missing end time not verified",
+			r:    report(StatusFinished, start, time.Time{}),
+		},
+		{
+			name:      "missing end time",
+			r:         report(StatusFailed, start, time.Time{}),
+			opts:      ValidationOptions{ValidateEndTime: true},
+			errString: "report with status FAILED is missing end time",
+		},
+		{
+			name:      "running with end time",
+			r:         report(StatusRunning, start, end),
+			opts:      ValidationOptions{ValidateEndTime: true},
+			errString: "report with status RUNNING has end time",
+		},
+		{
+			name: "running without end time",
+			r:    report(StatusRunning, start, time.Time{}),
+			opts: ValidationOptions{ValidateEndTime: true},
+		},
+		{
+			name: "unknown status",
+			r:    report("UNKNOWN", start, time.Time{}),
+			opts: ValidationOptions{ValidateEndTime: true},
+		},
+		{
+			name:      "start time in the future",
+			r:         report(StatusRunning, now.Add(time.Hour), time.Time{}),
+			opts:      ValidationOptions{MaxClockSkew: time.Minute, Now: clock},
+			errString: "report start time 2021-05-18T16:00:50Z is in the future",
+		},
+		{
+			name:      "end time in the future",
+			r:         report(StatusFinished, start, now.Add(time.Hour)),
+			opts:      ValidationOptions{MaxClockSkew: time.Minute, Now: clock},
+			errString: "report end time 2021-05-18T16:00:50Z is in the future",
+		},
+		{
+			name: "end time within the clock skew",
+			r:    report(StatusFinished, start, now.Add(time.Second)),
+			opts: ValidationOptions{MaxClockSkew: time.Minute, Now: clock},
+		},
+		{
+			name:      "too old",
+			r:         report(StatusFinished, start, end),
+			opts:      ValidationOptions{MaxAge: time.Hour, Now: clock},
+			errString: "report start time 2021-05-18T13:30:15Z is older than 1h0m0s",
+		},
+		{
+			name:      "too long",
+			r:         report(StatusFinished, start, end),
+			opts:      ValidationOptions{MaxDuration: 30 * time.Minute},
+			errString: "report duration 30m35s exceeds the limit of 30m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateReportWithOptions(tt.r, tt.opts)
+			errString := ""
+			if err != nil {
+				errString = err.Error()
+			}
+			if errString != tt.errString {
+				t.Errorf("got error %q, want %q", errString, tt.errString)
+			}
+		})
+	}
+}
+
+func TestRuntimeStatsByChecktype(t *testing.T) {
+	start := mustConvertStrToDateTime(st)
+	report := func(checktype string, d time.Duration) Report {
+		return Report{CheckData: CheckData{ChecktypeName: checktype, StartTime: start, EndTime: start.Add(d)}}
+	}
+	var reports []Report
+	for i := 1; i <= 20; i++ {
+		reports = append(reports, report("vulcan-nuclei", time.Duration(21-i)*time.Minute))
+	}
+	reports = append(reports,
+		report("vulcan-tls", 10*time.Second),
+		report("vulcan-tls", 30*time.Second),
+		Report{CheckData: CheckData{ChecktypeName: "vulcan-tls", StartTime: start}},
+	)
+
+	got := RuntimeStatsByChecktype(reports)
+	want := map[string]RuntimeStats{
+		"vulcan-nuclei": {
+			Count: 20,
+			Min:   time.Minute,
+			Max:   20 * time.Minute,
+			Mean:  10*time.Minute + 30*time.Second,
+			P50:   10 * time.Minute,
+			P95:   19 * time.Minute,
+		},
+		"vulcan-tls": {
+			Count: 2,
+			Min:   10 * time.Second,
+			Max:   30 * time.Second,
+			Mean:  20 * time.Second,
+			P50:   10 * time.Second,
+			P95:   30 * time.Second,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := RuntimeStatsByChecktype(nil); len(got) != 0 {
+		t.Errorf("got %+v for no reports, want none", got)
+	}
+}
@@ -0,0 +1,514 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package trivy imports the JSON results of Trivy scans as Vulcan
+// vulnerabilities.
+package trivy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// Severities reported by Trivy.
+const (
+	SeverityUnknown  = "UNKNOWN"
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// Labels added to the imported vulnerabilities.
+const (
+	LabelTrivy            = "trivy"
+	LabelVulnerability    = "vulnerability"
+	LabelMisconfiguration = "misconfiguration"
+	LabelSecret           = "secret"
+)
+
+// cweHardcodedCredentials is the CWE of exposed secrets.
+const cweHardcodedCredentials = 798
+
+// Report is the JSON result of a Trivy scan.
+type Report struct {
+	SchemaVersion int      `json:"SchemaVersion"`
+	ArtifactName  string   `json:"ArtifactName"`
+	ArtifactType  string   `json:"ArtifactType"`
+	Results       []Result `json:"Results"`
+}
+
+// Result contains the findings of a scanned target, e.g.: the OS packages of
+// an image or a lock file.
+type Result struct {
+	Target            string                  `json:"Target"`
+	Class             string                  `json:"Class"`
+	Type              string                  `json:"Type"`
+	Vulnerabilities   []DetectedVulnerability `json:"Vulnerabilities"`
+	Misconfigurations []Misconfiguration      `json:"Misconfigurations"`
+	Secrets           []Secret                `json:"Secrets"`
+}
+
+// DetectedVulnerability is a vulnerability of a package.
+type DetectedVulnerability struct {
+	VulnerabilityID  string          `json:"VulnerabilityID"`
+	PkgID            string          `json:"PkgID"`
+	PkgName          string          `json:"PkgName"`
+	PkgPath          string          `json:"PkgPath"`
+	PkgIdentifier    PkgIdentifier   `json:"PkgIdentifier"`
+	InstalledVersion string          `json:"InstalledVersion"`
+	FixedVersion     string          `json:"FixedVersion"`
+	SeveritySource   string          `json:"SeveritySource"`
+	PrimaryURL       string          `json:"PrimaryURL"`
+	Title            string          `json:"Title"`
+	Description      string          `json:"Description"`
+	Severity         string          `json:"Severity"`
+	CweIDs           []string        `json:"CweIDs"`
+	CVSS             map[string]CVSS `json:"CVSS"`
+	References       []string        `json:"References"`
+}
+
+// PkgIdentifier identifies a package.
+type PkgIdentifier struct {
+	PURL string `json:"PURL"`
+}
+
+// CVSS contains the CVSS data of a vulnerability provided by a source.
+type CVSS struct {
+	V2Vector string  `json:"V2Vector"`
+	V3Vector string  `json:"V3Vector"`
+	V2Score  float32 `json:"V2Score"`
+	V3Score  float32 `json:"V3Score"`
+}
+
+// Misconfiguration is a failed check of a configuration file.
+type Misconfiguration struct {
+	Type          string        `json:"Type"`
+	ID            string        `json:"ID"`
+	AVDID         string        `json:"AVDID"`
+	Title         string        `json:"Title"`
+	Description   string        `json:"Description"`
+	Message       string        `json:"Message"`
+	Resolution    string        `json:"Resolution"`
+	Severity      string        `json:"Severity"`
+	PrimaryURL    string        `json:"PrimaryURL"`
+	References    []string      `json:"References"`
+	Status        string        `json:"Status"`
+	CauseMetadata CauseMetadata `json:"CauseMetadata"`
+}
+
+// CauseMetadata locates the cause of a misconfiguration.
+type CauseMetadata struct {
+	Resource  string `json:"Resource"`
+	Provider  string `json:"Provider"`
+	Service   string `json:"Service"`
+	StartLine int    `json:"StartLine"`
+	EndLine   int    `json:"EndLine"`
+}
+
+// Secret is a secret found in a file.
+type Secret struct {
+	RuleID    string `json:"RuleID"`
+	Category  string `json:"Category"`
+	Severity  string `json:"Severity"`
+	Title     string `json:"Title"`
+	StartLine int    `json:"StartLine"`
+	EndLine   int    `json:"EndLine"`
+	Match     string `json:"Match"`
+}
+
+// Decode reads the JSON result of a Trivy scan.
+func Decode(r io.Reader) (Report, error) {
+	var tr Report
+	if err := json.NewDecoder(r).Decode(&tr); err != nil {
+		return Report{}, fmt.Errorf("invalid trivy report: %w", err)
+	}
+	if tr.SchemaVersion != 0 && tr.SchemaVersion != 2 {
+		return Report{}, fmt.Errorf("unsupported trivy schema version %d", tr.SchemaVersion)
+	}
+	return tr, nil
+}
+
+// Import reads the JSON result of a Trivy scan and converts it to Vulcan
+// vulnerabilities, see Convert.
+func Import(r io.Reader) ([]report.Vulnerability, error) {
+	tr, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(tr), nil
+}
+
+// Score returns the score of a Trivy severity. Vulnerabilities with CVSS
+// data are scored according to it instead, see VulnerabilityScore.
+func Score(severity string) float32 {
+	switch strings.ToUpper(severity) {
+	case SeverityLow:
+		return report.ScoreSeverity(report.SeverityLow)
+	case SeverityMedium:
+		return report.ScoreSeverity(report.SeverityMedium)
+	case SeverityHigh:
+		return report.ScoreSeverity(report.SeverityHigh)
+	case SeverityCritical:
+		return report.ScoreSeverity(report.SeverityCritical)
+	}
+	return report.ScoreSeverity(report.SeverityNone)
+}
+
+// VulnerabilityScore returns the score of a vulnerability and the CVSS
+// vector it comes from. CVSS v3 data is preferred over v2, and the data of
+// the severity source over the one of NVD and the other sources. When there
+// is no CVSS data the score is derived from the Trivy severity.
+func VulnerabilityScore(v DetectedVulnerability) (float32, string) {
+	var sources []string
+	for s := range v.CVSS {
+		if s != v.SeveritySource && s != "nvd" {
+			sources = append(sources, s)
+		}
+	}
+	sort.Strings(sources)
+	sources = append([]string{v.SeveritySource, "nvd"}, sources...)
+	for _, s := range sources {
+		if c, ok := v.CVSS[s]; ok && c.V3Score > 0 {
+			return c.V3Score, c.V3Vector
+		}
+	}
+	for _, s := range sources {
+		if c, ok := v.CVSS[s]; ok && c.V2Score > 0 {
+			return c.V2Score, c.V2Vector
+		}
+	}
+	return Score(v.Severity), ""
+}
+
+// Convert returns the Vulcan vulnerabilities of a Trivy report:
+//
+//   - One vulnerability per vulnerable package, with a child per
+//     vulnerability of the package and a table of the locations of the
+//     package.
+//   - One vulnerability per failed misconfiguration check, with a table of
+//     the files that failed it.
+//   - One vulnerability per kind of secret, with a table of the files where
+//     it was found.
+//
+// The vulnerabilities are sorted by score and have deterministic
+// fingerprints, so the same scan results produce the same output.
+func Convert(tr Report) []report.Vulnerability {
+	var vulns []report.Vulnerability
+	vulns = append(vulns, packageVulnerabilities(tr.Results)...)
+	vulns = append(vulns, misconfigurationVulnerabilities(tr.Results)...)
+	vulns = append(vulns, secretVulnerabilities(tr.Results)...)
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].Score != vulns[j].Score {
+			return vulns[i].Score > vulns[j].Score
+		}
+		if vulns[i].Summary != vulns[j].Summary {
+			return vulns[i].Summary < vulns[j].Summary
+		}
+		return vulns[i].AffectedResource < vulns[j].AffectedResource
+	})
+	return vulns
+}
+
+// pkg is a vulnerable package and the places it was found.
+type pkg struct {
+	name      string
+	version   string
+	purl      string
+	scheme    string
+	locations [][2]string
+	vulns     map[string]DetectedVulnerability
+}
+
+func packageVulnerabilities(results []Result) []report.Vulnerability {
+	var keys []string
+	pkgs := map[string]*pkg{}
+	for _, res := range results {
+		for _, dv := range res.Vulnerabilities {
+			purl, scheme := packageURL(dv.PkgIdentifier.PURL, res.Type)
+			key := strings.Join([]string{dv.PkgName, dv.InstalledVersion, purl}, "\x00")
+			p, ok := pkgs[key]
+			if !ok {
+				p = &pkg{
+					name:    dv.PkgName,
+					version: dv.InstalledVersion,
+					purl:    purl,
+					scheme:  scheme,
+					vulns:   map[string]DetectedVulnerability{},
+				}
+				pkgs[key] = p
+				keys = append(keys, key)
+			}
+			loc := [2]string{res.Target, dv.PkgPath}
+			if !containsLocation(p.locations, loc) {
+				p.locations = append(p.locations, loc)
+			}
+			if _, ok := p.vulns[dv.VulnerabilityID]; !ok {
+				p.vulns[dv.VulnerabilityID] = dv
+			}
+		}
+	}
+
+	var vulns []report.Vulnerability
+	for _, key := range keys {
+		vulns = append(vulns, packageVulnerability(pkgs[key]))
+	}
+	return vulns
+}
+
+func packageVulnerability(p *pkg) report.Vulnerability {
+	resource, resourceString := p.name+"@"+p.version, p.name+"@"+p.version
+	if p.purl != "" {
+		if purl, err := report.ParsePackageURL(p.purl); err == nil {
+			purl.Version = p.version
+			resource = purl.String()
+		}
+	}
+
+	ids := make([]string, 0, len(p.vulns))
+	for id := range p.vulns {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	v := report.Vulnerability{
+		Summary:                fmt.Sprintf("Vulnerable package %s", p.name),
+		Description:            fmt.Sprintf("The package %s has %d known vulnerabilities.", resourceString, len(ids)),
+		AffectedResource:       resource,
+		AffectedResourceString: resourceString,
+		Fingerprint:            report.Fingerprint(append([]string{LabelVulnerability, resource}, ids...)...),
+		Labels:                 []string{LabelTrivy, LabelVulnerability},
+		Resources: []report.ResourcesGroup{{
+			Name:   "Affected Package",
+			Header: []string{"Name", "Installed Version", "Fixed Version", "Target", "Path"},
+		}},
+	}
+	if p.purl != "" {
+		v.Package = &report.AffectedPackage{PURL: p.purl, InstalledVersion: p.version}
+	}
+
+	var fixes []string
+	for _, id := range ids {
+		child := packageChild(p, v, p.vulns[id])
+		fixes = append(fixes, fixedVersions(p.vulns[id].FixedVersion)...)
+		v.Vulnerabilities = append(v.Vulnerabilities, child)
+	}
+	sort.SliceStable(v.Vulnerabilities, func(i, j int) bool {
+		return v.Vulnerabilities[i].Score > v.Vulnerabilities[j].Score
+	})
+	v.Score = v.Vulnerabilities[0].Score
+
+	fix := ""
+	for _, f := range fixes {
+		if fix == "" || report.CompareVersions(p.scheme, f, fix) > 0 {
+			fix = f
+		}
+	}
+	if fix != "" {
+		v.Recommendations = []string{fmt.Sprintf("Upgrade %s to version %s or later.", p.name, fix)}
+	}
+	for _, loc := range p.locations {
+		v.Resources[0].Rows = append(v.Resources[0].Rows, map[string]string{
+			"Name":              p.name,
+			"Installed Version": p.version,
+			"Fixed Version":     fix,
+			"Target":            loc[0],
+			"Path":              loc[1],
+		})
+	}
+	return v
+}
+
+func packageChild(p *pkg, parent report.Vulnerability, dv DetectedVulnerability) report.Vulnerability {
+	score, vector := VulnerabilityScore(dv)
+	summary := dv.VulnerabilityID
+	if dv.Title != "" {
+		summary += ": " + dv.Title
+	}
+	child := report.Vulnerability{
+		Summary:                summary,
+		Score:                  score,
+		CVSS:                   vector,
+		AffectedResource:       parent.AffectedResource,
+		AffectedResourceString: parent.AffectedResourceString,
+		Fingerprint:            report.Fingerprint(parent.AffectedResource, dv.VulnerabilityID),
+		Description:            dv.Description,
+		Labels:                 parent.Labels,
+	}
+	child.AddReferences(append([]string{dv.PrimaryURL}, dv.References...)...)
+	for _, id := range dv.CweIDs {
+		if n, err := strconv.ParseUint(strings.TrimPrefix(id, "CWE-"), 10, 32); err == nil {
+			child.CWEID = uint32(n)
+			break
+		}
+	}
+	fixes := fixedVersions(dv.FixedVersion)
+	if len(fixes) > 0 {
+		child.Recommendations = []string{fmt.Sprintf("Upgrade %s to version %s.", p.name, strings.Join(fixes, " or "))}
+	}
+	if parent.Package != nil {
+		child.Package = &report.AffectedPackage{
+			PURL:             parent.Package.PURL,
+			InstalledVersion: parent.Package.InstalledVersion,
+			FixedVersions:    fixes,
+		}
+	}
+	return child
+}
+
+// packageURL returns the package URL without version and the versioning
+// scheme of a package.
+func packageURL(s, typ string) (string, string) {
+	if s == "" {
+		return "", typ
+	}
+	purl, err := report.ParsePackageURL(s)
+	if err != nil {
+		return "", typ
+	}
+	purl.Version = ""
+	return purl.String(), purl.Type
+}
+
+func fixedVersions(s string) []string {
+	var fixes []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fixes = append(fixes, f)
+		}
+	}
+	return fixes
+}
+
+func misconfigurationVulnerabilities(results []Result) []report.Vulnerability {
+	var ids []string
+	vulns := map[string]*report.Vulnerability{}
+	for _, res := range results {
+		for _, m := range res.Misconfigurations {
+			if m.Status != "" && m.Status != "FAIL" {
+				continue
+			}
+			id := m.AVDID
+			if id == "" {
+				id = m.ID
+			}
+			v, ok := vulns[id]
+			if !ok {
+				v = &report.Vulnerability{
+					Summary:          m.Title,
+					Score:            Score(m.Severity),
+					AffectedResource: id,
+					Description:      m.Description,
+					Labels:           []string{LabelTrivy, LabelMisconfiguration},
+					Resources: []report.ResourcesGroup{{
+						Name:   "Misconfigurations",
+						Header: []string{"Target", "Resource", "Lines", "Message"},
+					}},
+				}
+				v.AddReferences(append([]string{m.PrimaryURL}, m.References...)...)
+				if m.Resolution != "" {
+					v.Recommendations = []string{m.Resolution}
+				}
+				vulns[id] = v
+				ids = append(ids, id)
+			}
+			v.Resources[0].Rows = append(v.Resources[0].Rows, map[string]string{
+				"Target":   res.Target,
+				"Resource": m.CauseMetadata.Resource,
+				"Lines":    lines(m.CauseMetadata.StartLine, m.CauseMetadata.EndLine),
+				"Message":  m.Message,
+			})
+		}
+	}
+	return finish(ids, vulns)
+}
+
+func secretVulnerabilities(results []Result) []report.Vulnerability {
+	var ids []string
+	vulns := map[string]*report.Vulnerability{}
+	for _, res := range results {
+		for _, s := range res.Secrets {
+			v, ok := vulns[s.RuleID]
+			if !ok {
+				v = &report.Vulnerability{
+					Summary:          fmt.Sprintf("Secret exposed: %s", s.Title),
+					Score:            Score(s.Severity),
+					AffectedResource: s.RuleID,
+					CWEID:            cweHardcodedCredentials,
+					Description:      fmt.Sprintf("A secret of category %s has been found in the target.", s.Category),
+					Recommendations: []string{
+						"Remove the secret from the affected files and their history.",
+						"Revoke the secret and issue a new one.",
+					},
+					Labels: []string{LabelTrivy, LabelSecret},
+					Resources: []report.ResourcesGroup{{
+						Name:   "Secrets",
+						Header: []string{"Target", "Lines", "Match"},
+					}},
+				}
+				vulns[s.RuleID] = v
+				ids = append(ids, s.RuleID)
+			}
+			v.Resources[0].Rows = append(v.Resources[0].Rows, map[string]string{
+				"Target": res.Target,
+				"Lines":  lines(s.StartLine, s.EndLine),
+				"Match":  s.Match,
+			})
+		}
+	}
+	return finish(ids, vulns)
+}
+
+// finish sorts the rows of the resources table of every vulnerability and
+// sets their fingerprints from them.
+func finish(ids []string, vulns map[string]*report.Vulnerability) []report.Vulnerability {
+	var res []report.Vulnerability
+	for _, id := range ids {
+		v := vulns[id]
+		rg := v.Resources[0]
+		sort.SliceStable(rg.Rows, func(i, j int) bool {
+			return rowKey(rg.Header, rg.Rows[i]) < rowKey(rg.Header, rg.Rows[j])
+		})
+		parts := []string{id}
+		for _, row := range rg.Rows {
+			parts = append(parts, rowKey(rg.Header, row))
+		}
+		v.Fingerprint = report.Fingerprint(parts...)
+		res = append(res, *v)
+	}
+	return res
+}
+
+func rowKey(header []string, row map[string]string) string {
+	var values []string
+	for _, h := range header {
+		values = append(values, row[h])
+	}
+	return strings.Join(values, "\x00")
+}
+
+func lines(start, end int) string {
+	switch {
+	case start == 0:
+		return ""
+	case end == 0 || end == start:
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+func containsLocation(locs [][2]string, loc [2]string) bool {
+	for _, l := range locs {
+		if l == loc {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package trivy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+const testTrivyReport = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "alpine:3.10",
+  "ArtifactType": "container_image",
+  "Results": [
+    {
+      "Target": "alpine:3.10 (alpine 3.10.9)",
+      "Class": "os-pkgs",
+      "Type": "alpine",
+      "Vulnerabilities": [
+        {
+          "VulnerabilityID": "CVE-2021-36159",
+          "PkgName": "apk-tools",
+          "PkgIdentifier": {"PURL": "pkg:apk/alpine/apk-tools@2.10.6-r0?arch=x86_64&distro=3.10.9"},
+          "InstalledVersion": "2.10.6-r0",
+          "FixedVersion": "2.10.7-r0",
+          "SeveritySource": "nvd",
+          "PrimaryURL": "https://avd.aquasec.com/nvd/cve-2021-36159",
+          "Title": "apk-tools: heap overflow in libfetch",
+          "Description": "libfetch before 2021-07-26 mishandles numeric strings.",
+          "Severity": "CRITICAL",
+          "CweIDs": ["CWE-125"],
+          "CVSS": {
+            "nvd": {"V2Vector": "AV:N/AC:L/Au:N/C:P/I:N/A:P", "V3Vector": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:H", "V2Score": 6.4, "V3Score": 9.1}
+          },
+          "References": ["https://gitlab.alpinelinux.org/alpine/apk-tools/-/issues/10749", "https://avd.aquasec.com/nvd/cve-2021-36159"]
+        },
+        {
+          "VulnerabilityID": "CVE-2021-30139",
+          "PkgName": "apk-tools",
+          "PkgIdentifier": {"PURL": "pkg:apk/alpine/apk-tools@2.10.6-r0?arch=x86_64&distro=3.10.9"},
+          "InstalledVersion": "2.10.6-r0",
+          "FixedVersion": "2.10.6-r1",
+          "Severity": "HIGH"
+        },
+        {
+          "VulnerabilityID": "CVE-2023-0286",
+          "PkgName": "libcrypto1.1",
+          "PkgIdentifier": {"PURL": "pkg:apk/alpine/libcrypto1.1@1.1.1k-r0?arch=x86_64&distro=3.10.9"},
+          "InstalledVersion": "1.1.1k-r0",
+          "Severity": "MEDIUM",
+          "CVSS": {
+            "redhat": {"V3Vector": "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:H", "V3Score": 7.4}
+          }
+        }
+      ]
+    },
+    {
+      "Target": "Dockerfile",
+      "Class": "config",
+      "Type": "dockerfile",
+      "Misconfigurations": [
+        {
+          "Type": "Dockerfile Security Check",
+          "ID": "DS002",
+          "AVDID": "AVD-DS-0002",
+          "Title": "Image user should not be 'root'",
+          "Description": "Running containers with 'root' user can lead to a container escape situation.",
+          "Message": "Specify at least 1 USER command in Dockerfile with non-root user as argument",
+          "Resolution": "Add 'USER <non root user name>' line to the Dockerfile",
+          "Severity": "HIGH",
+          "PrimaryURL": "https://avd.aquasec.com/misconfig/ds002",
+          "Status": "FAIL",
+          "CauseMetadata": {"Provider": "Dockerfile", "Service": "general"}
+        },
+        {
+          "ID": "DS001",
+          "AVDID": "AVD-DS-0001",
+          "Title": "':latest' tag used",
+          "Severity": "MEDIUM",
+          "Status": "PASS"
+        }
+      ]
+    },
+    {
+      "Target": "/app/.env",
+      "Class": "secret",
+      "Secrets": [
+        {
+          "RuleID": "aws-access-key-id",
+          "Category": "AWS",
+          "Severity": "CRITICAL",
+          "Title": "AWS Access Key ID",
+          "StartLine": 3,
+          "EndLine": 3,
+          "Match": "AWS_ACCESS_KEY_ID=********************"
+        }
+      ]
+    }
+  ]
+}`
+
+func TestImport(t *testing.T) {
+	vulns, err := Import(strings.NewReader(testTrivyReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var summaries []string
+	for _, v := range vulns {
+		summaries = append(summaries, v.Summary)
+		if err := report.ValidateVulnerability(v); err != nil {
+			t.Errorf("invalid vulnerability %q: %s", v.Summary, err)
+		}
+	}
+	wantSummaries := []string{
+		"Secret exposed: AWS Access Key ID",
+		"Vulnerable package apk-tools",
+		"Image user should not be 'root'",
+		"Vulnerable package libcrypto1.1",
+	}
+	if !reflect.DeepEqual(summaries, wantSummaries) {
+		t.Fatalf("summaries do not match: have: %v - want: %v", summaries, wantSummaries)
+	}
+
+	apk := vulns[1]
+	if apk.AffectedResource != "pkg:apk/alpine/apk-tools@2.10.6-r0?arch=x86_64&distro=3.10.9" {
+		t.Errorf("unexpected affected resource: %s", apk.AffectedResource)
+	}
+	if apk.Score != 9.1 || len(apk.Vulnerabilities) != 2 {
+		t.Fatalf("unexpected score or children: %v - %d", apk.Score, len(apk.Vulnerabilities))
+	}
+	child := apk.Vulnerabilities[0]
+	if child.Summary != "CVE-2021-36159: apk-tools: heap overflow in libfetch" || child.CWEID != 125 {
+		t.Errorf("unexpected child: %s - CWE %d", child.Summary, child.CWEID)
+	}
+	if child.CVSS != "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:H" {
+		t.Errorf("unexpected CVSS vector: %s", child.CVSS)
+	}
+	wantRefs := []string{"https://avd.aquasec.com/nvd/cve-2021-36159", "https://gitlab.alpinelinux.org/alpine/apk-tools/-/issues/10749"}
+	if !reflect.DeepEqual(child.References, wantRefs) {
+		t.Errorf("references do not match: have: %v - want: %v", child.References, wantRefs)
+	}
+	if apk.Vulnerabilities[1].Score != report.ScoreSeverity(report.SeverityHigh) {
+		t.Errorf("unexpected score without CVSS data: %v", apk.Vulnerabilities[1].Score)
+	}
+	if want := []string{"Upgrade apk-tools to version 2.10.7-r0 or later."}; !reflect.DeepEqual(apk.Recommendations, want) {
+		t.Errorf("recommendations do not match: have: %v - want: %v", apk.Recommendations, want)
+	}
+	if row := apk.Resources[0].Rows[0]; row["Fixed Version"] != "2.10.7-r0" || row["Target"] != "alpine:3.10 (alpine 3.10.9)" {
+		t.Errorf("unexpected affected package row: %v", row)
+	}
+	if vulns[3].Score != 7.4 {
+		t.Errorf("unexpected score from non NVD source: %v", vulns[3].Score)
+	}
+	if misconf := vulns[2]; misconf.AffectedResource != "AVD-DS-0002" || len(misconf.Resources[0].Rows) != 1 {
+		t.Errorf("unexpected misconfiguration: %+v", misconf)
+	}
+	if secret := vulns[0]; secret.CWEID != 798 || secret.Resources[0].Rows[0]["Lines"] != "3" {
+		t.Errorf("unexpected secret: %+v", secret)
+	}
+}
+
+func TestImportDeterministic(t *testing.T) {
+	a, err := Import(strings.NewReader(testTrivyReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := Import(strings.NewReader(testTrivyReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("imports of the same report differ")
+	}
+	seen := map[string]bool{}
+	for _, v := range a {
+		for _, leaf := range append([]report.Vulnerability{v}, v.Vulnerabilities...) {
+			if leaf.Fingerprint == "" || seen[leaf.Fingerprint] {
+				t.Errorf("missing or duplicated fingerprint for %q", leaf.Summary)
+			}
+			seen[leaf.Fingerprint] = true
+		}
+	}
+}
+
+func TestVulnerabilityScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		v          DetectedVulnerability
+		wantScore  float32
+		wantVector string
+	}{
+		{
+			name: "SeveritySourcePreferred",
+			v: DetectedVulnerability{
+				SeveritySource: "ghsa",
+				CVSS: map[string]CVSS{
+					"nvd":  {V3Score: 9.8, V3Vector: "nvd"},
+					"ghsa": {V3Score: 7.5, V3Vector: "ghsa"},
+				},
+			},
+			wantScore:  7.5,
+			wantVector: "ghsa",
+		},
+		{
+			name: "V3PreferredOverV2",
+			v: DetectedVulnerability{
+				CVSS: map[string]CVSS{
+					"nvd":    {V2Score: 5, V2Vector: "nvd-v2"},
+					"redhat": {V3Score: 6.1, V3Vector: "redhat-v3"},
+				},
+			},
+			wantScore:  6.1,
+			wantVector: "redhat-v3",
+		},
+		{
+			name:       "SeverityFallback",
+			v:          DetectedVulnerability{Severity: "low"},
+			wantScore:  report.ScoreSeverity(report.SeverityLow),
+			wantVector: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, vector := VulnerabilityScore(tt.v)
+			if score != tt.wantScore || vector != tt.wantVector {
+				t.Errorf("score does not match: have: %v %q - want: %v %q", score, vector, tt.wantScore, tt.wantVector)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, data := range []string{"{", `{"SchemaVersion": 1}`} {
+		if _, err := Decode(strings.NewReader(data)); err == nil {
+			t.Errorf("expected error decoding %q", data)
+		}
+	}
+}
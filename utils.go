@@ -6,6 +6,8 @@ package report
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"sort"
 	"time"
 )
@@ -128,8 +130,75 @@ func SecurityStatus(score float32) string {
 	}
 }
 
+// ValidationOptions defines the optional checks performed when validating a
+// Report or a Vulnerability. The zero value disables all of them.
+type ValidationOptions struct {
+	// MaxAttachmentSize is the maximum size in bytes of a single attachment.
+	// Zero means no limit.
+	MaxAttachmentSize int
+	// MaxReportAttachmentsSize is the maximum size in bytes of all the
+	// attachments of a report. Zero means no limit.
+	MaxReportAttachmentsSize int
+	// VerifyAttachmentContentType enables checking that the declared content
+	// type of the attachments matches the sniffed one.
+	VerifyAttachmentContentType bool
+	// ValidateCWEID enables checking that the CWE-ID of the vulnerabilities,
+	// when set, exists in the embedded CWE catalog.
+	ValidateCWEID bool
+	// ValidateTarget enables checking that the target of the report is a
+	// valid asset, see ParseTarget.
+	ValidateTarget bool
+	// ValidateCVSSScore enables checking that the score of the
+	// vulnerabilities with a CVSS vector is its base score. Vectors of
+	// unsupported CVSS versions are not checked.
+	ValidateCVSSScore bool
+	// ValidateParentScore enables checking that the score of the
+	// vulnerabilities with children is the aggregated score of them.
+	ValidateParentScore bool
+	// ScoreAggregation aggregates the scores of the children of a
+	// vulnerability. Nil means AggregateScore.
+	ScoreAggregation func([]Vulnerability) float32
+	// FixScores makes ValidateAndFixReport and ValidateAndFixVulnerability
+	// fix the scores instead of failing: the scores out of range are
+	// clamped, and the scores checked against a CVSS vector or the children
+	// are replaced by the expected ones. Scores that are not a number are
+	// only fixed if they can be computed.
+	FixScores bool
+	// ValidateEndTime enables checking that the reports with a final status
+	// have an end time and the ones with a running status do not, see
+	// IsFinalStatus.
+	ValidateEndTime bool
+	// MaxClockSkew is how far in the future the times of a report can be.
+	// Zero means no limit.
+	MaxClockSkew time.Duration
+	// MaxAge is how far in the past the start time of a report can be. Zero
+	// means no limit.
+	MaxAge time.Duration
+	// MaxDuration is the maximum time a check can take to run. Zero means no
+	// limit.
+	MaxDuration time.Duration
+	// Now returns the current time, used to check MaxClockSkew and MaxAge.
+	// Nil means time.Now.
+	Now func() time.Time
+}
+
+// aggregateScore aggregates the scores of the children of a vulnerability
+// without modifying them.
+func (opts ValidationOptions) aggregateScore(children []Vulnerability) float32 {
+	aggregate := opts.ScoreAggregation
+	if aggregate == nil {
+		aggregate = AggregateScore
+	}
+	return aggregate(append([]Vulnerability(nil), children...))
+}
+
 // ValidateReport validates a Report.
 func ValidateReport(r Report) error {
+	return ValidateReportWithOptions(r, ValidationOptions{})
+}
+
+// ValidateReportWithOptions validates a Report applying the given options.
+func ValidateReportWithOptions(r Report, opts ValidationOptions) error {
 	// Must have basic check information.
 	if r.CheckID == "" {
 		return errors.New("report is missing check ID")
@@ -145,6 +214,11 @@ func ValidateReport(r Report) error {
 	if r.Target == "" {
 		return errors.New("report is missing target")
 	}
+	if opts.ValidateTarget {
+		if _, err := ParseTarget(r.Target); err != nil {
+			return err
+		}
+	}
 	if r.Status == "" {
 		return errors.New("report is missing status")
 	}
@@ -153,29 +227,69 @@ func ValidateReport(r Report) error {
 	if r.StartTime == (time.Time{}) {
 		return errors.New("report is missing start time")
 	}
+	if err := validateTiming(r, opts); err != nil {
+		return err
+	}
 
 	// All vulnerabilities must be valid.
 	for _, v := range r.Vulnerabilities {
-		err := v.Validate()
+		err := ValidateVulnerabilityWithOptions(v, opts)
 		if err != nil {
 			return err
 		}
 	}
 
+	// The attachments of the report as a whole must not exceed the limit.
+	if opts.MaxReportAttachmentsSize > 0 {
+		var size int
+		walkAttachments(r.Vulnerabilities, func(a *Attachment) error {
+			size += a.DataSize()
+			return nil
+		})
+		if size > opts.MaxReportAttachmentsSize {
+			return fmt.Errorf("report attachments size %d exceeds the limit of %d bytes", size, opts.MaxReportAttachmentsSize)
+		}
+	}
+
 	return nil
 }
 
 // ValidateVulnerability validates a Vulnerability.
 func ValidateVulnerability(v Vulnerability) error {
+	return ValidateVulnerabilityWithOptions(v, ValidationOptions{})
+}
+
+// ValidateVulnerabilityWithOptions validates a Vulnerability applying the given options.
+func ValidateVulnerabilityWithOptions(v Vulnerability, opts ValidationOptions) error {
 	if v.Summary == "" {
 		return errors.New("vulnerability group is missing summary")
 	}
 	if v.AffectedResource == "" {
 		return errors.New("vulnerability affected resource is missing")
 	}
+	if err := validateScore(v, opts); err != nil {
+		return err
+	}
+	if opts.ValidateCWEID && v.CWEID != 0 {
+		if _, ok := LookupCWE(v.CWEID); !ok {
+			return fmt.Errorf("vulnerability CWE-ID %d is unknown", v.CWEID)
+		}
+	}
+	if v.Package != nil {
+		if err := v.Package.Validate(); err != nil {
+			return err
+		}
+	}
+	// Validate attachments.
+	for _, a := range v.Attachments {
+		err := ValidateAttachment(a, opts)
+		if err != nil {
+			return err
+		}
+	}
 	// Validate vulnerabilities.
 	for _, vulnerability := range v.Vulnerabilities {
-		err := vulnerability.Validate()
+		err := ValidateVulnerabilityWithOptions(vulnerability, opts)
 		if err != nil {
 			return err
 		}
@@ -187,3 +301,100 @@ func ValidateVulnerability(v Vulnerability) error {
 
 	return nil
 }
+
+// ValidateAndFixReport validates a Report applying the given options. If
+// opts.FixScores is set, the scores of the vulnerabilities are fixed, when
+// possible, before validating it.
+func ValidateAndFixReport(r *Report, opts ValidationOptions) error {
+	if opts.FixScores {
+		for i := range r.Vulnerabilities {
+			fixScore(&r.Vulnerabilities[i], opts)
+		}
+	}
+	return ValidateReportWithOptions(*r, opts)
+}
+
+// ValidateAndFixVulnerability validates a Vulnerability applying the given
+// options. If opts.FixScores is set, the scores of the vulnerability and its
+// children are fixed, when possible, before validating it.
+func ValidateAndFixVulnerability(v *Vulnerability, opts ValidationOptions) error {
+	if opts.FixScores {
+		fixScore(v, opts)
+	}
+	return ValidateVulnerabilityWithOptions(*v, opts)
+}
+
+// validateScore checks the score of a vulnerability is a number from 0 to
+// 10 and, if enabled in the options, is consistent with its CVSS vector and
+// its children.
+func validateScore(v Vulnerability, opts ValidationOptions) error {
+	if err := checkScoreRange(v.Score); err != nil {
+		return err
+	}
+	if opts.ValidateCVSSScore && v.CVSS != "" {
+		score, err := CVSSBaseScore(v.CVSS)
+		if err != nil && !errors.Is(err, ErrUnsupportedCVSSVersion) {
+			return fmt.Errorf("vulnerability CVSS vector %q is invalid: %w", v.CVSS, err)
+		}
+		if err == nil && !equalScores(v.Score, score) {
+			return fmt.Errorf("vulnerability score %.1f does not match the base score %.1f of its CVSS vector", v.Score, score)
+		}
+	}
+	if opts.ValidateParentScore && len(v.Vulnerabilities) > 0 {
+		if score := opts.aggregateScore(v.Vulnerabilities); !equalScores(v.Score, score) {
+			return fmt.Errorf("vulnerability score %.1f does not match the aggregated score %.1f of its children", v.Score, score)
+		}
+	}
+	return nil
+}
+
+func checkScoreRange(score float32) error {
+	if math.IsNaN(float64(score)) || math.IsInf(float64(score), 0) {
+		return fmt.Errorf("vulnerability score %v is not a finite number", score)
+	}
+	if score < 0 || score > 10 {
+		return fmt.Errorf("vulnerability score %v is out of the range from 0 to 10", score)
+	}
+	return nil
+}
+
+// fixScore fixes the scores of a vulnerability and its children, see
+// ValidationOptions.FixScores.
+func fixScore(v *Vulnerability, opts ValidationOptions) {
+	for i := range v.Vulnerabilities {
+		fixScore(&v.Vulnerabilities[i], opts)
+	}
+	if opts.ValidateParentScore && len(v.Vulnerabilities) > 0 {
+		v.Score = opts.aggregateScore(v.Vulnerabilities)
+		return
+	}
+	if v.CVSS != "" && (opts.ValidateCVSSScore || checkScoreRange(v.Score) != nil) {
+		if score, err := CVSSBaseScore(v.CVSS); err == nil {
+			v.Score = score
+			return
+		}
+	}
+	switch {
+	case v.Score < 0:
+		v.Score = 0
+	case v.Score > 10:
+		v.Score = 10
+	}
+}
+
+// ValidateAttachment validates an Attachment applying the given options.
+func ValidateAttachment(a Attachment, opts ValidationOptions) error {
+	if a.Digest != "" && !validDigest(a.Digest) {
+		return fmt.Errorf("attachment %q has an invalid digest", a.Name)
+	}
+	if a.Digest != "" && len(a.Data) > 0 && Digest(a.Data) != a.Digest {
+		return fmt.Errorf("attachment %q digest does not match its data", a.Name)
+	}
+	if opts.MaxAttachmentSize > 0 && a.DataSize() > opts.MaxAttachmentSize {
+		return fmt.Errorf("attachment %q size %d exceeds the limit of %d bytes", a.Name, a.DataSize(), opts.MaxAttachmentSize)
+	}
+	if opts.VerifyAttachmentContentType {
+		return a.VerifyContentType()
+	}
+	return nil
+}
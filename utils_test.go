@@ -1,6 +1,10 @@
 package report
 
-import "testing"
+import (
+	"math"
+	"reflect"
+	"testing"
+)
 
 func TestVulnerabilityRank(t *testing.T) {
 	tests := []struct {
@@ -147,3 +151,150 @@ func TestAggregateScoreWithNoVulnerabilities(t *testing.T) {
 		t.Errorf("unexpected score for empty vulnerability array: have: %f.2 - want: %f.2", score, 0.0)
 	}
 }
+
+func TestValidateScore(t *testing.T) {
+	vuln := func(score float32, vector string, children ...float32) Vulnerability {
+		v := Vulnerability{Summary: "vulnerability", AffectedResource: "resource", Score: score, CVSS: vector}
+		for _, c := range children {
+			v.Vulnerabilities = append(v.Vulnerabilities, vulnerabilityWithScore(c))
+		}
+		return v
+	}
+	all := ValidationOptions{ValidateCVSSScore: true, ValidateParentScore: true}
+
+	tests := []struct {
+		name      string
+		v         Vulnerability
+		opts      ValidationOptions
+		errString string
+	}{
+		{
+			name: "valid",
+			v:    vuln(9.8, "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"),
+			opts: all,
+		},
+		{
+			name:      "negative",
+			v:         vuln(-3, ""),
+			errString: "vulnerability score -3 is out of the range from 0 to 10",
+		},
+		{
+			name:      "too high",
+			v:         vuln(42, ""),
+			errString: "vulnerability score 42 is out of the range from 0 to 10",
+		},
+		{
+			name:      "not a number",
+			v:         vuln(float32(math.NaN()), ""),
+			errString: "vulnerability score NaN is not a finite number",
+		},
+		{
+			name:      "infinite",
+			v:         vuln(float32(math.Inf(1)), ""),
+			errString: "vulnerability score +Inf is not a finite number",
+		},
+		{
+			name: "vector not checked",
+			v:    vuln(5, "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"),
+		},
+		{
+			name:      "vector mismatch",
+			v:         vuln(5, "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"),
+			opts:      all,
+			errString: "vulnerability score 5.0 does not match the base score 9.8 of its CVSS vector",
+		},
+		{
+			name:      "invalid vector",
+			v:         vuln(5, "CVSS:3.1/AV:N"),
+			opts:      all,
+			errString: `vulnerability CVSS vector "CVSS:3.1/AV:N" is invalid: missing CVSS metric "AC"`,
+		},
+		{
+			name: "unsupported vector",
+			v:    vuln(5, "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"),
+			opts: all,
+		},
+		{
+			name: "parent not checked",
+			v:    vuln(3.9, "", 8.9, 6.9),
+		},
+		{
+			name:      "parent lower than children",
+			v:         vuln(3.9, "", 8.9, 6.9),
+			opts:      all,
+			errString: "vulnerability score 3.9 does not match the aggregated score 8.9 of its children",
+		},
+		{
+			name: "custom aggregation",
+			v:    vuln(3.9, "", 8.9, 3.9),
+			opts: ValidationOptions{
+				ValidateParentScore: true,
+				ScoreAggregation: func(children []Vulnerability) float32 {
+					return children[len(children)-1].Score
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			children := append([]Vulnerability(nil), tt.v.Vulnerabilities...)
+			err := ValidateVulnerabilityWithOptions(tt.v, tt.opts)
+			errString := ""
+			if err != nil {
+				errString = err.Error()
+			}
+			if errString != tt.errString {
+				t.Errorf("got error %q, want %q", errString, tt.errString)
+			}
+			if !reflect.DeepEqual(tt.v.Vulnerabilities, children) {
+				t.Errorf("validation modified the children")
+			}
+		})
+	}
+}
+
+func TestValidateAndFixReport(t *testing.T) {
+	r := Report{
+		CheckData: cd0,
+		ResultData: ResultData{
+			Vulnerabilities: []Vulnerability{
+				{
+					Summary:          "parent",
+					AffectedResource: "resource",
+					Score:            0,
+					Vulnerabilities: []Vulnerability{
+						{Summary: "child", AffectedResource: "a", Score: 5, CVSS: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+						{Summary: "child", AffectedResource: "b", Score: 42},
+					},
+				},
+				{Summary: "negative", AffectedResource: "resource", Score: -1},
+				{Summary: "infinite", AffectedResource: "resource", Score: float32(math.Inf(-1))},
+				{Summary: "not a number", AffectedResource: "resource", Score: float32(math.NaN()), CVSS: "AV:N/AC:L/Au:N/C:P/I:P/A:N"},
+			},
+		},
+	}
+	opts := ValidationOptions{ValidateCVSSScore: true, ValidateParentScore: true, FixScores: true}
+	if err := ValidateAndFixReport(&r, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []float32
+	for _, v := range r.Vulnerabilities {
+		got = append(got, v.Score)
+		for _, c := range v.Vulnerabilities {
+			got = append(got, c.Score)
+		}
+	}
+	want := []float32{10, 9.8, 10, 0, 0, 6.4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got scores %v, want %v", got, want)
+	}
+
+	v := Vulnerability{Summary: "not a number", AffectedResource: "resource", Score: float32(math.NaN())}
+	if err := ValidateAndFixVulnerability(&v, opts); err == nil {
+		t.Error("got no error for a score that can not be fixed")
+	}
+	v = Vulnerability{Summary: "too high", AffectedResource: "resource", Score: 42}
+	if err := ValidateAndFixVulnerability(&v, ValidationOptions{}); err == nil {
+		t.Error("got no error without fixing the scores")
+	}
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2019 Adevinta
+*/
+
+// Package zap imports the traditional JSON and XML reports of OWASP ZAP as
+// Vulcan vulnerabilities.
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+// Risk codes of the ZAP alerts.
+const (
+	RiskInformational = 0
+	RiskLow           = 1
+	RiskMedium        = 2
+	RiskHigh          = 3
+)
+
+// Confidence codes of the ZAP alerts.
+const (
+	ConfidenceFalsePositive = 0
+	ConfidenceLow           = 1
+	ConfidenceMedium        = 2
+	ConfidenceHigh          = 3
+	ConfidenceConfirmed     = 4
+)
+
+// LabelZAP is the label added to the imported vulnerabilities, along with
+// labels for their risk, confidence and WASC ID, e.g.: "risk:medium",
+// "confidence:high" and "wasc:15".
+const LabelZAP = "zap"
+
+var (
+	riskNames       = []string{"informational", "low", "medium", "high"}
+	confidenceNames = []string{"false-positive", "low", "medium", "high", "confirmed"}
+)
+
+// Report is a traditional ZAP report. The same types decode the JSON and the
+// XML formats.
+type Report struct {
+	XMLName     xml.Name `json:"-" xml:"OWASPZAPReport"`
+	ProgramName string   `json:"@programName" xml:"programName,attr"`
+	Version     string   `json:"@version" xml:"version,attr"`
+	Generated   string   `json:"@generated" xml:"generated,attr"`
+	Sites       []Site   `json:"site" xml:"site"`
+}
+
+// Site contains the alerts raised for a site.
+type Site struct {
+	Name   string  `json:"@name" xml:"name,attr"`
+	Host   string  `json:"@host" xml:"host,attr"`
+	Port   string  `json:"@port" xml:"port,attr"`
+	SSL    string  `json:"@ssl" xml:"ssl,attr"`
+	Alerts []Alert `json:"alerts" xml:"alerts>alertitem"`
+}
+
+// Alert is a ZAP alert. Numeric fields are encoded as strings in both
+// formats.
+type Alert struct {
+	PluginID   string     `json:"pluginid" xml:"pluginid"`
+	AlertRef   string     `json:"alertRef" xml:"alertRef"`
+	Alert      string     `json:"alert" xml:"alert"`
+	Name       string     `json:"name" xml:"name"`
+	RiskCode   string     `json:"riskcode" xml:"riskcode"`
+	Confidence string     `json:"confidence" xml:"confidence"`
+	RiskDesc   string     `json:"riskdesc" xml:"riskdesc"`
+	Desc       string     `json:"desc" xml:"desc"`
+	Instances  []Instance `json:"instances" xml:"instances>instance"`
+	Solution   string     `json:"solution" xml:"solution"`
+	OtherInfo  string     `json:"otherinfo" xml:"otherinfo"`
+	Reference  string     `json:"reference" xml:"reference"`
+	CWEID      string     `json:"cweid" xml:"cweid"`
+	WASCID     string     `json:"wascid" xml:"wascid"`
+}
+
+// Instance is an occurrence of an alert.
+type Instance struct {
+	URI       string `json:"uri" xml:"uri"`
+	Method    string `json:"method" xml:"method"`
+	Param     string `json:"param" xml:"param"`
+	Attack    string `json:"attack" xml:"attack"`
+	Evidence  string `json:"evidence" xml:"evidence"`
+	OtherInfo string `json:"otherinfo" xml:"otherinfo"`
+}
+
+// DecodeJSON reads a traditional JSON ZAP report.
+func DecodeJSON(r io.Reader) (Report, error) {
+	var zr Report
+	if err := json.NewDecoder(r).Decode(&zr); err != nil {
+		return Report{}, fmt.Errorf("invalid zap json report: %w", err)
+	}
+	return zr, nil
+}
+
+// DecodeXML reads a traditional XML ZAP report.
+func DecodeXML(r io.Reader) (Report, error) {
+	var zr Report
+	if err := xml.NewDecoder(r).Decode(&zr); err != nil {
+		return Report{}, fmt.Errorf("invalid zap xml report: %w", err)
+	}
+	return zr, nil
+}
+
+// Decode reads a traditional ZAP report, detecting whether it is in JSON or
+// XML format.
+func Decode(r io.Reader) (Report, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Report{}, fmt.Errorf("invalid zap report: %w", err)
+	}
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		return DecodeJSON(bytes.NewReader(data))
+	case bytes.HasPrefix(data, []byte("<")):
+		return DecodeXML(bytes.NewReader(data))
+	}
+	return Report{}, errors.New("invalid zap report: unknown format")
+}
+
+// Import reads a traditional ZAP report, in JSON or XML format, and converts
+// it to Vulcan vulnerabilities, see Convert.
+func Import(r io.Reader) ([]report.Vulnerability, error) {
+	zr, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(zr)
+}
+
+// Score returns the score of an alert from its risk and confidence. The
+// score is the maximum of the severity rank of the risk, lowered one rank
+// when the confidence is low. Informational alerts score 0.
+func Score(risk, confidence int) float32 {
+	rank := report.SeverityNone
+	switch risk {
+	case RiskLow:
+		rank = report.SeverityLow
+	case RiskMedium:
+		rank = report.SeverityMedium
+	case RiskHigh:
+		rank = report.SeverityHigh
+	}
+	if confidence == ConfidenceLow && rank > report.SeverityLow {
+		rank--
+	}
+	return report.ScoreSeverity(rank)
+}
+
+// Convert returns a vulnerability for every alert of every site of the
+// report, with one child per instance of the alert and a table of the
+// instances. Alerts marked as false positives are kept but labeled as
+// suppressed. The vulnerabilities are sorted by score.
+func Convert(zr Report) ([]report.Vulnerability, error) {
+	var vulns []report.Vulnerability
+	for _, site := range zr.Sites {
+		for _, a := range site.Alerts {
+			v, err := convertAlert(site, a)
+			if err != nil {
+				return nil, err
+			}
+			vulns = append(vulns, v)
+		}
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		return vulns[i].Score > vulns[j].Score
+	})
+	return vulns, nil
+}
+
+func convertAlert(site Site, a Alert) (report.Vulnerability, error) {
+	risk, err := code(a.RiskCode, len(riskNames))
+	if err != nil {
+		return report.Vulnerability{}, fmt.Errorf("alert %s: invalid riskcode: %w", a.PluginID, err)
+	}
+	confidence, err := code(a.Confidence, len(confidenceNames))
+	if err != nil {
+		return report.Vulnerability{}, fmt.Errorf("alert %s: invalid confidence: %w", a.PluginID, err)
+	}
+	summary := a.Alert
+	if summary == "" {
+		summary = a.Name
+	}
+	ref := a.AlertRef
+	if ref == "" {
+		ref = a.PluginID
+	}
+
+	v := report.Vulnerability{
+		Summary:          summary,
+		Score:            Score(risk, confidence),
+		AffectedResource: site.Name,
+		Description:      strings.Join(paragraphs(a.Desc), "\n\n"),
+		Details:          strings.Join(paragraphs(a.OtherInfo), "\n\n"),
+		Recommendations:  paragraphs(a.Solution),
+		References:       paragraphs(a.Reference),
+		Labels: []string{
+			LabelZAP,
+			"risk:" + riskNames[risk],
+			"confidence:" + confidenceNames[confidence],
+		},
+	}
+	if n, err := strconv.ParseUint(a.CWEID, 10, 32); err == nil && n > 0 {
+		v.CWEID = uint32(n)
+	}
+	if n, err := strconv.Atoi(a.WASCID); err == nil && n > 0 {
+		v.Labels = append(v.Labels, "wasc:"+strconv.Itoa(n))
+	}
+	if confidence == ConfidenceFalsePositive {
+		v.Labels = append(v.Labels, report.LabelSuppressed)
+	}
+
+	rg := report.ResourcesGroup{
+		Name:   "Instances",
+		Header: []string{"URL", "Method", "Parameter", "Attack", "Evidence", "Other Info"},
+	}
+	keys := []string{ref, site.Name}
+	for _, in := range a.Instances {
+		child := report.Vulnerability{
+			Summary:                summary,
+			Score:                  v.Score,
+			AffectedResource:       in.URI,
+			AffectedResourceString: strings.TrimSpace(in.Method + " " + in.URI),
+			Details:                instanceDetails(in),
+			Fingerprint:            report.Fingerprint(ref, in.Method, in.URI, in.Param),
+		}
+		v.Vulnerabilities = append(v.Vulnerabilities, child)
+		rg.Rows = append(rg.Rows, map[string]string{
+			"URL":        in.URI,
+			"Method":     in.Method,
+			"Parameter":  in.Param,
+			"Attack":     in.Attack,
+			"Evidence":   in.Evidence,
+			"Other Info": in.OtherInfo,
+		})
+		keys = append(keys, child.Fingerprint)
+	}
+	if len(rg.Rows) > 0 {
+		v.Resources = []report.ResourcesGroup{rg}
+	}
+	sort.Strings(keys[2:])
+	v.Fingerprint = report.Fingerprint(keys...)
+	return v, nil
+}
+
+func instanceDetails(in Instance) string {
+	var lines []string
+	for _, f := range []struct{ name, value string }{
+		{"Parameter", in.Param},
+		{"Attack", in.Attack},
+		{"Evidence", in.Evidence},
+		{"Other info", in.OtherInfo},
+	} {
+		if f.value != "" {
+			lines = append(lines, f.name+": "+f.value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func code(s string, n int) (int, error) {
+	c, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	if c < 0 || c >= n {
+		return 0, fmt.Errorf("%d out of range", c)
+	}
+	return c, nil
+}
+
+var (
+	paragraphRegexp = regexp.MustCompile(`(?i)</p>|<br\s*/?>`)
+	tagRegexp       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// paragraphs returns the text of the paragraphs of a ZAP HTML field.
+func paragraphs(s string) []string {
+	var res []string
+	for _, p := range paragraphRegexp.Split(s, -1) {
+		p = strings.TrimSpace(html.UnescapeString(tagRegexp.ReplaceAllString(p, "")))
+		if p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
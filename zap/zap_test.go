@@ -0,0 +1,232 @@
+package zap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	report "github.com/adevinta/vulcan-report"
+)
+
+const testJSONReport = `{
+  "@programName": "ZAP",
+  "@version": "2.14.0",
+  "@generated": "Mon, 18 Mar 2024 10:00:00",
+  "site": [
+    {
+      "@name": "https://example.com",
+      "@host": "example.com",
+      "@port": "443",
+      "@ssl": "true",
+      "alerts": [
+        {
+          "pluginid": "10038",
+          "alertRef": "10038-1",
+          "alert": "Content Security Policy (CSP) Header Not Set",
+          "name": "Content Security Policy (CSP) Header Not Set",
+          "riskcode": "2",
+          "confidence": "3",
+          "riskdesc": "Medium (High)",
+          "desc": "<p>Content Security Policy (CSP) is an added layer of security.</p>",
+          "instances": [
+            {"uri": "https://example.com/", "method": "GET", "param": "", "attack": "", "evidence": "", "otherinfo": ""},
+            {"uri": "https://example.com/login", "method": "POST", "param": "", "attack": "", "evidence": "", "otherinfo": ""}
+          ],
+          "count": "2",
+          "solution": "<p>Ensure that your web server sets the Content-Security-Policy header.</p>",
+          "otherinfo": "",
+          "reference": "<p>https://developer.mozilla.org/en-US/docs/Web/Security/CSP</p><p>https://www.w3.org/TR/CSP/</p>",
+          "cweid": "693",
+          "wascid": "15",
+          "sourceid": "1"
+        },
+        {
+          "pluginid": "40012",
+          "alertRef": "40012",
+          "alert": "Cross Site Scripting (Reflected)",
+          "riskcode": "3",
+          "confidence": "1",
+          "desc": "<p>Cross-site Scripting (XSS) is an attack technique.</p>",
+          "instances": [
+            {"uri": "https://example.com/search?q=%3Cscript%3E", "method": "GET", "param": "q", "attack": "<script>alert(1);</script>", "evidence": "<script>alert(1);</script>", "otherinfo": ""}
+          ],
+          "solution": "<p>Validate all input.</p>",
+          "reference": "<p>https://owasp.org/www-community/attacks/xss/</p>",
+          "cweid": "79",
+          "wascid": "8"
+        },
+        {
+          "pluginid": "10096",
+          "alert": "Timestamp Disclosure - Unix",
+          "riskcode": "0",
+          "confidence": "0",
+          "desc": "<p>A timestamp was disclosed by the application.</p>",
+          "instances": [],
+          "cweid": "200",
+          "wascid": "13"
+        }
+      ]
+    }
+  ]
+}`
+
+const testXMLReport = `<?xml version="1.0"?>
+<OWASPZAPReport programName="ZAP" version="2.14.0" generated="Mon, 18 Mar 2024 10:00:00">
+  <site name="https://example.com" host="example.com" port="443" ssl="true">
+    <alerts>
+      <alertitem>
+        <pluginid>10038</pluginid>
+        <alertRef>10038-1</alertRef>
+        <alert>Content Security Policy (CSP) Header Not Set</alert>
+        <name>Content Security Policy (CSP) Header Not Set</name>
+        <riskcode>2</riskcode>
+        <confidence>3</confidence>
+        <riskdesc>Medium (High)</riskdesc>
+        <desc>&lt;p&gt;Content Security Policy (CSP) is an added layer of security.&lt;/p&gt;</desc>
+        <instances>
+          <instance><uri>https://example.com/</uri><method>GET</method><param></param><attack></attack><evidence></evidence><otherinfo></otherinfo></instance>
+          <instance><uri>https://example.com/login</uri><method>POST</method><param></param><attack></attack><evidence></evidence><otherinfo></otherinfo></instance>
+        </instances>
+        <count>2</count>
+        <solution>&lt;p&gt;Ensure that your web server sets the Content-Security-Policy header.&lt;/p&gt;</solution>
+        <otherinfo></otherinfo>
+        <reference>&lt;p&gt;https://developer.mozilla.org/en-US/docs/Web/Security/CSP&lt;/p&gt;&lt;p&gt;https://www.w3.org/TR/CSP/&lt;/p&gt;</reference>
+        <cweid>693</cweid>
+        <wascid>15</wascid>
+        <sourceid>1</sourceid>
+      </alertitem>
+      <alertitem>
+        <pluginid>40012</pluginid>
+        <alertRef>40012</alertRef>
+        <alert>Cross Site Scripting (Reflected)</alert>
+        <riskcode>3</riskcode>
+        <confidence>1</confidence>
+        <desc>&lt;p&gt;Cross-site Scripting (XSS) is an attack technique.&lt;/p&gt;</desc>
+        <instances>
+          <instance><uri>https://example.com/search?q=%3Cscript%3E</uri><method>GET</method><param>q</param><attack>&lt;script&gt;alert(1);&lt;/script&gt;</attack><evidence>&lt;script&gt;alert(1);&lt;/script&gt;</evidence><otherinfo></otherinfo></instance>
+        </instances>
+        <solution>&lt;p&gt;Validate all input.&lt;/p&gt;</solution>
+        <reference>&lt;p&gt;https://owasp.org/www-community/attacks/xss/&lt;/p&gt;</reference>
+        <cweid>79</cweid>
+        <wascid>8</wascid>
+      </alertitem>
+      <alertitem>
+        <pluginid>10096</pluginid>
+        <alert>Timestamp Disclosure - Unix</alert>
+        <riskcode>0</riskcode>
+        <confidence>0</confidence>
+        <desc>&lt;p&gt;A timestamp was disclosed by the application.&lt;/p&gt;</desc>
+        <instances></instances>
+        <cweid>200</cweid>
+        <wascid>13</wascid>
+      </alertitem>
+    </alerts>
+  </site>
+</OWASPZAPReport>`
+
+func TestImport(t *testing.T) {
+	vulns, err := Import(strings.NewReader(testJSONReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(vulns) != 3 {
+		t.Fatalf("unexpected number of vulnerabilities: %d", len(vulns))
+	}
+	for _, v := range vulns {
+		if err := report.ValidateVulnerability(v); err != nil {
+			t.Errorf("invalid vulnerability %q: %s", v.Summary, err)
+		}
+	}
+
+	csp := vulns[0]
+	if csp.Summary != "Content Security Policy (CSP) Header Not Set" || csp.Score != report.ScoreSeverity(report.SeverityMedium) {
+		t.Fatalf("unexpected vulnerability: %s - %v", csp.Summary, csp.Score)
+	}
+	if want := []string{"zap", "risk:medium", "confidence:high", "wasc:15"}; !reflect.DeepEqual(csp.Labels, want) {
+		t.Errorf("labels do not match: have: %v - want: %v", csp.Labels, want)
+	}
+	if want := []string{"https://developer.mozilla.org/en-US/docs/Web/Security/CSP", "https://www.w3.org/TR/CSP/"}; !reflect.DeepEqual(csp.References, want) {
+		t.Errorf("references do not match: have: %v - want: %v", csp.References, want)
+	}
+	if csp.CWEID != 693 || csp.Description != "Content Security Policy (CSP) is an added layer of security." {
+		t.Errorf("unexpected CWE or description: %d - %q", csp.CWEID, csp.Description)
+	}
+	if len(csp.Vulnerabilities) != 2 || len(csp.Resources) != 1 || len(csp.Resources[0].Rows) != 2 {
+		t.Fatalf("unexpected instances: %d children - %+v", len(csp.Vulnerabilities), csp.Resources)
+	}
+	if child := csp.Vulnerabilities[1]; child.AffectedResourceString != "POST https://example.com/login" {
+		t.Errorf("unexpected child affected resource: %s", child.AffectedResourceString)
+	}
+
+	// The low confidence lowers the high risk alert one rank.
+	xss := vulns[1]
+	if xss.Score != report.ScoreSeverity(report.SeverityMedium) {
+		t.Errorf("unexpected score: %v", xss.Score)
+	}
+	wantDetails := "Parameter: q\nAttack: <script>alert(1);</script>\nEvidence: <script>alert(1);</script>"
+	if xss.Vulnerabilities[0].Details != wantDetails {
+		t.Errorf("details do not match: have: %q - want: %q", xss.Vulnerabilities[0].Details, wantDetails)
+	}
+
+	timestamp := vulns[2]
+	if !timestamp.Suppressed() || timestamp.Score != 0 || len(timestamp.Resources) != 0 {
+		t.Errorf("unexpected false positive: %+v", timestamp)
+	}
+}
+
+func TestImportFormatsMatch(t *testing.T) {
+	fromJSON, err := Import(strings.NewReader(testJSONReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	fromXML, err := Import(strings.NewReader(testXMLReport))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromXML) {
+		t.Errorf("json and xml imports differ:\njson: %+v\nxml: %+v", fromJSON, fromXML)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Empty", "  "},
+		{"UnknownFormat", "site: []"},
+		{"MalformedJSON", `{"site": [`},
+		{"InvalidRisk", `{"site": [{"@name": "https://example.com", "alerts": [{"alert": "a", "riskcode": "7", "confidence": "1"}]}]}`},
+		{"InvalidConfidence", `{"site": [{"@name": "https://example.com", "alerts": [{"alert": "a", "riskcode": "1", "confidence": "x"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Import(strings.NewReader(tt.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name             string
+		risk, confidence int
+		want             float32
+	}{
+		{"Informational", RiskInformational, ConfidenceHigh, 0},
+		{"LowLowConfidence", RiskLow, ConfidenceLow, report.ScoreSeverity(report.SeverityLow)},
+		{"MediumConfirmed", RiskMedium, ConfidenceConfirmed, report.ScoreSeverity(report.SeverityMedium)},
+		{"HighMediumConfidence", RiskHigh, ConfidenceMedium, report.ScoreSeverity(report.SeverityHigh)},
+		{"HighLowConfidence", RiskHigh, ConfidenceLow, report.ScoreSeverity(report.SeverityMedium)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.risk, tt.confidence); got != tt.want {
+				t.Errorf("score does not match: have: %v - want: %v", got, tt.want)
+			}
+		})
+	}
+}